@@ -0,0 +1,248 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"iast-auto-inject/internal/core/detector"
+	"iast-auto-inject/internal/core/store"
+	"iast-auto-inject/internal/pkg/logger"
+	"iast-auto-inject/internal/pkg/procfs"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var (
+	agentsRoot     string
+	agentsName     string
+	agentsVersion  string
+	agentsActivate bool
+	agentsDryRun   bool
+)
+
+// agentsCmd agents 命令
+var agentsCmd = &cobra.Command{
+	Use:   "agents",
+	Short: "管理本地版本化 agent 仓库",
+	Long: `管理本地 agent 仓库，每个版本存放在独立目录中，通过原子切换 current 符号链接激活版本，
+避免在 JVM 持有 jar 时原地覆盖文件`,
+}
+
+// agentsInstallCmd agents install 命令
+var agentsInstallCmd = &cobra.Command{
+	Use:   "install <jar>",
+	Short: "安装 agent jar 到仓库",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runAgentsInstall,
+}
+
+// agentsListCmd agents list 命令
+var agentsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "列出仓库中的 agent 版本",
+	RunE:  runAgentsList,
+}
+
+// agentsActivateCmd agents activate 命令
+var agentsActivateCmd = &cobra.Command{
+	Use:   "activate <version>",
+	Short: "激活指定 agent 版本（原子切换 current 链接）",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runAgentsActivate,
+}
+
+// agentsGCCmd agents gc 命令
+var agentsGCCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "删除未激活且未被运行中 JVM 引用的版本",
+	Long: `删除未激活且未被运行中 JVM 引用的版本。
+
+引用包括 JVM 命令行和 JAVA_TOOL_OPTIONS 中的 agent 路径，以及 JVM 当前打开的文件。
+无法读取某个 JVM 打开的文件时（如非 root 用户检查其他用户的进程）不删除任何版本。`,
+	RunE: runAgentsGC,
+}
+
+func init() {
+	rootCmd.AddCommand(agentsCmd)
+	agentsCmd.AddCommand(agentsInstallCmd, agentsListCmd, agentsActivateCmd, agentsGCCmd)
+
+	agentsCmd.PersistentFlags().StringVar(&agentsRoot, "root", "", "仓库根目录（默认使用配置文件中的值）")
+	agentsCmd.PersistentFlags().StringVar(&agentsName, "name", "", "agent 名称（默认根据 jar 文件名生成，如 secpoint）")
+
+	agentsInstallCmd.Flags().StringVar(&agentsVersion, "version", "", "版本号（默认读取 jar MANIFEST）")
+	agentsInstallCmd.Flags().BoolVar(&agentsActivate, "activate", false, "安装后立即激活")
+
+	agentsGCCmd.Flags().BoolVarP(&agentsDryRun, "dry-run", "n", false, "只显示将被删除的版本")
+}
+
+// newAgentStore 创建 agent 仓库
+func newAgentStore() *store.AgentStore {
+	root := agentsRoot
+	if root == "" && GetConfig().Store != nil {
+		root = GetConfig().Store.Root
+	}
+	return store.NewAgentStore(root)
+}
+
+// agentName 获取命令行指定的 agent 名称，默认为 SecPoint
+func agentName() string {
+	if agentsName != "" {
+		return agentsName
+	}
+	return detector.SecPointAgentName
+}
+
+func runAgentsInstall(cmd *cobra.Command, args []string) error {
+	s := newAgentStore()
+
+	v, err := s.Install(args[0], agentsName, agentsVersion)
+	if err != nil {
+		return fmt.Errorf("failed to install agent: %w", err)
+	}
+
+	color.Green("Installed %s %s", v.Name, v.Version)
+	fmt.Printf("  Path:   %s\n", v.Path)
+	fmt.Printf("  SHA256: %s\n", v.SHA256)
+
+	if agentsActivate {
+		if _, err := s.Activate(v.Name, v.Version); err != nil {
+			return fmt.Errorf("failed to activate agent: %w", err)
+		}
+		current, _ := s.CurrentPath(v.Name)
+		color.Green("Activated %s %s", v.Name, v.Version)
+		fmt.Printf("  Use:    %s\n", current)
+	}
+
+	return nil
+}
+
+func runAgentsList(cmd *cobra.Command, args []string) error {
+	s := newAgentStore()
+
+	versions, err := s.List(agentsName)
+	if err != nil {
+		return fmt.Errorf("failed to list agents: %w", err)
+	}
+
+	if len(versions) == 0 {
+		color.Yellow("No agents installed in %s", s.Root())
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Name\tVersion\tActive\tInstalled\tSHA256\tPath")
+
+	green := color.New(color.FgGreen).SprintFunc()
+
+	for _, v := range versions {
+		active := ""
+		if v.Active {
+			active = green("*")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			v.Name, v.Version, active,
+			v.InstalledAt.Format("2006-01-02 15:04:05"),
+			v.SHA256[:12], v.Path)
+	}
+
+	w.Flush()
+
+	return nil
+}
+
+func runAgentsActivate(cmd *cobra.Command, args []string) error {
+	s := newAgentStore()
+
+	v, err := s.Activate(agentName(), args[0])
+	if err != nil {
+		return fmt.Errorf("failed to activate agent: %w", err)
+	}
+
+	current, _ := s.CurrentPath(v.Name)
+	color.Green("Activated %s %s", v.Name, v.Version)
+	fmt.Printf("  Use: %s\n", current)
+	fmt.Println("  Running JVMs keep the version they loaded until restarted (see 'upgrade').")
+
+	return nil
+}
+
+func runAgentsGC(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	s := newAgentStore()
+
+	// 收集运行中 JVM 引用的文件
+	det := detector.NewDetector(GetConfig())
+	procs, err := det.DiscoverJavaProcesses(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to discover processes: %w", err)
+	}
+	referenced, err := referencedAgentFiles(procs)
+	if err != nil {
+		return fmt.Errorf("failed to gc agents: %w", err)
+	}
+
+	logger.Debug("Collected referenced agent files", zap.Int("count", len(referenced)))
+
+	removed, err := s.GC(agentsName, referenced, agentsDryRun)
+	if err != nil {
+		return fmt.Errorf("failed to gc agents: %w", err)
+	}
+
+	if len(removed) == 0 {
+		fmt.Println("Nothing to remove")
+		return nil
+	}
+
+	prefix := "Removed"
+	if agentsDryRun {
+		prefix = "[DRY RUN] Would remove"
+	}
+	for _, v := range removed {
+		fmt.Printf("%s %s %s (%s)\n", prefix, v.Name, v.Version, filepath.Dir(v.Path))
+	}
+
+	return nil
+}
+
+// referencedAgentFiles 收集 Java 进程引用的 agent 文件（已解析符号链接）
+// 包括命令行和 JAVA_TOOL_OPTIONS 中的 -javaagent 路径，以及进程当前打开的文件
+// 无法读取某个 JVM 打开的文件时返回错误，否则可能删除该 JVM 仍在使用的版本
+func referencedAgentFiles(procs []*detector.JavaProcess) ([]string, error) {
+	var files []string
+
+	add := func(path string) {
+		if resolved, err := filepath.EvalSymlinks(path); err == nil {
+			path = resolved
+		}
+		files = append(files, path)
+	}
+
+	for _, proc := range procs {
+		args := append([]string{}, proc.CmdLine...)
+		args = append(args, detector.ToolOptionValues(proc.Envs[detector.AgentSourceEnv])...)
+
+		for _, arg := range args {
+			if agent := detector.ParseAgentParam(arg); agent != nil {
+				add(detector.ResolveAgentPath(agent.Path, proc.Cwd))
+			}
+		}
+
+		// JVM 启动后 current 链接可能已切换，打开的文件反映实际加载的版本
+		open, err := procfs.ReadOpenFiles(proc.PID)
+		if err != nil {
+			if os.IsNotExist(err) {
+				// 进程已退出
+				continue
+			}
+			return nil, fmt.Errorf("cannot read open files of JVM %d (run as root or the process owner): %w", proc.PID, err)
+		}
+		files = append(files, open...)
+	}
+
+	return files, nil
+}
@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"iast-auto-inject/internal/core/audit"
+	"iast-auto-inject/internal/pkg/redact"
+	"iast-auto-inject/internal/pkg/textutil"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	auditSince      string
+	auditFull       bool
+	auditExpectHead string
+)
+
+// auditCmd audit 命令
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "查看和校验审计日志",
+	Long: `每次注入、升级和移除（包括被拒绝和失败的操作）都会追加一条审计记录，
+包含操作者、执行的命令、目标进程、新旧命令行、agent 摘要、注入方式和结果。
+每条记录包含前一条记录的哈希，修改、删除或插入记录都会被 'audit verify' 发现。
+每次写入后最新的哈希会输出到日志（建议配置为 journald 或远程 syslog），
+使用 'audit verify --expect-head <hash>' 可以发现截断尾部或重写整个文件`,
+}
+
+// auditVerifyCmd audit verify 命令
+var auditVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "校验审计日志的哈希链",
+	Args:  cobra.NoArgs,
+	RunE:  runAuditVerify,
+}
+
+// auditShowCmd audit show 命令
+var auditShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "显示审计记录",
+	Args:  cobra.NoArgs,
+	RunE:  runAuditShow,
+}
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+	auditCmd.AddCommand(auditVerifyCmd, auditShowCmd)
+
+	auditVerifyCmd.Flags().StringVar(&auditExpectHead, "expect-head", "", "要求链中包含该哈希（取自系统日志中的 'Audit record appended' 记录）")
+	auditShowCmd.Flags().StringVar(&auditSince, "since", "", "只显示该时间之后的记录（时长如 24h，或日期如 2006-01-02、2006-01-02T15:04:05）")
+	auditShowCmd.Flags().BoolVar(&auditFull, "full", false, "逐条显示完整记录（包括新旧命令行和 agent 摘要）")
+}
+
+// auditLog 返回配置的审计日志
+func auditLog() (*audit.Log, error) {
+	cfg := GetConfig().Audit
+	if cfg == nil || cfg.File == "" {
+		return nil, fmt.Errorf("audit log is not configured")
+	}
+	return audit.Open(cfg.File), nil
+}
+
+func runAuditVerify(cmd *cobra.Command, args []string) error {
+	log, err := auditLog()
+	if err != nil {
+		return err
+	}
+
+	result, err := log.Verify(auditExpectHead)
+	if err != nil {
+		return err
+	}
+
+	if !result.OK() {
+		if result.Line > 0 {
+			color.Red("✗ Audit log %s is broken at line %d: %s", log.Path(), result.Line, result.Problem)
+			fmt.Printf("  %d record(s) verified before the break\n", result.Records)
+		} else {
+			color.Red("✗ Audit log %s: %s", log.Path(), result.Problem)
+			fmt.Printf("  %d record(s) verified, last hash %s\n", result.Records, result.LastHash)
+		}
+		return fmt.Errorf("audit log verification failed")
+	}
+
+	color.Green("✓ Audit log %s verified", log.Path())
+	fmt.Printf("  Records:   %d\n", result.Records)
+	fmt.Printf("  Last hash: %s\n", result.LastHash)
+
+	return nil
+}
+
+func runAuditShow(cmd *cobra.Command, args []string) error {
+	log, err := auditLog()
+	if err != nil {
+		return err
+	}
+
+	since, err := parseSince(auditSince, time.Now())
+	if err != nil {
+		return err
+	}
+
+	records, err := log.Read(since)
+	if err != nil {
+		return err
+	}
+
+	if len(records) == 0 {
+		fmt.Println("No audit records")
+		return nil
+	}
+
+	if auditFull {
+		printAuditDetails(records)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Seq\tTime\tOperator\tAction\tPID\tNew PID\tUser\tService\tStrategy\tOutcome\tError")
+
+	for _, rec := range records {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			rec.Seq, rec.Time.Local().Format("2006-01-02 15:04:05"), rec.Operator,
+			rec.Action, rec.PID, formatNewPID(rec.NewPID), rec.User, textutil.Truncate(rec.Service, 50),
+			valueOr(rec.Strategy, "-"), formatOutcome(rec.Outcome), textutil.Truncate(rec.Error, 60))
+	}
+
+	w.Flush()
+
+	return nil
+}
+
+// printAuditDetails 逐条显示审计记录的完整内容
+func printAuditDetails(records []*audit.Record) {
+	for _, rec := range records {
+		fmt.Printf("#%d %s %s %s PID %d -> %s (%s)\n",
+			rec.Seq, rec.Time.Local().Format("2006-01-02 15:04:05"), rec.Operator,
+			rec.Action, rec.PID, formatNewPID(rec.NewPID), formatOutcome(rec.Outcome))
+		// 早期版本写入的记录未隐藏敏感值
+		r := redact.Default()
+		fmt.Printf("  Command:  %s\n", r.Text(rec.Command))
+		fmt.Printf("  Target:   %s %s (%s)\n", rec.User, rec.Service, rec.Name)
+		if rec.Agent != "" {
+			fmt.Printf("  Agent:    %s (sha256 %s)\n", rec.Agent, valueOr(rec.AgentSHA256, "unknown"))
+		}
+		if rec.Strategy != "" {
+			fmt.Printf("  Strategy: %s\n", rec.Strategy)
+		}
+		fmt.Printf("  Old argv: %s\n", strings.Join(r.Args(rec.OldArgv), " "))
+		if rec.NewArgv != nil {
+			fmt.Printf("  New argv: %s\n", strings.Join(r.Args(rec.NewArgv), " "))
+		}
+		if rec.Error != "" {
+			fmt.Printf("  Error:    %s (%s)\n", rec.Error, rec.Code)
+		}
+		fmt.Println()
+	}
+}
+
+// formatNewPID 格式化新进程 PID，未重启时显示 -
+func formatNewPID(pid int) string {
+	if pid == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d", pid)
+}
+
+// parseSince 解析 --since：相对时长（24h、30m）或日期时间，为空时返回零值（不过滤）
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid --since %q: expected a duration (24h) or a date (2006-01-02)", value)
+}
+
+// formatOutcome 按结果着色
+func formatOutcome(outcome string) string {
+	switch outcome {
+	case audit.OutcomeSuccess:
+		return color.GreenString(outcome)
+	case audit.OutcomeDenied:
+		return color.YellowString(outcome)
+	case audit.OutcomeStarted:
+		return color.CyanString(outcome)
+	default:
+		return color.RedString(outcome)
+	}
+}
+
+// valueOr 值为空时返回默认值
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"iast-auto-inject/internal/core/config"
+	"iast-auto-inject/internal/pkg/redact"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	configInitForce     bool
+	configShowEffective bool
+)
+
+// configCmd config 命令
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "生成、检查和查看配置文件",
+	Long: `未指定 --config 时依次查找 config.yaml、configs/config.yaml、~/.iast-inject/config.yaml
+和 /etc/iast-inject/config.yaml，使用第一个存在的文件；都不存在时使用默认配置`,
+	// init 和 validate 不加载配置，配置文件有错误时也可以使用
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+}
+
+// configInitCmd config init 命令
+var configInitCmd = &cobra.Command{
+	Use:   "init [file]",
+	Short: "生成带注释的默认配置文件",
+	Long: `按默认配置生成带注释的配置文件，未指定文件时输出到标准输出。
+默认配置中的 agent 路径需要修改为实际路径后才能通过 'config validate'`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runConfigInit,
+}
+
+// configValidateCmd config validate 命令
+var configValidateCmd = &cobra.Command{
+	Use:   "validate [file]",
+	Short: "严格检查配置文件",
+	Long: `检查 YAML 语法、未知配置项（如拼写错误，加载时会被忽略）、类型错误和配置值，
+问题按 文件:行号: 问题 的格式输出。未指定文件时检查 --config 或第一个存在的默认路径`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runConfigValidate,
+}
+
+// configShowCmd config show 命令
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "显示配置文件",
+	Long: `显示加载的配置文件，--effective 显示与默认值合并后实际生效的完整配置。
+agent 选项等配置中的敏感值按 redaction 配置隐藏`,
+	Args: cobra.NoArgs,
+	RunE: runConfigShow,
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configInitCmd, configValidateCmd, configShowCmd)
+
+	configInitCmd.Flags().BoolVar(&configInitForce, "force", false, "覆盖已存在的文件")
+	configShowCmd.Flags().BoolVar(&configShowEffective, "effective", false, "显示与默认值合并后实际生效的配置")
+}
+
+func runConfigInit(cmd *cobra.Command, args []string) error {
+	data, err := config.Template()
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+
+	path := args[0]
+	if _, err := os.Stat(path); err == nil && !configInitForce {
+		return fmt.Errorf("%s already exists (use --force to overwrite)", path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+
+	color.Green("✓ Wrote default configuration to %s", path)
+	fmt.Printf("  Edit agents[].path, then run 'config validate %s'\n", path)
+	return nil
+}
+
+func runConfigValidate(cmd *cobra.Command, args []string) error {
+	path := cfgFile
+	if len(args) > 0 {
+		path = args[0]
+	}
+	if path == "" {
+		path = config.FindDefaultPath()
+	}
+	if path == "" {
+		return fmt.Errorf("no configuration file found, specify one with --config or as an argument")
+	}
+
+	problems, err := config.Check(path)
+	if err != nil {
+		return err
+	}
+
+	if len(problems) == 0 {
+		color.Green("✓ %s is valid", path)
+		return nil
+	}
+
+	for _, problem := range problems {
+		if problem.Line > 0 {
+			color.Red("%s:%d: %s", path, problem.Line, problem.Message)
+		} else {
+			color.Red("%s: %s", path, problem.Message)
+		}
+	}
+	return exitWith(cmd, exitFailed, fmt.Errorf("%s: %d problem(s) found", path, len(problems)))
+}
+
+func runConfigShow(cmd *cobra.Command, args []string) error {
+	if err := persistentPreRun(cmd, args); err != nil {
+		return err
+	}
+
+	var node yaml.Node
+	if configShowEffective {
+		if err := node.Encode(GetConfig()); err != nil {
+			return fmt.Errorf("failed to marshal config: %w", err)
+		}
+	} else {
+		if cfgPath == "" {
+			color.Yellow("No configuration file found, using built-in defaults (see 'config show --effective')")
+			return nil
+		}
+		data, err := os.ReadFile(cfgPath)
+		if err != nil {
+			return fmt.Errorf("failed to read config file: %w", err)
+		}
+		if err := yaml.Unmarshal(data, &node); err != nil {
+			return fmt.Errorf("failed to parse config file: %w", err)
+		}
+	}
+	redactNode(&node, redact.Default(), "")
+
+	source := "built-in defaults (no configuration file found)"
+	if cfgPath != "" {
+		source = cfgPath
+		if abs, err := filepath.Abs(cfgPath); err == nil {
+			source = abs
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Source: %s\n", source)
+	if configShowEffective && debug {
+		buf.WriteString("# debug: overridden by --debug\n")
+	}
+
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	_, err := os.Stdout.Write(buf.Bytes())
+	return err
+}
+
+// redactNode 隐藏配置中的敏感值：键名匹配的值替换为 ******，其他字符串按参数处理（如 agent 选项、URL）
+func redactNode(node *yaml.Node, r *redact.Redactor, key string) {
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, child := range node.Content {
+			redactNode(child, r, key)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			redactNode(node.Content[i+1], r, node.Content[i].Value)
+		}
+	case yaml.ScalarNode:
+		if node.Tag == "!!str" || node.Tag == "" {
+			node.Value = r.Value(key, node.Value)
+		}
+	}
+}
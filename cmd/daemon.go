@@ -3,15 +3,22 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"maps"
 	"os"
 	"os/signal"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"iast-auto-inject/internal/core/config"
+	"iast-auto-inject/internal/core/control"
 	"iast-auto-inject/internal/core/detector"
 	"iast-auto-inject/internal/core/injector"
-	"iast-auto-inject/internal/core/process"
 	"iast-auto-inject/internal/pkg/logger"
+	"iast-auto-inject/internal/pkg/pidfile"
+	"iast-auto-inject/internal/pkg/procevents"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
@@ -19,19 +26,32 @@ import (
 )
 
 var (
-	daemonInterval   time.Duration
-	daemonOnce       bool
-	daemonNoDaemon   bool
-	daemonPidFile    string
-	daemonSecPoint   string
+	daemonInterval     time.Duration
+	daemonOnce         bool
+	daemonNoDaemon     bool
+	daemonPidFile      string
+	daemonSocket       string
+	daemonSecPoint     string
+	daemonAgentVersion string
 )
 
 // daemonCmd daemon 命令
 var daemonCmd = &cobra.Command{
 	Use:   "daemon",
 	Short: "启动守护进程模式",
-	Long:  `启动守护进程模式，定期扫描并自动注入 SecPoint 到 Java 进程`,
-	RunE:  runDaemon,
+	Long: `启动守护进程模式，定期扫描并自动注入 SecPoint 到 Java 进程
+
+运行中的守护进程通过本地控制 socket 管理：
+  daemon status    查看运行状态
+  daemon pause     暂停注入
+  daemon resume    恢复注入
+  daemon scan      立即扫描
+  daemon reload    重新加载配置（等价于发送 SIGHUP）
+  daemon log-level 查看或临时修改日志级别
+  daemon stop      停止守护进程
+
+守护进程使用 daemon.log_level 作为日志级别（为空时使用 log.level）`,
+	RunE: runDaemon,
 }
 
 func init() {
@@ -39,9 +59,11 @@ func init() {
 
 	daemonCmd.Flags().DurationVarP(&daemonInterval, "interval", "i", 0, "扫描间隔（默认使用配置文件中的值）")
 	daemonCmd.Flags().BoolVar(&daemonOnce, "once", false, "只执行一次然后退出")
-	daemonCmd.Flags().BoolVar(&daemonNoDaemon, "no-daemon", false, "前台运行（不后台化）")
-	daemonCmd.Flags().StringVar(&daemonPidFile, "pid-file", "", "PID 文件路径")
+	daemonCmd.Flags().BoolVar(&daemonNoDaemon, "no-daemon", false, "前台运行（不后台化，用于 systemd 等进程管理器）")
+	daemonCmd.PersistentFlags().StringVar(&daemonPidFile, "pid-file", "", "PID 文件路径（默认使用配置文件中的值）")
+	daemonCmd.PersistentFlags().StringVar(&daemonSocket, "socket", "", "控制 socket 路径（默认使用配置文件中的值）")
 	daemonCmd.Flags().StringVarP(&daemonSecPoint, "secpoint", "s", "", "SecPoint.jar 路径（必需）")
+	daemonCmd.Flags().StringVar(&daemonAgentVersion, "agent-version", "", "保持已附加 SecPoint 的版本，低于该版本的进程会被原位升级（默认使用配置文件中的值）")
 }
 
 func runDaemon(cmd *cobra.Command, args []string) error {
@@ -50,110 +72,496 @@ func runDaemon(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("请指定 SecPoint.jar 路径（使用 --secpoint 或 -s）")
 	}
 
+	pidPath := daemonPidFilePath()
+
+	// 默认后台运行：重新执行自身作为脱离终端的子进程，等待其完成初始化后退出
+	if !daemonNoDaemon && !daemonOnce && !isDaemonChild() {
+		return startBackground(pidPath)
+	}
+
+	// 后台子进程通过管道向父进程报告初始化结果
+	ready := daemonReadyPipe()
+	err := serveDaemon(pidPath, ready)
+	ready.done(err)
+	return err
+}
+
+// serveDaemon 持有 PID 文件锁并运行扫描循环，初始化完成后通知 ready
+func serveDaemon(pidPath string, ready *readyNotifier) error {
+	// 单实例：持有 PID 文件锁，防止多个守护进程同时重启同一个 JVM
+	lock, err := pidfile.Acquire(pidPath)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	if stale := lock.StalePID(); stale > 0 && stale != os.Getpid() {
+		logger.Warn("Removed stale pid file",
+			zap.String("pid_file", pidPath),
+			zap.Int("stale_pid", stale))
+	}
+
+	// 退出时取消 ctx 只停止扫描和滚动注入，已重启的 JVM 不受影响
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// 获取扫描间隔
-	interval := daemonInterval
-	if interval == 0 {
-		interval = GetConfig().Daemon.Interval
-	}
-	if interval == 0 {
-		interval = 60 * time.Second
+	d := &daemonRunner{
+		secPoint:  daemonSecPoint,
+		pending:   make(map[int]time.Time),
+		startedAt: time.Now(),
+		scanNow:   make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+		reloads:   make(chan *daemonSettings, 1),
+		metrics:   newDaemonMetrics(),
 	}
 
-	// 创建组件
-	det := detector.NewDetector(GetConfig())
-	procMgr := process.NewManager(
-		GetConfig().Restart.GracePeriod,
-		GetConfig().Restart.KillTimeout,
-		GetConfig().Restart.VerifyWait,
-		GetConfig().Restart.MaxRetries,
-	)
-	inj := injector.NewStaticInjector(GetConfig(), det, procMgr)
+	settings, err := d.prepare(GetConfig())
+	if err != nil {
+		return err
+	}
+	d.apply(settings)
 
 	color.Green("Starting daemon mode")
 	logger.Info("Daemon started",
-		zap.Duration("interval", interval),
+		zap.Duration("interval", d.interval),
 		zap.Bool("once", daemonOnce),
-		zap.String("secpoint", daemonSecPoint))
+		zap.String("secpoint", daemonSecPoint),
+		zap.String("agent_version", d.agentVersion),
+		zap.Duration("min_uptime", d.minUptime),
+		zap.Int("pid", os.Getpid()),
+		zap.String("pid_file", pidPath))
 
 	// 设置信号处理
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
-	// 扫描循环
-	scanCount := 0
-	injectCount := 0
+	// SIGHUP 重新加载配置
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
 
-	for {
-		scanCount++
-
-		logger.Info("Scanning for Java processes", zap.Int("scan", scanCount))
-		fmt.Printf("\n[%s] Scan #%d\n", time.Now().Format("2006-01-02 15:04:05"), scanCount)
-
-		// 发现进程
-		procs, err := det.DiscoverJavaProcesses(ctx, nil)
-		if err != nil {
-			logger.Error("Failed to discover processes", zap.Error(err))
-		} else {
-			logger.Debug("Found processes", zap.Int("count", len(procs)))
-
-			// 找出需要注入的进程（未包含 SecPoint 的）
-			var targets []*detector.JavaProcess
-			for _, proc := range procs {
-				if inj.NeedsInject(proc) {
-					targets = append(targets, proc)
-				}
-			}
+	ready.done(nil)
 
-			if len(targets) == 0 {
-				fmt.Println("No processes need injection")
-			} else {
-				color.Cyan("Found %d process(es) needing injection", len(targets))
-
-				// 执行注入
-				results := inj.BatchInject(ctx, targets, daemonSecPoint)
-
-				// 统计成功数量
-				for _, result := range results {
-					if result.Success {
-						injectCount++
-						logger.Info("Injected SecPoint agent",
-							zap.Int("pid", result.PID),
-							zap.Int("new_pid", result.NewPID))
-					} else {
-						logger.Error("Failed to inject",
-							zap.Int("pid", result.PID),
-							zap.Error(result.Error))
-					}
-				}
+	return d.run(ctx, sigChan, hupChan)
+}
 
-				fmt.Printf("Injected: %d/%d\n", injectCount, len(results))
-			}
+// daemonRunner 守护进程扫描循环
+type daemonRunner struct {
+	daemonSettings // 随配置重新加载整体替换
+
+	secPoint string
+
+	pending map[int]time.Time // 等待维护窗口的进程 -> 下一个窗口开始时间
+
+	scanCount    int
+	injectCount  int
+	upgradeCount int
+	lastScan     time.Time
+
+	// 控制接口
+	startedAt time.Time
+	paused    atomic.Bool   // 暂停注入
+	scanNow   chan struct{} // 立即全量扫描
+	stop      chan struct{} // 优雅停止
+	stopOnce  sync.Once
+	reloads   chan *daemonSettings // 通过控制接口重新加载并已验证的配置
+
+	metrics *daemonMetrics
+
+	mu       sync.Mutex
+	snapshot control.Status // 扫描循环每轮结束时发布的状态快照
+}
+
+// run 执行扫描循环，直到收到信号或 context 取消
+// 进程事件可用时新进程在达到最小运行时间后立即处理，并按 resync 间隔全量扫描；否则按 interval 轮询
+func (d *daemonRunner) run(ctx context.Context, sigChan, hupChan <-chan os.Signal) error {
+	if !daemonOnce {
+		if server := d.startControl(ctx); server != nil {
+			defer server.Close()
 		}
+		d.publish(false, time.Time{})
+		d.startMetrics(ctx)
+	}
+
+	d.scan(ctx, nil)
+
+	// 单次执行模式
+	if daemonOnce {
+		color.Green("\nSingle execution completed")
+		logger.Info("Single execution completed",
+			zap.Int("scans", d.scanCount),
+			zap.Int("injections", d.injectCount),
+			zap.Int("upgrades", d.upgradeCount))
+		return nil
+	}
+
+	events, eventErrs := d.startEvents(ctx)
+	period := d.interval
+	if events != nil {
+		period = d.resync
+	}
+	nextScan := time.Now().Add(period)
+	d.publish(events != nil, nextScan)
 
-		// 单次执行模式
-		if daemonOnce {
-			color.Green("\nSingle execution completed")
-			logger.Info("Single execution completed",
-				zap.Int("scans", scanCount),
-				zap.Int("injections", injectCount))
-			break
+	// 新进程的延迟处理（同一进程再次 exec 时重新计时），退出时停止未触发的计时器
+	debounce := make(map[int]*time.Timer)
+	ready := make(chan int, 64)
+	defer func() {
+		for _, timer := range debounce {
+			timer.Stop()
 		}
+	}()
+
+	// 暂停期间达到最小运行时间的新进程，恢复后处理
+	deferred := make(map[int]bool)
+
+	for {
+		timer := time.NewTimer(d.untilNextScan(nextScan))
 
-		// 等待下次扫描或信号
 		select {
 		case <-sigChan:
+			timer.Stop()
 			color.Yellow("\nReceived signal, shutting down...")
 			logger.Info("Received shutdown signal")
 			return nil
-		case <-time.After(interval):
-			continue
 		case <-ctx.Done():
+			timer.Stop()
 			return ctx.Err()
+		case <-d.stop:
+			timer.Stop()
+			color.Yellow("\nStop requested, shutting down...")
+			logger.Info("Stop requested via control socket")
+			return nil
+		case <-timer.C:
+			d.fullScan(ctx, deferred)
+			nextScan = time.Now().Add(period)
+		case <-d.scanNow:
+			d.fullScan(ctx, deferred)
+			nextScan = time.Now().Add(period)
+		case <-hupChan:
+			logger.Info("Received SIGHUP, reloading configuration")
+			if settings, _, err := d.reload(); err == nil {
+				d.apply(settings)
+				period, nextScan = d.reschedule(events != nil, period, nextScan)
+			}
+		case settings := <-d.reloads:
+			d.apply(settings)
+			period, nextScan = d.reschedule(events != nil, period, nextScan)
+		case err := <-eventErrs:
+			logger.Warn("Process events unavailable, falling back to polling", zap.Error(err))
+			events, eventErrs = nil, nil
+			period = d.interval
+			nextScan = time.Now().Add(period)
+		case ev := <-events:
+			d.handleEvent(ctx, ev, debounce, ready)
+			delete(deferred, ev.PID)
+		case pid := <-ready:
+			delete(debounce, pid)
+			if d.paused.Load() {
+				deferred[pid] = true
+				break
+			}
+			d.scan(ctx, &detector.ProcessFilter{PIDs: []int{pid}})
+		}
+
+		if len(deferred) > 0 && !d.paused.Load() {
+			pids := slices.Sorted(maps.Keys(deferred))
+			clear(deferred)
+			d.scan(ctx, &detector.ProcessFilter{PIDs: pids})
+		}
+
+		timer.Stop()
+		d.publish(events != nil, nextScan)
+	}
+}
+
+// startEvents 订阅 netlink 进程事件，不可用时返回 nil（使用轮询）
+func (d *daemonRunner) startEvents(ctx context.Context) (<-chan procevents.Event, <-chan error) {
+	if !GetConfig().Daemon.Events {
+		return nil, nil
+	}
+
+	listener, err := procevents.Listen()
+	if err != nil {
+		logger.Warn("Process events unavailable, using polling",
+			zap.Duration("interval", d.interval),
+			zap.Error(err))
+		return nil, nil
+	}
+
+	events := make(chan procevents.Event, 256)
+	errs := make(chan error, 1)
+	go func() {
+		defer listener.Close()
+		if err := listener.Run(ctx, events); err != nil && ctx.Err() == nil {
+			errs <- err
+		}
+	}()
+
+	logger.Info("Subscribed to process events", zap.Duration("resync_interval", d.resync))
+
+	return events, errs
+}
+
+// handleEvent 处理进程事件：新的 Java 进程在达到最小运行时间后处理，退出的进程取消处理
+func (d *daemonRunner) handleEvent(ctx context.Context, ev procevents.Event, debounce map[int]*time.Timer, ready chan<- int) {
+	switch ev.Type {
+	case procevents.Exec:
+		if !detector.IsJavaPID(ev.PID) {
+			return
+		}
+		if timer, ok := debounce[ev.PID]; ok {
+			timer.Stop()
+		}
+		logger.Debug("New Java process", zap.Int("pid", ev.PID), zap.Duration("delay", d.minUptime))
+
+		pid := ev.PID
+		debounce[pid] = time.AfterFunc(d.minUptime, func() {
+			select {
+			case ready <- pid:
+			case <-ctx.Done():
+			}
+		})
+
+	case procevents.Exit:
+		if timer, ok := debounce[ev.PID]; ok {
+			timer.Stop()
+			delete(debounce, ev.PID)
+		}
+		delete(d.pending, ev.PID)
+	}
+}
+
+// fullScan 执行全量扫描，未暂停时全量扫描已包含暂停期间推迟的新进程
+func (d *daemonRunner) fullScan(ctx context.Context, deferred map[int]bool) {
+	d.scan(ctx, nil)
+	if !d.paused.Load() {
+		clear(deferred)
+	}
+}
+
+// untilNextScan 返回距下一次全量扫描的时间，等待维护窗口的进程在窗口开始时提前扫描
+func (d *daemonRunner) untilNextScan(nextScan time.Time) time.Duration {
+	if next := nextPending(d.pending); !next.IsZero() && next.Before(nextScan) {
+		nextScan = next
+	}
+	if wait := time.Until(nextScan); wait > time.Second {
+		return wait
+	}
+	return time.Second
+}
+
+// scan 扫描进程并执行注入和升级，filter 为空时为全量扫描
+func (d *daemonRunner) scan(ctx context.Context, filter *detector.ProcessFilter) {
+	if d.paused.Load() {
+		logger.Info("Injection paused, skipping scan")
+		return
+	}
+
+	full := filter == nil
+	scanType := "event"
+	if full {
+		scanType = "full"
+		start := time.Now()
+		defer func() {
+			d.metrics.scanDuration.Observe(time.Since(start).Seconds())
+			d.metrics.pending.Set(float64(len(d.pending)))
+			d.metrics.observeFailures(d.failures)
+		}()
+	}
+	d.metrics.scans.Inc(scanType)
+	if full {
+		filter = &detector.ProcessFilter{}
+	}
+	filter.InScope = true
+	// 事件触发的扫描在进程达到最小运行时间后才开始，不再按启动时间过滤
+	if full && d.minUptime > 0 {
+		seconds := int(d.minUptime / time.Second)
+		filter.MinUptime = &seconds
+	}
+
+	if full {
+		d.scanCount++
+		d.lastScan = time.Now()
+		logger.Info("Scanning for Java processes", zap.Int("scan", d.scanCount))
+		fmt.Printf("\n[%s] Scan #%d\n", time.Now().Format("2006-01-02 15:04:05"), d.scanCount)
+	} else {
+		logger.Info("Evaluating new Java process", zap.Ints("pids", filter.PIDs))
+		fmt.Printf("\n[%s] New process %v\n", time.Now().Format("2006-01-02 15:04:05"), filter.PIDs)
+	}
+
+	// 发现进程
+	procs, err := d.det.DiscoverJavaProcesses(ctx, filter)
+	if err != nil {
+		d.metrics.scanErrors.Inc()
+		logger.Error("Failed to discover processes", zap.Error(err))
+		return
+	}
+	logger.Debug("Found processes", zap.Int("count", len(procs)))
+
+	if full {
+		d.metrics.observeProcesses(d.det, procs, metricsAgents())
+	}
+
+	inj := d.inj
+
+	// 找出需要注入的进程（未包含 SecPoint 且策略允许注入的）
+	var targets []*detector.JavaProcess
+	observed := 0
+	for _, proc := range procs {
+		if inj.NeedsInject(proc) {
+			targets = append(targets, proc)
+			continue
+		}
+
+		// 仅观察的进程只上报，不注入
+		if decision := inj.Decide(proc); decision.Action == config.PolicyActionObserve {
+			observed++
+			logger.Debug("Observing process",
+				zap.Int("pid", proc.PID),
+				zap.String("name", proc.Name),
+				zap.String("rule", policyRuleName(decision)),
+				zap.Bool("secpoint_attached", d.det.HasSecPointAgent(proc)))
+		}
+	}
+
+	if observed > 0 {
+		fmt.Printf("Observed (policy: observe only): %d\n", observed)
+	}
+
+	// 维护窗口外的进程排队等待，下次扫描时重新检查
+	if full {
+		prunePending(d.pending, procs)
+	}
+	targets = holdForMaintenance(inj, targets, d.pending)
+
+	// 跳过之前失败仍在退避期或已隔离的应用
+	targets = skipBackoff(d.failures, targets)
+
+	if len(targets) == 0 {
+		fmt.Println("No processes need injection")
+	} else {
+		color.Cyan("Found %d process(es) needing injection", len(targets))
+
+		// 执行注入
+		results := inj.BatchInject(ctx, targets, d.secPoint)
+		recordResults(d.failures, targets, results)
+		d.metrics.recordResults("inject", results)
+
+		// 统计成功数量
+		injected := 0
+		for _, result := range results {
+			if result.Success {
+				injected++
+				d.injectCount++
+				logger.Info("Injected SecPoint agent",
+					zap.Int("pid", result.PID),
+					zap.Int("new_pid", result.NewPID))
+			} else {
+				logger.Error("Failed to inject",
+					zap.Int("pid", result.PID),
+					zap.Error(result.Error))
+			}
+		}
+
+		fmt.Printf("Injected: %d/%d\n", injected, len(results))
+	}
+
+	// 升级版本低于策略版本的 SecPoint
+	if d.agentVersion != "" {
+		var upgradeTargets []*detector.JavaProcess
+		for _, proc := range procs {
+			if inj.NeedsUpgrade(proc, detector.SecPointAgentName, d.agentVersion) {
+				upgradeTargets = append(upgradeTargets, proc)
+			}
+		}
+
+		upgradeTargets = holdForMaintenance(inj, upgradeTargets, d.pending)
+		upgradeTargets = skipBackoff(d.failures, upgradeTargets)
+
+		if len(upgradeTargets) > 0 {
+			color.Cyan("Found %d process(es) with SecPoint older than %s", len(upgradeTargets), d.agentVersion)
+
+			results := inj.BatchUpgrade(ctx, upgradeTargets, detector.SecPointAgentName, d.secPoint)
+			recordResults(d.failures, upgradeTargets, results)
+			d.metrics.recordResults("upgrade", results)
+
+			upgraded := 0
+			for _, result := range results {
+				if result.Success {
+					upgraded++
+					d.upgradeCount++
+					logger.Info("Upgraded SecPoint agent",
+						zap.Int("pid", result.PID),
+						zap.Int("new_pid", result.NewPID))
+				} else {
+					logger.Error("Failed to upgrade",
+						zap.Int("pid", result.PID),
+						zap.Error(result.Error))
+				}
+			}
+
+			fmt.Printf("Upgraded: %d/%d\n", upgraded, len(results))
+		}
+	}
+
+	if len(d.pending) > 0 {
+		color.Yellow("Queued until maintenance window: %d process(es), next window: %s",
+			len(d.pending), formatWindow(nextPending(d.pending)))
+	}
+}
+
+// holdForMaintenance 返回当前处于维护窗口内的进程，窗口外的进程加入等待队列
+func holdForMaintenance(inj *injector.StaticInjector, procs []*detector.JavaProcess, pending map[int]time.Time) []*detector.JavaProcess {
+	now := time.Now()
+
+	var ready []*detector.JavaProcess
+	for _, proc := range procs {
+		active, next := inj.MaintenanceWindow(proc, now)
+		if active {
+			delete(pending, proc.PID)
+			ready = append(ready, proc)
+			continue
+		}
+
+		if _, queued := pending[proc.PID]; !queued {
+			logger.Info("Restart deferred until maintenance window",
+				zap.Int("pid", proc.PID),
+				zap.String("next_window", formatWindow(next)))
 		}
+		pending[proc.PID] = next
 	}
 
-	return nil
+	return ready
+}
+
+// prunePending 移除已退出进程的等待记录
+func prunePending(pending map[int]time.Time, procs []*detector.JavaProcess) {
+	alive := make(map[int]bool, len(procs))
+	for _, proc := range procs {
+		alive[proc.PID] = true
+	}
+	for pid := range pending {
+		if !alive[pid] {
+			delete(pending, pid)
+		}
+	}
+}
+
+// nextPending 返回等待队列中最近的窗口开始时间
+func nextPending(pending map[int]time.Time) time.Time {
+	var next time.Time
+	for _, t := range pending {
+		if !t.IsZero() && (next.IsZero() || t.Before(next)) {
+			next = t
+		}
+	}
+	return next
+}
+
+// formatWindow 格式化维护窗口开始时间
+func formatWindow(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return t.Format("2006-01-02 15:04 MST")
 }
@@ -0,0 +1,334 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"iast-auto-inject/internal/core/control"
+	"iast-auto-inject/internal/pkg/logger"
+	"iast-auto-inject/internal/pkg/pidfile"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var (
+	daemonStopTimeout time.Duration
+)
+
+// daemonStatusCmd daemon status 命令
+var daemonStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "查看守护进程运行状态",
+	Args:  cobra.NoArgs,
+	RunE:  runDaemonStatus,
+}
+
+// daemonPauseCmd daemon pause 命令
+var daemonPauseCmd = &cobra.Command{
+	Use:   "pause",
+	Short: "暂停注入（守护进程继续运行，不再扫描和重启进程）",
+	Args:  cobra.NoArgs,
+	RunE:  runDaemonPause,
+}
+
+// daemonResumeCmd daemon resume 命令
+var daemonResumeCmd = &cobra.Command{
+	Use:   "resume",
+	Short: "恢复注入并立即扫描",
+	Args:  cobra.NoArgs,
+	RunE:  runDaemonResume,
+}
+
+// daemonScanCmd daemon scan 命令
+var daemonScanCmd = &cobra.Command{
+	Use:   "scan",
+	Short: "立即执行一次全量扫描",
+	Args:  cobra.NoArgs,
+	RunE:  runDaemonScan,
+}
+
+// daemonStopCmd daemon stop 命令
+var daemonStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "停止守护进程（当前扫描完成后退出）",
+	Args:  cobra.NoArgs,
+	RunE:  runDaemonStop,
+}
+
+// daemonLogLevelCmd daemon log-level 命令
+var daemonLogLevelCmd = &cobra.Command{
+	Use:   "log-level [level]",
+	Short: "查看或临时修改日志级别（重新加载配置或重启后恢复）",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runDaemonLogLevel,
+}
+
+func init() {
+	daemonCmd.AddCommand(daemonStatusCmd, daemonPauseCmd, daemonResumeCmd, daemonScanCmd, daemonStopCmd, daemonLogLevelCmd)
+
+	daemonStopCmd.Flags().DurationVar(&daemonStopTimeout, "timeout", 60*time.Second, "等待守护进程退出的时间")
+}
+
+// daemonSocketPath 返回控制 socket 路径（命令行参数优先于配置文件）
+func daemonSocketPath() string {
+	if daemonSocket != "" {
+		return daemonSocket
+	}
+	if path := GetConfig().Daemon.ControlSocket; path != "" {
+		return path
+	}
+	return control.DefaultSocketPath
+}
+
+// daemonClient 创建控制 socket 客户端
+func daemonClient() *control.Client {
+	return control.NewClient(daemonSocketPath())
+}
+
+func runDaemonStatus(cmd *cobra.Command, args []string) error {
+	status, err := daemonClient().Status()
+	if err != nil {
+		return err
+	}
+
+	printDaemonStatus(status)
+	return nil
+}
+
+func runDaemonPause(cmd *cobra.Command, args []string) error {
+	if err := daemonClient().Pause(); err != nil {
+		return err
+	}
+	color.Yellow("Daemon paused, no processes will be injected until resumed")
+	return nil
+}
+
+func runDaemonResume(cmd *cobra.Command, args []string) error {
+	if err := daemonClient().Resume(); err != nil {
+		return err
+	}
+	color.Green("Daemon resumed, scan triggered")
+	return nil
+}
+
+func runDaemonScan(cmd *cobra.Command, args []string) error {
+	if err := daemonClient().Scan(); err != nil {
+		return err
+	}
+	color.Green("Scan triggered")
+	return nil
+}
+
+func runDaemonLogLevel(cmd *cobra.Command, args []string) error {
+	var level string
+	if len(args) > 0 {
+		level = args[0]
+	}
+
+	current, err := daemonClient().LogLevel(level)
+	if err != nil {
+		return err
+	}
+
+	if level == "" {
+		fmt.Printf("Log level: %s\n", current)
+	} else {
+		color.Green("Log level set to %s", current)
+	}
+	return nil
+}
+
+func runDaemonStop(cmd *cobra.Command, args []string) error {
+	if err := daemonClient().Stop(); err != nil {
+		return err
+	}
+	fmt.Println("Stop requested, waiting for the daemon to exit...")
+
+	if err := pidfile.WaitReleased(daemonPidFilePath(), daemonStopTimeout); err != nil {
+		return err
+	}
+	color.Green("Daemon stopped")
+	return nil
+}
+
+// printDaemonStatus 打印守护进程状态
+func printDaemonStatus(status *control.Status) {
+	state := color.GreenString("running")
+	if status.Paused {
+		state = color.YellowString("paused")
+	}
+	fmt.Printf("Daemon %s (pid %d)\n\n", state, status.PID)
+
+	discovery := fmt.Sprintf("polling every %s", status.Interval)
+	if status.Events {
+		discovery = "process events"
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Started:\t%s (up %s)\n", status.StartedAt.Format("2006-01-02 15:04:05"), status.Uptime.Round(time.Second))
+	fmt.Fprintf(w, "Discovery:\t%s\n", discovery)
+	fmt.Fprintf(w, "SecPoint:\t%s\n", status.SecPoint)
+	if status.AgentVersion != "" {
+		fmt.Fprintf(w, "Agent version:\t%s\n", status.AgentVersion)
+	}
+	fmt.Fprintf(w, "Last scan:\t%s\n", formatStatusTime(status.LastScan))
+	fmt.Fprintf(w, "Next scan:\t%s\n", formatStatusTime(status.NextScan))
+	fmt.Fprintf(w, "Scans:\t%d\n", status.ScanCount)
+	fmt.Fprintf(w, "Injections:\t%d\n", status.InjectCount)
+	fmt.Fprintf(w, "Upgrades:\t%d\n", status.UpgradeCount)
+	w.Flush()
+
+	if len(status.Pending) > 0 {
+		fmt.Printf("\nQueued until maintenance window (%d):\n", len(status.Pending))
+		for _, p := range status.Pending {
+			fmt.Printf("  pid %d -> %s\n", p.PID, formatWindow(p.NextWindow))
+		}
+	}
+
+	if len(status.Quarantined) > 0 {
+		fmt.Printf("\nFailing applications (%d):\n", len(status.Quarantined))
+		for _, entry := range status.Quarantined {
+			state := "backoff"
+			if entry.Quarantined {
+				state = color.RedString("quarantined")
+			}
+			fmt.Printf("  %s  %-11s  attempts=%d  %s\n", entry.ID, state, entry.Attempts, entry.Key)
+		}
+	}
+}
+
+// formatStatusTime 格式化状态中的时间
+func formatStatusTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
+
+// startControl 启动控制 socket，失败时只记录警告（守护进程仍可运行）
+func (d *daemonRunner) startControl(ctx context.Context) *control.Server {
+	path := daemonSocketPath()
+
+	server, err := control.Listen(path, d.handleControl)
+	if err != nil {
+		logger.Warn("Control socket unavailable", zap.String("socket", path), zap.Error(err))
+		return nil
+	}
+	go server.Serve(ctx)
+
+	logger.Info("Control socket listening", zap.String("socket", path))
+	return server
+}
+
+// publish 发布扫描循环的状态快照，供控制接口读取
+func (d *daemonRunner) publish(events bool, nextScan time.Time) {
+	pending := make([]control.Pending, 0, len(d.pending))
+	for pid, window := range d.pending {
+		pending = append(pending, control.Pending{PID: pid, NextWindow: window})
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].PID < pending[j].PID
+	})
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.snapshot = control.Status{
+		PID:          os.Getpid(),
+		StartedAt:    d.startedAt,
+		Events:       events,
+		SecPoint:     d.secPoint,
+		AgentVersion: d.agentVersion,
+		Interval:     d.interval,
+		Flags: control.Flags{
+			AgentVersion: daemonAgentVersion,
+			Interval:     daemonInterval,
+		},
+		LastScan:     d.lastScan,
+		NextScan:     nextScan,
+		ScanCount:    d.scanCount,
+		InjectCount:  d.injectCount,
+		UpgradeCount: d.upgradeCount,
+		Pending:      pending,
+	}
+}
+
+// handleControl 处理控制请求
+func (d *daemonRunner) handleControl(req *control.Request) *control.Response {
+	switch req.Command {
+	case control.CommandStatus:
+		d.mu.Lock()
+		status := d.snapshot
+		d.mu.Unlock()
+
+		status.Uptime = time.Since(d.startedAt)
+		status.Paused = d.paused.Load()
+
+		entries, err := newQuarantineStore().List()
+		if err != nil {
+			logger.Warn("Failed to load quarantine entries", zap.Error(err))
+		}
+		status.Quarantined = entries
+
+		return &control.Response{OK: true, Status: &status}
+
+	case control.CommandPause:
+		if !d.paused.Swap(true) {
+			d.metrics.paused.Set(1)
+			logger.Info("Injection paused via control socket")
+		}
+		return &control.Response{OK: true}
+
+	case control.CommandResume:
+		if d.paused.Swap(false) {
+			d.metrics.paused.Set(0)
+			logger.Info("Injection resumed via control socket")
+		}
+		d.triggerScan()
+		return &control.Response{OK: true}
+
+	case control.CommandScan:
+		if d.paused.Load() {
+			return &control.Response{Error: "injection is paused, resume first"}
+		}
+		d.triggerScan()
+		return &control.Response{OK: true}
+
+	case control.CommandReload:
+		settings, changes, err := d.reload()
+		if err != nil {
+			return &control.Response{Error: err.Error()}
+		}
+		d.queueReload(settings)
+		return &control.Response{OK: true, Changes: changes}
+
+	case control.CommandStop:
+		d.stopOnce.Do(func() { close(d.stop) })
+		return &control.Response{OK: true}
+
+	case control.CommandLogLevel:
+		if req.Level != "" {
+			if err := logger.SetLevel(req.Level); err != nil {
+				return &control.Response{Error: err.Error()}
+			}
+			logger.Info("Log level changed via control socket", zap.String("level", logger.Level()))
+		}
+		return &control.Response{OK: true, Level: logger.Level()}
+	}
+
+	return nil
+}
+
+// triggerScan 请求扫描循环立即全量扫描（已有待处理请求时合并）
+func (d *daemonRunner) triggerScan() {
+	select {
+	case d.scanNow <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"iast-auto-inject/internal/core/detector"
+	"iast-auto-inject/internal/core/injector"
+	"iast-auto-inject/internal/core/quarantine"
+	"iast-auto-inject/internal/pkg/logger"
+	"iast-auto-inject/internal/pkg/metrics"
+
+	"go.uber.org/zap"
+)
+
+// daemonMetrics 守护进程的 Prometheus 指标
+type daemonMetrics struct {
+	registry *metrics.Registry
+
+	scans          *metrics.Counter
+	scanErrors     *metrics.Counter
+	scanDuration   *metrics.Histogram
+	javaProcesses  *metrics.Gauge
+	agentProcesses *metrics.Gauge
+	operations     *metrics.Counter
+	restartSeconds *metrics.Histogram
+	pending        *metrics.Gauge
+	failing        *metrics.Gauge
+	paused         *metrics.Gauge
+}
+
+// newDaemonMetrics 注册守护进程指标
+func newDaemonMetrics() *daemonMetrics {
+	r := metrics.NewRegistry()
+
+	m := &daemonMetrics{
+		registry: r,
+		scans: r.NewCounter("iast_scans_total",
+			"Process scans performed by the daemon, by type (full or event).", "type"),
+		scanErrors: r.NewCounter("iast_scan_errors_total",
+			"Process scans that failed to list processes."),
+		scanDuration: r.NewHistogram("iast_scan_duration_seconds",
+			"Duration of full process scans including injections and upgrades.", metrics.DefaultBuckets),
+		javaProcesses: r.NewGauge("iast_java_processes",
+			"Java processes discovered in the last full scan."),
+		agentProcesses: r.NewGauge("iast_agent_processes",
+			"Java processes with and without each agent in the last full scan.", "agent", "attached"),
+		operations: r.NewCounter("iast_operations_total",
+			"Injections and upgrades attempted by the daemon, by result (success, skipped, failed) and reason.",
+			"operation", "result", "reason"),
+		restartSeconds: r.NewHistogram("iast_restart_duration_seconds",
+			"Duration of process restarts performed for injections and upgrades.", metrics.DefaultBuckets,
+			"operation", "result"),
+		pending: r.NewGauge("iast_pending_maintenance_processes",
+			"Processes queued until their maintenance window."),
+		failing: r.NewGauge("iast_failing_applications",
+			"Applications in failure backoff or quarantine.", "state"),
+		paused: r.NewGauge("iast_daemon_paused",
+			"Whether injection is paused via the control socket (1) or not (0)."),
+	}
+	m.paused.Set(0)
+	m.scanErrors.Add(0)
+
+	return m
+}
+
+// startMetrics 在配置的地址上提供 /metrics，未配置时不启动
+func (d *daemonRunner) startMetrics(ctx context.Context) {
+	addr := GetConfig().Daemon.MetricsAddress
+	if addr == "" {
+		return
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		logger.Warn("Metrics endpoint unavailable", zap.String("address", addr), zap.Error(err))
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", d.metrics.registry.Handler())
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Warn("Metrics endpoint stopped", zap.Error(err))
+		}
+	}()
+
+	logger.Info("Metrics endpoint listening", zap.String("address", listener.Addr().String()))
+}
+
+// observeProcesses 记录全量扫描发现的进程和各 agent 的覆盖情况
+func (m *daemonMetrics) observeProcesses(det *detector.Detector, procs []*detector.JavaProcess, agents map[string]string) {
+	m.javaProcesses.Set(float64(len(procs)))
+
+	// agent 列表可能随配置重新加载变化，先清空旧序列
+	m.agentProcesses.Reset()
+	for name, match := range agents {
+		attached := 0
+		for _, proc := range procs {
+			if hasMetricsAgent(det, proc, name, match) {
+				attached++
+			}
+		}
+		m.agentProcesses.Set(float64(attached), name, "true")
+		m.agentProcesses.Set(float64(len(procs)-attached), name, "false")
+	}
+}
+
+// recordResults 记录注入或升级结果
+func (m *daemonMetrics) recordResults(operation string, results []*injector.InjectResult) {
+	for _, result := range results {
+		outcome := result.Status()
+
+		reason := result.Code
+		if reason == "" && outcome == injector.StatusFailed {
+			reason = "unknown"
+		}
+		m.operations.Inc(operation, outcome, reason)
+
+		if result.RestartDuration > 0 {
+			m.restartSeconds.Observe(result.RestartDuration.Seconds(), operation, outcome)
+		}
+	}
+}
+
+// observeFailures 记录退避中和已隔离的应用数量
+func (m *daemonMetrics) observeFailures(failures *quarantine.Store) {
+	entries, err := failures.List()
+	if err != nil {
+		logger.Warn("Failed to load quarantine entries", zap.Error(err))
+		return
+	}
+
+	quarantined := 0
+	for _, entry := range entries {
+		if entry.Quarantined {
+			quarantined++
+		}
+	}
+	m.failing.Set(float64(len(entries)-quarantined), "backoff")
+	m.failing.Set(float64(quarantined), "quarantined")
+}
+
+// hasMetricsAgent 检查进程是否附加了指标中的 agent
+// SecPoint 按 jar 名称匹配，配置中的 agent 按解析后的路径匹配
+func hasMetricsAgent(det *detector.Detector, proc *detector.JavaProcess, name, path string) bool {
+	if name == detector.SecPointAgentName {
+		return proc.HasSecPoint()
+	}
+	return det.HasAgent(proc, path)
+}
+
+// metricsAgents 返回需要统计覆盖情况的 agent：SecPoint 和配置中启用的 agent（名称 -> 匹配的 jar）
+func metricsAgents() map[string]string {
+	agents := map[string]string{detector.SecPointAgentName: detector.SecPointAgentName}
+	for _, agent := range GetConfig().Agents {
+		if agent.Enabled {
+			agents[agent.Name] = agent.Path
+		}
+	}
+	return agents
+}
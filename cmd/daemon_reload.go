@@ -0,0 +1,260 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"iast-auto-inject/internal/core/config"
+	"iast-auto-inject/internal/core/detector"
+	"iast-auto-inject/internal/core/injector"
+	"iast-auto-inject/internal/core/process"
+	"iast-auto-inject/internal/core/quarantine"
+	"iast-auto-inject/internal/pkg/jar"
+	"iast-auto-inject/internal/pkg/logger"
+	"iast-auto-inject/internal/pkg/redact"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// daemonSettings 由配置构建的守护进程组件和参数，重新加载配置时整体替换
+type daemonSettings struct {
+	cfg          *config.Config
+	det          *detector.Detector
+	inj          *injector.StaticInjector
+	failures     *quarantine.Store
+	agentVersion string
+	interval     time.Duration // 轮询间隔
+	resync       time.Duration // 启用进程事件时的全量扫描间隔
+	minUptime    time.Duration // 新进程运行达到该时间后才处理
+}
+
+// restartOnlyKeys 运行中无法生效、需要重启守护进程的配置项前缀
+var restartOnlyKeys = []string{
+	"log.format",
+	"log.output",
+	"log.max_",
+	"log.compress",
+	"daemon.pid_file",
+	"daemon.control_socket",
+	"daemon.metrics_address",
+	"daemon.events",
+}
+
+// daemonReloadCmd daemon reload 命令
+var daemonReloadCmd = &cobra.Command{
+	Use:   "reload",
+	Short: "重新加载配置文件（等价于发送 SIGHUP）",
+	Args:  cobra.NoArgs,
+	RunE:  runDaemonReload,
+}
+
+func init() {
+	daemonCmd.AddCommand(daemonReloadCmd)
+}
+
+func runDaemonReload(cmd *cobra.Command, args []string) error {
+	changes, err := daemonClient().Reload()
+	if err != nil {
+		return err
+	}
+
+	if len(changes) == 0 {
+		fmt.Println("Configuration reloaded, no changes")
+		return nil
+	}
+
+	color.Green("Configuration reloaded, %d change(s):", len(changes))
+	for _, change := range changes {
+		fmt.Printf("  %s: %v -> %v\n", change.Key, change.Old, change.New)
+	}
+	return nil
+}
+
+// prepare 根据配置构建守护进程组件并验证 SecPoint.jar，失败时不影响当前运行的配置
+func (d *daemonRunner) prepare(cfg *config.Config) (*daemonSettings, error) {
+	// 获取扫描间隔（命令行参数优先）
+	interval := daemonInterval
+	if interval == 0 {
+		interval = cfg.Daemon.Interval
+	}
+	if interval == 0 {
+		interval = 60 * time.Second
+	}
+
+	// 获取 agent 版本策略
+	agentVersion := daemonAgentVersion
+	if agentVersion == "" {
+		agentVersion = cfg.Daemon.AgentVersion
+	}
+	if agentVersion != "" {
+		secPointVersion, err := jar.ReadVersion(d.secPoint)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read SecPoint version: %w", err)
+		}
+		if jar.CompareVersions(secPointVersion, agentVersion) < 0 {
+			return nil, fmt.Errorf("SecPoint.jar version %s is older than required agent version %s", secPointVersion, agentVersion)
+		}
+	}
+
+	// 创建组件
+	det := detector.NewDetector(cfg)
+	procMgr := process.NewManager(
+		cfg.Restart.GracePeriod,
+		cfg.Restart.KillTimeout,
+		cfg.Restart.VerifyWait,
+		cfg.Restart.MaxRetries,
+	)
+	inj := injector.NewStaticInjector(cfg, det, procMgr)
+	inj.EnforceMaintenanceWindows()
+
+	// 验证 agent jar
+	if err := inj.VerifyAgentJar(d.secPoint); err != nil {
+		return nil, fmt.Errorf("agent verification failed: %w", err)
+	}
+
+	return &daemonSettings{
+		cfg:          cfg,
+		det:          det,
+		inj:          inj,
+		failures:     quarantineStoreFor(cfg),
+		agentVersion: agentVersion,
+		interval:     interval,
+		resync:       cfg.Daemon.ResyncInterval,
+		minUptime:    cfg.Daemon.MinUptime,
+	}, nil
+}
+
+// apply 切换到新的配置，只能在扫描循环中调用
+func (d *daemonRunner) apply(settings *daemonSettings) {
+	d.daemonSettings = *settings
+	globalCfg.Store(settings.cfg)
+	redact.SetDefault(settings.cfg.Redaction.Redactor())
+
+	// 日志级别可以在运行时修改，重新加载时恢复为配置的级别
+	level := daemonLogLevel(settings.cfg)
+	if level != logger.Level() {
+		logger.Info("Setting log level", zap.String("level", level))
+		if err := logger.SetLevel(level); err != nil {
+			logger.Warn("Failed to set log level", zap.Error(err))
+		}
+	}
+}
+
+// daemonLogLevel 返回守护进程的日志级别：调试模式、daemon.log_level、log.level
+func daemonLogLevel(cfg *config.Config) string {
+	if cfg.Debug {
+		return "debug"
+	}
+	if cfg.Daemon.LogLevel != "" {
+		return cfg.Daemon.LogLevel
+	}
+	return cfg.Log.Level
+}
+
+// reload 重新读取并验证配置文件，返回构建好的组件和配置变更
+// 配置无效时返回错误，当前配置保持不变
+func (d *daemonRunner) reload() (*daemonSettings, []config.Change, error) {
+	settings, changes, err := d.loadSettings()
+	if err != nil {
+		logger.Error("Configuration reload failed, keeping current configuration", zap.Error(err))
+		return nil, nil, err
+	}
+
+	for _, change := range changes {
+		logger.Info("Configuration changed",
+			zap.String("key", change.Key),
+			zap.Any("old", redactChange(change.Key, change.Old)),
+			zap.Any("new", redactChange(change.Key, change.New)))
+
+		if requiresRestart(change.Key) {
+			logger.Warn("Configuration change takes effect after restart", zap.String("key", change.Key))
+		}
+	}
+	logger.Info("Configuration reloaded", zap.Int("changes", len(changes)))
+
+	return settings, changes, nil
+}
+
+// redactChange 隐藏配置变更中的敏感值（如 env 中的密码）
+func redactChange(key string, value interface{}) interface{} {
+	s, ok := value.(string)
+	if !ok {
+		return value
+	}
+	r := redact.Default()
+	if r.Sensitive(key[strings.LastIndex(key, ".")+1:]) {
+		return redact.Mask
+	}
+	return r.Text(s)
+}
+
+// loadSettings 读取配置文件并构建组件
+func (d *daemonRunner) loadSettings() (*daemonSettings, []config.Change, error) {
+	path := cfgFile
+	if path == "" {
+		path = config.FindDefaultPath()
+	}
+	if path == "" {
+		return nil, nil, fmt.Errorf("no configuration file to reload")
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if debug {
+		cfg.Debug = true
+	}
+
+	changes, err := config.Diff(GetConfig(), cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	settings, err := d.prepare(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return settings, changes, nil
+}
+
+// queueReload 将控制接口重新加载的配置交给扫描循环，只保留最新的一份
+func (d *daemonRunner) queueReload(settings *daemonSettings) {
+	for {
+		select {
+		case d.reloads <- settings:
+			return
+		default:
+		}
+		select {
+		case <-d.reloads:
+		default:
+		}
+	}
+}
+
+// reschedule 配置变更后重新计算扫描间隔和下次扫描时间
+func (d *daemonRunner) reschedule(events bool, period time.Duration, nextScan time.Time) (time.Duration, time.Time) {
+	newPeriod := d.interval
+	if events {
+		newPeriod = d.resync
+	}
+	if newPeriod == period {
+		return period, nextScan
+	}
+	return newPeriod, nextScan.Add(newPeriod - period)
+}
+
+// requiresRestart 判断配置项是否需要重启守护进程才能生效
+func requiresRestart(key string) bool {
+	for _, prefix := range restartOnlyKeys {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+
+	"iast-auto-inject/internal/pkg/pidfile"
+
+	"github.com/fatih/color"
+)
+
+const (
+	// daemonChildEnv 标记当前进程是后台化后的守护子进程
+	daemonChildEnv = "IAST_AUTO_INJECT_DAEMON_CHILD"
+
+	// defaultPidFile 默认 PID 文件路径
+	defaultPidFile = "/var/run/iast-auto-inject.pid"
+
+	// daemonStartTimeout 等待后台守护进程完成初始化的时间
+	daemonStartTimeout = 30 * time.Second
+
+	// readyOK 守护子进程初始化成功时写入管道的内容
+	readyOK = "ok"
+)
+
+// daemonPidFilePath 返回 PID 文件路径（命令行参数优先于配置文件）
+func daemonPidFilePath() string {
+	if daemonPidFile != "" {
+		return daemonPidFile
+	}
+	if path := GetConfig().Daemon.PidFile; path != "" {
+		return path
+	}
+	return defaultPidFile
+}
+
+// isDaemonChild 判断当前进程是否为后台化后的守护子进程
+func isDaemonChild() bool {
+	return os.Getenv(daemonChildEnv) == "1"
+}
+
+// startBackground 以新会话重新执行当前命令，等待子进程持有 PID 文件并完成初始化
+func startBackground(pidPath string) error {
+	// 提前拒绝，避免另一个实例运行时再启动子进程
+	if running, err := pidfile.Check(pidPath); err != nil {
+		return err
+	} else if running > 0 {
+		return &pidfile.RunningError{Path: pidPath, PID: running}
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate executable: %w", err)
+	}
+
+	devNull, err := os.OpenFile(os.DevNull, os.O_RDWR, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", os.DevNull, err)
+	}
+	defer devNull.Close()
+
+	readyRead, readyWrite, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("failed to create pipe: %w", err)
+	}
+	defer readyRead.Close()
+
+	child := exec.Command(executable, os.Args[1:]...)
+	child.Env = append(os.Environ(), daemonChildEnv+"=1")
+	child.Stdin = devNull
+	child.Stdout = devNull
+	child.Stderr = devNull
+	child.ExtraFiles = []*os.File{readyWrite} // 子进程中为 fd 3
+	child.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+
+	if err := child.Start(); err != nil {
+		readyWrite.Close()
+		return fmt.Errorf("failed to start daemon: %w", err)
+	}
+	readyWrite.Close()
+
+	// 子进程初始化完成时写入结果并关闭管道；异常退出时管道直接关闭
+	result := make(chan string, 1)
+	go func() {
+		data, _ := io.ReadAll(readyRead)
+		result <- strings.TrimSpace(string(data))
+	}()
+
+	select {
+	case msg := <-result:
+		if msg != readyOK {
+			child.Wait()
+			if msg == "" {
+				msg = fmt.Sprintf("daemon exited during startup (%s)", child.ProcessState)
+			}
+			return fmt.Errorf("%s", msg)
+		}
+	case <-time.After(daemonStartTimeout):
+		child.Process.Release()
+		return fmt.Errorf("daemon (pid %d) did not finish starting within %s, check the logs", child.Process.Pid, daemonStartTimeout)
+	}
+
+	color.Green("Daemon started in background (pid %d)", child.Process.Pid)
+	fmt.Printf("PID file: %s\n", pidPath)
+	if !hasPersistentLog(GetConfig().Log.Sinks()) {
+		color.Yellow("Log output is %s and is discarded in the background, set log.output to a file to keep daemon logs",
+			strings.Join(outputNames(GetConfig().Log.Sinks()), ", "))
+	}
+
+	return child.Process.Release()
+}
+
+// hasPersistentLog 判断后台运行时是否有日志输出会被保留（stdout、stderr 被丢弃）
+func hasPersistentLog(outputs []string) bool {
+	for _, output := range outputs {
+		if output != "stdout" && output != "stderr" {
+			return true
+		}
+	}
+	return false
+}
+
+// outputNames 返回日志输出名称
+func outputNames(outputs []string) []string {
+	if len(outputs) == 0 {
+		return []string{"stdout"}
+	}
+	return outputs
+}
+
+// readyNotifier 守护子进程向父进程报告初始化结果
+type readyNotifier struct {
+	file *os.File
+}
+
+// daemonReadyPipe 返回继承自父进程的通知管道，前台运行时返回 nil
+func daemonReadyPipe() *readyNotifier {
+	if !isDaemonChild() {
+		return nil
+	}
+	os.Unsetenv(daemonChildEnv)
+	return &readyNotifier{file: os.NewFile(3, "ready")}
+}
+
+// done 报告初始化结果，只有第一次调用生效
+func (r *readyNotifier) done(err error) {
+	if r == nil || r.file == nil {
+		return
+	}
+
+	msg := readyOK
+	if err != nil {
+		msg = err.Error()
+	}
+	r.file.WriteString(msg)
+	r.file.Close()
+	r.file = nil
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"iast-auto-inject/internal/core/detector"
+	"iast-auto-inject/internal/core/injector"
+	"iast-auto-inject/internal/core/process"
+	"iast-auto-inject/internal/pkg/logger"
+	"iast-auto-inject/internal/pkg/redact"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var (
+	ejectPids    []int
+	ejectAll     bool
+	ejectAgent   string
+	ejectSources []string
+	ejectDryRun  bool
+	ejectForce   bool
+)
+
+// ejectCmd eject 命令
+var ejectCmd = &cobra.Command{
+	Use:   "eject",
+	Short: "从 Java 进程中移除 agent",
+	Long: `从 Java 进程的命令行和/或 JAVA_TOOL_OPTIONS 中移除指定 agent，重启进程并验证 agent 已不存在。
+不依赖注入时保存的状态，可用于移除非本工具注入的 agent`,
+	Example: `  iast-auto-inject eject --pid 1234
+  iast-auto-inject eject --all --agent secpoint --source env`,
+	RunE: runEject,
+}
+
+func init() {
+	rootCmd.AddCommand(ejectCmd)
+
+	ejectCmd.Flags().IntSliceVarP(&ejectPids, "pid", "p", []int{}, "目标进程 PID（可多次指定）")
+	ejectCmd.Flags().BoolVarP(&ejectAll, "all", "a", false, "从所有已附加该 agent 的进程中移除")
+	ejectCmd.Flags().StringVar(&ejectAgent, "agent", detector.SecPointAgentName, "要移除的 agent 名称（jar 文件名，如 secpoint）")
+	ejectCmd.Flags().StringSliceVar(&ejectSources, "source", []string{"cmdline", "env"}, "移除来源 (cmdline, env)")
+	ejectCmd.Flags().BoolVarP(&ejectDryRun, "dry-run", "n", false, "模拟运行（不实际移除）")
+	ejectCmd.Flags().BoolVarP(&ejectForce, "force", "f", false, "强制移除（跳过确认）")
+}
+
+func runEject(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	// 检查参数
+	if len(ejectPids) == 0 && !ejectAll {
+		return fmt.Errorf("请指定目标进程（使用 --pid 或 --all）")
+	}
+
+	if len(ejectPids) > 0 && ejectAll {
+		return fmt.Errorf("--pid 和 --all 不能同时使用")
+	}
+
+	ejectOpts := &injector.EjectOptions{}
+	for _, source := range ejectSources {
+		switch source {
+		case "cmdline":
+			ejectOpts.FromCmdLine = true
+		case "env":
+			ejectOpts.FromEnv = true
+		default:
+			return fmt.Errorf("invalid source: %s (supported: cmdline, env)", source)
+		}
+	}
+
+	// 创建组件
+	det := detector.NewDetector(GetConfig())
+	procMgr := process.NewManager(
+		GetConfig().Restart.GracePeriod,
+		GetConfig().Restart.KillTimeout,
+		GetConfig().Restart.VerifyWait,
+		GetConfig().Restart.MaxRetries,
+	)
+	inj := injector.NewStaticInjector(GetConfig(), det, procMgr)
+
+	logger.Info("Ejecting agent",
+		zap.String("agent", ejectAgent),
+		zap.Strings("sources", ejectSources),
+		zap.Int("targets", len(ejectPids)))
+
+	// 获取已附加该 agent 的目标进程
+	filter := &detector.ProcessFilter{InScope: true}
+	if !ejectAll {
+		filter.PIDs = ejectPids
+	}
+	procs, err := det.DiscoverJavaProcesses(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to discover processes: %w", err)
+	}
+
+	var targetProcs []*detector.JavaProcess
+	for _, proc := range procs {
+		if det.FindAgent(proc, ejectAgent) != nil {
+			targetProcs = append(targetProcs, proc)
+		}
+	}
+
+	if len(targetProcs) == 0 {
+		color.Yellow("No processes with agent %s attached", ejectAgent)
+		return nil
+	}
+
+	// 显示目标进程
+	fmt.Println("\nTarget processes:")
+	fmt.Printf("Agent: %s\n\n", ejectAgent)
+	printInjectTargets(os.Stdout, targetProcs)
+
+	// 确认
+	if !ejectDryRun && !confirm("Proceed with removal?", ejectForce) {
+		fmt.Println("Removal cancelled")
+		return nil
+	}
+
+	// 模拟运行
+	if ejectDryRun {
+		color.Yellow("\n[DRY RUN] Would eject %s from:", ejectAgent)
+		for _, proc := range targetProcs {
+			for _, agent := range proc.Agents {
+				if detector.MatchAgentName(agent, ejectAgent) {
+					fmt.Printf("  PID %d: %s (%s)\n", proc.PID, redact.Default().Arg(agent.FullParam), agent.Source)
+				}
+			}
+		}
+		return nil
+	}
+
+	// 执行移除
+	results := inj.BatchEject(ctx, targetProcs, ejectAgent, ejectOpts)
+
+	// 显示结果
+	printInjectResults(os.Stdout, results)
+
+	successCount := 0
+	for _, result := range results {
+		if result.Success {
+			successCount++
+		}
+	}
+
+	logger.Info("Eject completed",
+		zap.Int("total", len(results)),
+		zap.Int("success", successCount),
+		zap.Int("failed", len(results)-successCount))
+
+	return exitWith(cmd, resultsExitCode(results), nil)
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"iast-auto-inject/internal/core/injector"
+
+	"github.com/spf13/cobra"
+)
+
+// 批量操作的退出码，便于 Ansible、CI 等根据结果处理
+const (
+	exitOK          = 0 // 全部成功
+	exitFailed      = 1 // 出错或全部失败
+	exitPartial     = 2 // 部分成功、部分失败
+	exitNothingToDo = 3 // 没有需要处理的进程
+	exitAborted     = 4 // 用户取消或被信号中断
+)
+
+// exitError 带退出码的错误，err 为 nil 时不输出错误信息
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	if e.err == nil {
+		return ""
+	}
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// exitWith 以指定退出码结束命令，退出码为 0 时返回 nil
+// 结果已经输出，不再显示用法
+func exitWith(cmd *cobra.Command, code int, err error) error {
+	if code == exitOK {
+		return nil
+	}
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	return &exitError{code: code, err: err}
+}
+
+// resultsExitCode 根据批量操作结果计算退出码
+// 被白名单或权限检查拒绝、或指定的进程不存在时，虽然结果为跳过，也按失败计算
+func resultsExitCode(results []*injector.InjectResult) int {
+	succeeded, failed := 0, 0
+	for _, result := range results {
+		switch {
+		case result.Status() == injector.StatusSuccess:
+			succeeded++
+		case result.Status() == injector.StatusFailed, result.Denied(), result.Code == injector.CodeNotFound:
+			failed++
+		}
+	}
+
+	switch {
+	case failed > 0 && succeeded > 0:
+		return exitPartial
+	case failed > 0:
+		return exitFailed
+	case succeeded == 0:
+		return exitNothingToDo
+	default:
+		return exitOK
+	}
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	"iast-auto-inject/internal/core/injector"
+)
+
+func TestResultsExitCode(t *testing.T) {
+	success := &injector.InjectResult{Success: true}
+	failed := &injector.InjectResult{Error: errors.New("restart failed"), Code: injector.CodeRestartFailed}
+	skipped := &injector.InjectResult{Message: "SecPoint already attached"}
+	policy := &injector.InjectResult{Reason: "policy skip", Code: injector.CodePolicy}
+	notAllowed := &injector.InjectResult{Error: errors.New("not allowed"), Reason: "not in allowlist", Code: injector.CodeNotAllowed}
+	permission := &injector.InjectResult{Error: errors.New("permission denied"), Reason: "permission denied", Code: injector.CodePermissionDenied}
+	notFound := &injector.InjectResult{Error: errors.New("process not found"), Code: injector.CodeNotFound}
+
+	tests := []struct {
+		name    string
+		results []*injector.InjectResult
+		want    int
+	}{
+		{"no results", nil, exitNothingToDo},
+		{"all success", []*injector.InjectResult{success, success}, exitOK},
+		{"success and skipped", []*injector.InjectResult{success, skipped, policy}, exitOK},
+		{"all failed", []*injector.InjectResult{failed}, exitFailed},
+		{"partial", []*injector.InjectResult{success, failed}, exitPartial},
+		{"only skipped", []*injector.InjectResult{skipped, policy}, exitNothingToDo},
+		{"denied by allowlist", []*injector.InjectResult{notAllowed}, exitFailed},
+		{"denied by permission", []*injector.InjectResult{policy, permission}, exitFailed},
+		{"success and denied", []*injector.InjectResult{success, notAllowed}, exitPartial},
+		{"pid not found", []*injector.InjectResult{notFound}, exitFailed},
+		{"success and pid not found", []*injector.InjectResult{success, notFound}, exitPartial},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resultsExitCode(tt.results); got != tt.want {
+				t.Fatalf("resultsExitCode() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"iast-auto-inject/internal/core/detector"
+	"iast-auto-inject/internal/core/injector"
+	"iast-auto-inject/internal/core/policy"
+	"iast-auto-inject/internal/core/process"
+
+	"iast-auto-inject/internal/pkg/redact"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	explainPid int
+)
+
+// explainCmd explain 命令
+var explainCmd = &cobra.Command{
+	Use:   "explain",
+	Short: "说明注入策略对指定进程的决策",
+	Long: `按顺序评估注入策略中的每条规则，显示进程信息、每个条件的匹配结果以及最终决策。
+第一个匹配的规则生效，没有规则匹配时使用 default_action`,
+	Example: `  iast-auto-inject explain --pid 1234`,
+	RunE:    runExplain,
+}
+
+func init() {
+	rootCmd.AddCommand(explainCmd)
+
+	explainCmd.Flags().IntVarP(&explainPid, "pid", "p", 0, "目标进程 PID（必需）")
+}
+
+func runExplain(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	if explainPid <= 0 {
+		return fmt.Errorf("请指定目标进程（使用 --pid）")
+	}
+
+	det := detector.NewDetector(GetConfig())
+	procs, err := det.DiscoverJavaProcesses(ctx, &detector.ProcessFilter{PIDs: []int{explainPid}})
+	if err != nil {
+		return fmt.Errorf("failed to discover processes: %w", err)
+	}
+	if len(procs) == 0 {
+		return fmt.Errorf("java process %d not found", explainPid)
+	}
+	proc := procs[0]
+	proc.LoadDetails()
+
+	procMgr := process.NewManager(
+		GetConfig().Restart.GracePeriod,
+		GetConfig().Restart.KillTimeout,
+		GetConfig().Restart.VerifyWait,
+		GetConfig().Restart.MaxRetries,
+	)
+	inj := injector.NewStaticInjector(GetConfig(), det, procMgr)
+
+	eval := inj.Explain(proc)
+
+	// 进程信息
+	color.Cyan("Process %d", proc.PID)
+	fmt.Printf("  User:         %s (uid %d)\n", proc.User, proc.UID)
+	fmt.Printf("  Groups:       %s\n", orNone(strings.Join(proc.Groups, ", ")))
+	fmt.Printf("  Cwd:          %s\n", proc.Cwd)
+	fmt.Printf("  Jar:          %s\n", orNone(proc.JarFile))
+	fmt.Printf("  Main Class:   %s\n", orNone(proc.MainClass))
+	fmt.Printf("  Java Version: %s\n", orNone(proc.JavaVersion))
+	fmt.Printf("  Systemd Unit: %s\n", orNone(proc.SystemdUnit))
+	fmt.Printf("  Container:    %s\n", orNone(proc.ContainerID))
+
+	// 规则匹配过程
+	fmt.Println()
+	color.Cyan("Rules (first match wins)")
+	if len(eval.Traces) == 0 {
+		fmt.Println("  (no rules configured)")
+	}
+
+	green := color.New(color.FgGreen).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+
+	for _, trace := range eval.Traces {
+		mark := red("✗")
+		if trace.Matched {
+			mark = green("✓")
+		}
+		fmt.Printf("  %s %s -> %s\n", mark, trace.Rule, trace.Action)
+		for _, reason := range trace.Reasons {
+			fmt.Printf("      %s\n", reason)
+		}
+	}
+
+	// 最终决策
+	decision := eval.Decision
+	fmt.Println()
+	color.Cyan("Decision")
+	fmt.Printf("  Rule:     %s\n", policyRuleName(decision))
+	fmt.Printf("  Action:   %s\n", decision.Action)
+	fmt.Printf("  Strategy: %s\n", decision.Strategy)
+	if len(decision.Agents) > 0 {
+		fmt.Printf("  Agents:   %s\n", strings.Join(decision.Agents, ", "))
+	}
+	if decision.Options != "" {
+		fmt.Printf("  Options:  %s\n", redact.Default().Options(decision.Options))
+	}
+	if det.HasSecPointAgent(proc) {
+		fmt.Println("  SecPoint agent already attached")
+	}
+
+	return nil
+}
+
+// policyRuleName 决策对应的规则名称
+func policyRuleName(decision *policy.Decision) string {
+	if decision.Rule == "" {
+		return "default"
+	}
+	return decision.Rule
+}
+
+// orNone 空字符串显示为 -
+func orNone(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
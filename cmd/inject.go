@@ -2,15 +2,21 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
 	"text/tabwriter"
 
+	"iast-auto-inject/internal/core/config"
 	"iast-auto-inject/internal/core/detector"
 	"iast-auto-inject/internal/core/injector"
 	"iast-auto-inject/internal/core/process"
 	"iast-auto-inject/internal/pkg/logger"
+	"iast-auto-inject/internal/pkg/textutil"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
@@ -23,14 +29,25 @@ var (
 	injectSecPoint string
 	injectDryRun   bool
 	injectForce    bool
+	injectOutput   string
 )
 
 // injectCmd inject 命令
 var injectCmd = &cobra.Command{
 	Use:   "inject",
 	Short: "注入 SecPoint agent 到 Java 进程",
-	Long:  `向指定的 Java 进程注入 SecPoint.jar，需要重启进程`,
-	RunE:  runInject,
+	Long: `向指定的 Java 进程注入 SecPoint.jar，需要重启进程
+
+使用 --output json 时在标准输出输出所有进程的注入结果（JSON 数组），其他信息写到标准错误。
+
+退出码：
+  0  全部成功
+  1  出错或全部失败（被白名单或权限检查拒绝、--pid 指定的进程不存在也视为失败）
+  2  部分成功、部分失败
+  3  没有需要注入的进程（未找到目标或全部被跳过）
+  4  已取消（未确认或被 SIGINT/SIGTERM 中断）
+使用 --dry-run 时返回实际注入时预期的退出码`,
+	RunE: runInject,
 }
 
 func init() {
@@ -41,10 +58,13 @@ func init() {
 	injectCmd.Flags().StringVarP(&injectSecPoint, "secpoint", "s", "", "SecPoint.jar 路径（必需）")
 	injectCmd.Flags().BoolVarP(&injectDryRun, "dry-run", "n", false, "模拟运行（不实际注入）")
 	injectCmd.Flags().BoolVarP(&injectForce, "force", "f", false, "强制注入（跳过确认）")
+	injectCmd.Flags().StringVarP(&injectOutput, "output", "o", "table", "结果输出格式 (table, json)")
 }
 
 func runInject(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
+	// SIGINT/SIGTERM 停止滚动注入，正在重启的进程完成后不再处理剩余进程
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	// 检查参数
 	if injectSecPoint == "" {
@@ -59,6 +79,17 @@ func runInject(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("--pid 和 --all 不能同时使用")
 	}
 
+	if injectOutput != "table" && injectOutput != "json" {
+		return fmt.Errorf("unknown output format %q (expected table or json)", injectOutput)
+	}
+
+	// JSON 输出时标准输出只包含结果，其他信息写到标准错误
+	out := io.Writer(os.Stdout)
+	if injectOutput == "json" {
+		out = os.Stderr
+		color.Output = os.Stderr
+	}
+
 	// 创建组件
 	det := detector.NewDetector(GetConfig())
 	procMgr := process.NewManager(
@@ -69,95 +100,183 @@ func runInject(cmd *cobra.Command, args []string) error {
 	)
 	inj := injector.NewStaticInjector(GetConfig(), det, procMgr)
 
+	// 验证 agent jar
+	if err := inj.VerifyAgentJar(injectSecPoint); err != nil {
+		return fmt.Errorf("agent verification failed: %w", err)
+	}
+
 	logger.Info("Injecting SecPoint agent",
 		zap.String("agent_path", injectSecPoint),
 		zap.Int("targets", len(injectPids)))
 
-	// 获取目标进程
+	// 获取目标进程，missing 为 --pid 指定但未找到的进程
 	var targetProcs []*detector.JavaProcess
+	var missing []*injector.InjectResult
 
 	if injectAll {
 		// 获取所有进程
-		procs, err := det.DiscoverJavaProcesses(ctx, nil)
+		procs, err := det.DiscoverJavaProcesses(ctx, &detector.ProcessFilter{InScope: true})
 		if err != nil {
 			return fmt.Errorf("failed to discover processes: %w", err)
 		}
 
-		// 过滤需要注入的进程（未包含 SecPoint 的）
+		// 过滤需要注入的进程（未包含 SecPoint 且策略允许注入的）
+		var observed []int
 		for _, proc := range procs {
 			if inj.NeedsInject(proc) {
 				targetProcs = append(targetProcs, proc)
+			} else if inj.Decide(proc).Action == config.PolicyActionObserve {
+				observed = append(observed, proc.PID)
 			}
 		}
+
+		if len(observed) > 0 {
+			color.Cyan("Observe only (not injected by policy): %v", observed)
+		}
 	} else {
 		// 获取指定 PID 的进程
 		for _, pid := range injectPids {
-			procs, err := det.DiscoverJavaProcesses(ctx, &detector.ProcessFilter{PIDs: []int{pid}})
+			procs, err := det.DiscoverJavaProcesses(ctx, &detector.ProcessFilter{PIDs: []int{pid}, InScope: true})
 			if err != nil {
 				logger.Warn("Failed to get process info", zap.Int("pid", pid), zap.Error(err))
-				continue
 			}
 			if len(procs) > 0 {
 				targetProcs = append(targetProcs, procs[0])
+				continue
 			}
+
+			err = fmt.Errorf("process %d not found, not a Java process or out of scope", pid)
+			color.Red("PID %d: %v", pid, err)
+			missing = append(missing, &injector.InjectResult{
+				PID:     pid,
+				Agent:   injectSecPoint,
+				Error:   err,
+				Code:    injector.CodeNotFound,
+				Message: err.Error(),
+			})
 		}
 	}
 
 	if len(targetProcs) == 0 {
 		color.Yellow("No target processes found")
-		return nil
+		if err := writeInjectResults(missing); err != nil {
+			return err
+		}
+		if len(missing) > 0 {
+			return exitWith(cmd, exitFailed, nil)
+		}
+		return exitWith(cmd, exitNothingToDo, nil)
 	}
 
 	// 显示目标进程
-	fmt.Println("\nTarget processes:")
-	fmt.Printf("SecPoint Agent: %s\n\n", injectSecPoint)
-	printInjectTargets(targetProcs)
+	fmt.Fprintln(out, "\nTarget processes:")
+	fmt.Fprintf(out, "SecPoint Agent: %s\n\n", injectSecPoint)
+	printInjectTargets(out, targetProcs)
 
 	// 确认
-	if !injectForce && !injectDryRun {
-		fmt.Print("\nProceed with injection? (y/N): ")
-		var confirm string
-		fmt.Scanln(&confirm)
-		if confirm != "y" && confirm != "Y" {
-			fmt.Println("Injection cancelled")
-			return nil
-		}
+	if !injectDryRun && !confirmOn(out, "Proceed with injection?", injectForce) {
+		fmt.Fprintln(out, "Injection cancelled")
+		return exitWith(cmd, exitAborted, nil)
 	}
 
 	// 模拟运行
 	if injectDryRun {
 		color.Yellow("\n[DRY RUN] Would inject SecPoint to:")
+		// predicted 为实际注入时的预期结果，计划注入的进程按成功计算，用于返回与实际注入相同的退出码
+		var planned, predicted []*injector.InjectResult
 		for _, proc := range targetProcs {
-			fmt.Printf("  PID %d: %s\n", proc.PID, proc.JarFile)
+			result := &injector.InjectResult{
+				PID:        proc.PID,
+				OldCmdLine: proc.CmdLine,
+				OldAgents:  proc.Agents,
+				Agent:      injectSecPoint,
+				Decision:   inj.Decide(proc),
+			}
+			planned = append(planned, result)
+			predicted = append(predicted, result)
+
+			if det.HasSecPointAgent(proc) {
+				result.Message = "SecPoint agent already attached"
+				fmt.Fprintf(out, "  PID %d: skipped, SecPoint agent already attached\n", proc.PID)
+				continue
+			}
+			if result.Decision.Action != config.PolicyActionInject {
+				result.Reason = fmt.Sprintf("policy %s (%s)", policyRuleName(result.Decision), result.Decision.Action)
+				result.Code = injector.CodePolicy
+				result.Message = "Skipped by " + result.Reason
+				fmt.Fprintf(out, "  PID %d: skipped by policy %s (%s)\n", proc.PID, policyRuleName(result.Decision), result.Decision.Action)
+				continue
+			}
+			agentArg, err := inj.BuildAgentArg(proc, injectSecPoint)
+			if err != nil {
+				result.Error = err
+				result.Code = injector.CodeAgentOptions
+				result.Message = fmt.Sprintf("Failed to render agent options: %v", err)
+				fmt.Fprintf(out, "  PID %d: %s\n", proc.PID, result.Message)
+				continue
+			}
+			result.Message = "[DRY RUN] Would inject " + agentArg
+			predicted[len(predicted)-1] = &injector.InjectResult{PID: proc.PID, Success: true}
+			fmt.Fprintf(out, "  PID %d: %s\n", proc.PID, proc.JarFile)
+			fmt.Fprintf(out, "    %s\n", agentArg)
 		}
-		return nil
+		if err := writeInjectResults(append(planned, missing...)); err != nil {
+			return err
+		}
+		return exitWith(cmd, resultsExitCode(append(predicted, missing...)), nil)
 	}
 
 	// 执行注入
-	results := inj.BatchInject(ctx, targetProcs, injectSecPoint)
+	injected := inj.BatchInject(ctx, targetProcs, injectSecPoint)
 
-	// 显示结果
-	printInjectResults(results)
+	// 显示结果（未找到的进程已在前面显示），JSON 结果和退出码包含未找到的进程
+	printInjectResults(out, injected)
+	results := append(injected, missing...)
+	if err := writeInjectResults(results); err != nil {
+		return err
+	}
 
 	// 记录日志
-	successCount := 0
+	successCount, failedCount := 0, 0
 	for _, result := range results {
-		if result.Success {
+		switch result.Status() {
+		case injector.StatusSuccess:
 			successCount++
+		case injector.StatusFailed:
+			failedCount++
 		}
 	}
 
 	logger.Info("Injection completed",
 		zap.Int("total", len(results)),
 		zap.Int("success", successCount),
-		zap.Int("failed", len(results)-successCount))
+		zap.Int("failed", failedCount),
+		zap.Int("skipped", len(results)-successCount-failedCount))
+
+	if ctx.Err() != nil {
+		color.Yellow("Injection interrupted, remaining processes were not restarted")
+		return exitWith(cmd, exitAborted, nil)
+	}
+	return exitWith(cmd, resultsExitCode(results), nil)
+}
+
+// writeInjectResults 使用 --output json 时在标准输出输出结果
+func writeInjectResults(results []*injector.InjectResult) error {
+	if injectOutput != "json" {
+		return nil
+	}
+	if results == nil {
+		results = []*injector.InjectResult{}
+	}
 
-	return nil
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(results)
 }
 
 // printInjectTargets 打印注入目标
-func printInjectTargets(procs []*detector.JavaProcess) {
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+func printInjectTargets(out io.Writer, procs []*detector.JavaProcess) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
 
 	fmt.Fprintln(w, "PID\tUser\tMain Class/JAR\t\tSecPoint Status")
 
@@ -174,30 +293,33 @@ func printInjectTargets(procs []*detector.JavaProcess) {
 		}
 
 		secPointStatus := red("✗ Not attached")
-		if len(proc.Agents) > 0 {
+		if proc.HasSecPoint() {
 			secPointStatus = green("✓ Attached")
 		}
 
 		fmt.Fprintf(w, "%d\t%s\t%s\t\t%s\n",
-			proc.PID, proc.User, truncate(main, 25), secPointStatus)
+			proc.PID, proc.User, textutil.Truncate(main, 25), secPointStatus)
 	}
 
 	w.Flush()
 }
 
 // printInjectResults 打印注入结果
-func printInjectResults(results []*injector.InjectResult) {
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+func printInjectResults(out io.Writer, results []*injector.InjectResult) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
 
 	fmt.Fprintln(w, "\nResults:")
 	fmt.Fprintln(w, "PID\tStatus\tNew PID\tMessage")
 
 	green := color.New(color.FgGreen).SprintFunc()
 	red := color.New(color.FgRed).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
 
 	for _, result := range results {
 		status := green("✓ Success")
-		if !result.Success {
+		if result.Reason != "" {
+			status = yellow("✗ Rejected")
+		} else if !result.Success {
 			status = red("✗ Failed")
 		}
 
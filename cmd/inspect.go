@@ -0,0 +1,479 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"iast-auto-inject/internal/core/audit"
+	"iast-auto-inject/internal/core/detector"
+	"iast-auto-inject/internal/core/injector"
+	"iast-auto-inject/internal/core/policy"
+	"iast-auto-inject/internal/core/process"
+	"iast-auto-inject/internal/core/quarantine"
+	"iast-auto-inject/internal/pkg/logger"
+	"iast-auto-inject/internal/pkg/procfs"
+	"iast-auto-inject/internal/pkg/redact"
+	"iast-auto-inject/internal/pkg/textutil"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var (
+	inspectOutput  string
+	inspectHistory int
+)
+
+// inspectCmd inspect 命令
+var inspectCmd = &cobra.Command{
+	Use:   "inspect <pid>",
+	Short: "显示 Java 进程的完整报告",
+	Long: `显示指定 Java 进程的完整报告：解析后的 JVM 命令行、agent 及其来源、环境变量、工作目录、
+可执行文件、用户和用户组、父进程链、systemd unit 或容器、监听端口、内存、注入策略决策，
+以及审计日志中的注入历史和失败记录中的退避、隔离状态。
+
+环境变量、系统属性、agent 选项和 URL 中的密码、token 等敏感值按 redaction 配置隐藏。`,
+	Example: `  iast-auto-inject inspect 1234
+  iast-auto-inject inspect 1234 -o json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runInspect,
+}
+
+func init() {
+	rootCmd.AddCommand(inspectCmd)
+
+	inspectCmd.Flags().StringVarP(&inspectOutput, "output", "o", "table", "输出格式 (table, json)")
+	inspectCmd.Flags().IntVar(&inspectHistory, "history", 10, "显示最近的注入记录数")
+}
+
+// inspectReport inspect 的报告，-o json 时原样输出
+type inspectReport struct {
+	Process     *detector.JavaProcess `json:"process"`
+	CommandLine *jvmCommandLine       `json:"command_line"`
+	Parents     []inspectParent       `json:"parents"`
+	Memory      *procfs.MemoryStats   `json:"memory,omitempty"`
+	Policy      *inspectPolicy        `json:"policy"`
+	Quarantine  *quarantine.Entry     `json:"quarantine,omitempty"`
+	History     []*audit.Record       `json:"history"`
+}
+
+// jvmCommandLine 解析后的 JVM 命令行
+type jvmCommandLine struct {
+	Java       string            `json:"java"`
+	Main       string            `json:"main"` // 主类、-jar <jar> 或 -m <module>
+	Classpath  string            `json:"classpath,omitempty"`
+	Properties map[string]string `json:"properties,omitempty"` // -D 系统属性
+	Agents     []string          `json:"agents,omitempty"`     // -javaagent、-agentpath、-agentlib
+	Options    []string          `json:"options,omitempty"`    // 其他 JVM 选项
+	Args       []string          `json:"args,omitempty"`       // 应用程序参数
+}
+
+// inspectParent 父进程
+type inspectParent struct {
+	PID     int    `json:"pid"`
+	Name    string `json:"name"`
+	CmdLine string `json:"cmdline"`
+}
+
+// inspectPolicy 注入策略决策
+type inspectPolicy struct {
+	Decision    *policy.Decision `json:"decision"`
+	NeedsInject bool             `json:"needs_inject"`
+	Blocked     string           `json:"blocked,omitempty"`     // 安全白名单或权限检查不通过的原因
+	NextWindow  string           `json:"next_window,omitempty"` // 待注入进程的下一个维护窗口
+}
+
+func runInspect(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	if inspectOutput != "table" && inspectOutput != "json" {
+		return fmt.Errorf("unknown output format %q (expected table or json)", inspectOutput)
+	}
+
+	pid, err := strconv.Atoi(args[0])
+	if err != nil || pid <= 0 {
+		return fmt.Errorf("invalid pid %q", args[0])
+	}
+
+	det := detector.NewDetector(GetConfig())
+	procs, err := det.DiscoverJavaProcesses(ctx, &detector.ProcessFilter{PIDs: []int{pid}})
+	if err != nil {
+		return fmt.Errorf("failed to discover processes: %w", err)
+	}
+	if len(procs) == 0 {
+		return fmt.Errorf("java process %d not found", pid)
+	}
+
+	procs[0].LoadDetails()
+	report, err := buildInspectReport(det, procs[0], redact.Default())
+	if err != nil {
+		return err
+	}
+
+	if inspectOutput == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+
+	printInspectReport(report)
+	return nil
+}
+
+// buildInspectReport 收集进程的完整信息，敏感值已隐藏
+func buildInspectReport(det *detector.Detector, proc *detector.JavaProcess, r *redact.Redactor) (*inspectReport, error) {
+	procMgr := process.NewManager(
+		GetConfig().Restart.GracePeriod,
+		GetConfig().Restart.KillTimeout,
+		GetConfig().Restart.VerifyWait,
+		GetConfig().Restart.MaxRetries,
+	)
+	inj := injector.NewStaticInjector(GetConfig(), det, procMgr)
+
+	report := &inspectReport{
+		Process:     proc.Redacted(r),
+		CommandLine: parseJVMCommandLine(proc.CmdLine, r),
+		Parents:     processParents(proc.PID, r),
+		Policy:      inspectPolicyOf(inj, det, proc),
+	}
+
+	if memory, err := procfs.ReadMemoryStats(proc.PID); err == nil {
+		report.Memory = memory
+	}
+
+	// 失败记录和审计日志不可读时不影响报告的其他部分
+	if state, err := newQuarantineStore().Load(); err == nil {
+		report.Quarantine = state.Entries[quarantineKey(proc)]
+	}
+
+	// 审计日志通常只有 root 可读，其他用户查看时不显示历史
+	history, err := injectionHistory(proc, inspectHistory)
+	if err != nil {
+		logger.Warn("Failed to read injection history, showing report without it",
+			zap.Int("pid", proc.PID),
+			zap.Error(err))
+	}
+	for _, rec := range history {
+		rec.OldArgv = r.Args(rec.OldArgv)
+		rec.NewArgv = r.Args(rec.NewArgv)
+	}
+	report.History = history
+
+	return report, nil
+}
+
+// parseJVMCommandLine 将命令行拆分为 JVM 选项、主类和应用程序参数
+func parseJVMCommandLine(cmdLine []string, r *redact.Redactor) *jvmCommandLine {
+	parsed := &jvmCommandLine{}
+	if len(cmdLine) == 0 {
+		return parsed
+	}
+
+	parsed.Java = cmdLine[detector.FindJavaIndex(cmdLine)]
+
+	start, end := detector.JVMOptionsRange(cmdLine)
+	for i := start; i < end; i++ {
+		arg := cmdLine[i]
+
+		// JVMOptionsRange 范围内不以 - 开头的参数是上一个选项的值
+		value := ""
+		if i+1 < end && !strings.HasPrefix(cmdLine[i+1], "-") {
+			value = cmdLine[i+1]
+		}
+
+		switch {
+		case arg == "-cp" || arg == "-classpath" || arg == "--class-path":
+			parsed.Classpath = value
+			i++
+		case strings.HasPrefix(arg, "-D"):
+			key, val, _ := strings.Cut(strings.TrimPrefix(arg, "-D"), "=")
+			if parsed.Properties == nil {
+				parsed.Properties = make(map[string]string)
+			}
+			parsed.Properties[key] = r.Value(key, val)
+		case strings.HasPrefix(arg, "-javaagent") || strings.HasPrefix(arg, "-agentpath") ||
+			strings.HasPrefix(arg, "-agentlib"):
+			parsed.Agents = append(parsed.Agents, r.Arg(arg))
+		case value != "":
+			parsed.Options = append(parsed.Options, r.Arg(arg)+" "+r.Arg(value))
+			i++
+		default:
+			parsed.Options = append(parsed.Options, r.Arg(arg))
+		}
+	}
+
+	if end < len(cmdLine) {
+		rest := cmdLine[end:]
+		switch rest[0] {
+		case "-jar", "-m", "--module":
+			if len(rest) > 1 {
+				parsed.Main = rest[0] + " " + rest[1]
+				rest = rest[2:]
+			} else {
+				parsed.Main, rest = rest[0], nil
+			}
+		default:
+			parsed.Main, rest = rest[0], rest[1:]
+		}
+		parsed.Args = r.AppArgs(rest)
+	}
+
+	return parsed
+}
+
+// processParents 返回父进程链（从直接父进程到 init）
+func processParents(pid int, r *redact.Redactor) []inspectParent {
+	var parents []inspectParent
+
+	status, err := procfs.ReadStatus(pid)
+	if err != nil {
+		return nil
+	}
+
+	// 限制深度，避免读取过程中 PID 复用造成循环
+	for ppid := status.PPID; ppid > 0 && len(parents) < 64; {
+		status, err := procfs.ReadStatus(ppid)
+		if err != nil {
+			break
+		}
+		cmdLine, _ := procfs.ReadCmdline(ppid)
+		parents = append(parents, inspectParent{
+			PID:     ppid,
+			Name:    status.Name,
+			CmdLine: strings.Join(r.Args(cmdLine), " "),
+		})
+		ppid = status.PPID
+	}
+
+	return parents
+}
+
+// inspectPolicyOf 评估进程的注入策略
+func inspectPolicyOf(inj *injector.StaticInjector, det *detector.Detector, proc *detector.JavaProcess) *inspectPolicy {
+	result := &inspectPolicy{
+		Decision:    inj.Decide(proc),
+		NeedsInject: inj.NeedsInject(proc),
+	}
+
+	if err := det.CheckAllowed(proc); err != nil {
+		result.Blocked = err.Error()
+	} else if err := det.CheckPermissions(proc); err != nil {
+		result.Blocked = err.Error()
+	}
+
+	if result.NeedsInject && GetConfig().HasMaintenanceWindows() {
+		if active, next := inj.MaintenanceWindow(proc, time.Now()); active {
+			result.NextWindow = "now"
+		} else {
+			result.NextWindow = formatWindow(next)
+		}
+	}
+
+	return result
+}
+
+// injectionHistory 返回审计日志中与进程相关的最近 limit 条记录：
+// 操作过该进程、重启后产生该进程，或属于同一用户的同一服务
+// 未配置审计日志时返回空
+func injectionHistory(proc *detector.JavaProcess, limit int) ([]*audit.Record, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+
+	cfg := GetConfig().Audit
+	if cfg == nil || !cfg.Enabled || cfg.File == "" {
+		return nil, nil
+	}
+
+	records, err := audit.Open(cfg.File).Read(time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	service := injector.ServiceKey(proc)
+	var history []*audit.Record
+	for _, rec := range records {
+		if rec.PID == proc.PID || rec.NewPID == proc.PID ||
+			(rec.User == proc.User && rec.Service == service) {
+			history = append(history, rec)
+		}
+	}
+
+	if len(history) > limit {
+		history = history[len(history)-limit:]
+	}
+	return history, nil
+}
+
+// printInspectReport 显示报告
+func printInspectReport(report *inspectReport) {
+	proc := report.Process
+
+	color.Cyan("Process %d (%s)", proc.PID, proc.Name)
+	fmt.Printf("  Executable:   %s\n", orNone(proc.ExecPath))
+	fmt.Printf("  Java Version: %s\n", orNone(proc.JavaVersion))
+	fmt.Printf("  User:         %s (uid %d, gid %d)\n", proc.User, proc.UID, proc.GID)
+	fmt.Printf("  Groups:       %s\n", orNone(strings.Join(proc.Groups, ", ")))
+	fmt.Printf("  Cwd:          %s\n", orNone(proc.Cwd))
+	fmt.Printf("  Started:      %s\n", orNone(proc.StartTime))
+	fmt.Printf("  Systemd Unit: %s\n", orNone(proc.SystemdUnit))
+	fmt.Printf("  Container:    %s\n", orNone(proc.ContainerID))
+	fmt.Printf("  Threads:      %d\n", proc.Threads)
+	fmt.Printf("  Open FDs:     %d\n", proc.OpenFDs)
+
+	cl := report.CommandLine
+	fmt.Println()
+	color.Cyan("Command Line")
+	fmt.Printf("  Java:         %s\n", orNone(cl.Java))
+	fmt.Printf("  Main:         %s\n", orNone(cl.Main))
+	if cl.Classpath != "" {
+		fmt.Printf("  Classpath:    %s\n", cl.Classpath)
+	}
+	printInspectList("Agents:", cl.Agents)
+	printInspectList("Options:", cl.Options)
+	var properties []string
+	for _, key := range sortedKeys(cl.Properties) {
+		properties = append(properties, key+"="+cl.Properties[key])
+	}
+	printInspectList("Properties:", properties)
+	printInspectList("Arguments:", cl.Args)
+
+	fmt.Println()
+	color.Cyan("Agents")
+	if len(proc.Agents) == 0 {
+		fmt.Println("  (none)")
+	}
+	for _, agent := range proc.Agents {
+		fmt.Printf("  %s\n", agent.Path)
+		fmt.Printf("    Version: %s\n", orNone(agent.Version))
+		fmt.Printf("    Source:  %s\n", agent.Source)
+		if agent.Options != "" {
+			fmt.Printf("    Options: %s\n", agent.Options)
+		}
+	}
+
+	fmt.Println()
+	color.Cyan("Parent Chain")
+	if len(report.Parents) == 0 {
+		fmt.Println("  (none)")
+	}
+	for _, parent := range report.Parents {
+		fmt.Printf("  %-7d %-16s %s\n", parent.PID, parent.Name, textutil.Truncate(parent.CmdLine, 80))
+	}
+
+	fmt.Println()
+	color.Cyan("Listening Ports")
+	if len(proc.Listening) == 0 {
+		fmt.Println("  (none)")
+	}
+	for _, listener := range proc.Listening {
+		fmt.Printf("  %-5s %s\n", listener.Proto, formatSocketAddr(listener.Address, listener.Port))
+	}
+	fmt.Printf("  Established connections: %d\n", proc.Established)
+
+	if mem := report.Memory; mem != nil {
+		fmt.Println()
+		color.Cyan("Memory")
+		fmt.Printf("  RSS:          %s\n", procfs.FormatMemory(mem.RSS))
+		fmt.Printf("  Virtual:      %s\n", procfs.FormatMemory(mem.VMS))
+		fmt.Printf("  Shared:       %s\n", procfs.FormatMemory(mem.Shared))
+		fmt.Printf("  Text:         %s\n", procfs.FormatMemory(mem.Text))
+		fmt.Printf("  Data:         %s\n", procfs.FormatMemory(mem.Data))
+	}
+
+	pol := report.Policy
+	fmt.Println()
+	color.Cyan("Policy")
+	fmt.Printf("  Rule:         %s\n", policyRuleName(pol.Decision))
+	fmt.Printf("  Action:       %s\n", pol.Decision.Action)
+	fmt.Printf("  Strategy:     %s\n", pol.Decision.Strategy)
+	if len(pol.Decision.Agents) > 0 {
+		fmt.Printf("  Agents:       %s\n", strings.Join(pol.Decision.Agents, ", "))
+	}
+	if pol.Blocked != "" {
+		fmt.Printf("  Blocked:      %s\n", color.RedString(pol.Blocked))
+	}
+	fmt.Printf("  Needs Inject: %v\n", pol.NeedsInject)
+	if pol.NextWindow != "" {
+		fmt.Printf("  Next Window:  %s\n", pol.NextWindow)
+	}
+
+	if entry := report.Quarantine; entry != nil {
+		fmt.Println()
+		color.Cyan("Failures")
+		fmt.Printf("  ID:           %s\n", entry.ID)
+		fmt.Printf("  Attempts:     %d\n", entry.Attempts)
+		fmt.Printf("  Last Failure: %s (pid %d)\n", entry.LastFailure.Local().Format("2006-01-02 15:04:05"), entry.LastPID)
+		fmt.Printf("  Last Error:   %s\n", entry.LastError)
+		if entry.Quarantined {
+			fmt.Printf("  Status:       %s\n", color.RedString("quarantined since %s",
+				entry.QuarantinedAt.Local().Format("2006-01-02 15:04:05")))
+		} else {
+			fmt.Printf("  Next Retry:   %s\n", entry.NextRetry.Local().Format("2006-01-02 15:04:05"))
+		}
+	}
+
+	fmt.Println()
+	color.Cyan("Injection History")
+	if len(report.History) == 0 {
+		fmt.Println("  (none)")
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "  Time\tOperator\tAction\tPID\tNew PID\tAgent\tOutcome\tError")
+		for _, rec := range report.History {
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%d\t%s\t%s\t%s\t%s\n",
+				rec.Time.Local().Format("2006-01-02 15:04:05"), rec.Operator, rec.Action,
+				rec.PID, formatNewPID(rec.NewPID), valueOr(rec.Agent, "-"),
+				formatOutcome(rec.Outcome), textutil.Truncate(rec.Error, 60))
+		}
+		w.Flush()
+	}
+
+	fmt.Println()
+	color.Cyan("Environment (%d)", len(proc.Envs))
+	for _, key := range sortedKeys(proc.Envs) {
+		fmt.Printf("  %s=%s\n", key, proc.Envs[key])
+	}
+}
+
+// printInspectList 显示多值字段，每行一个值
+func printInspectList(label string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+	for i, value := range values {
+		if i == 0 {
+			fmt.Printf("  %-13s %s\n", label, value)
+		} else {
+			fmt.Printf("  %-13s %s\n", "", value)
+		}
+	}
+}
+
+// formatSocketAddr 格式化监听地址，通配地址显示为 *
+func formatSocketAddr(addr string, port int) string {
+	if addr == "0.0.0.0" || addr == "::" {
+		addr = "*"
+	} else if strings.Contains(addr, ":") {
+		addr = "[" + addr + "]"
+	}
+	return fmt.Sprintf("%s:%d", addr, port)
+}
+
+// sortedKeys 返回排序后的键
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -38,6 +38,8 @@ func runInteractive(cmd *cobra.Command, args []string) error {
 
 	// 创建并显示菜单
 	m := menu.NewMenu(cfg, det, inj)
+	m.SetConfigFile(cfgFile)
+	m.SetPidFile(daemonPidFilePath())
 	if err := m.Show(); err != nil {
 		return fmt.Errorf("menu error: %w", err)
 	}
@@ -4,27 +4,52 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"text/tabwriter"
+	"time"
 
 	"iast-auto-inject/internal/core/detector"
+	"iast-auto-inject/internal/core/injector"
+	"iast-auto-inject/internal/core/process"
+	"iast-auto-inject/internal/pkg/redact"
+	"iast-auto-inject/internal/ui/watch"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
 
 var (
-	listPid        int
-	listAgent      string
-	listNoAgent    bool
-	listFormat     string
+	listPid      int
+	listAgent    string
+	listNoAgent  bool
+	listFormat   string
+	listColumns  []string
+	listSort     string
+	listWatch    bool
+	listInterval time.Duration
 )
 
 // listCmd list 命令
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "列出 Java 进程及其 agent 状态",
-	Long:  `列出系统中所有 Java 进程及其已附加的 javaagent 状态`,
-	RunE:  runList,
+	Long: `列出系统中所有 Java 进程及其已附加的 javaagent 状态
+
+输出格式（-o）：
+  table                表格（默认）
+  json, ndjson, yaml   完整的进程信息（指定 --columns 时只输出这些列）
+  csv                  CSV，第一行为列名
+  jsonpath=<模板>      对每个进程执行 JSONPath 模板，如 -o 'jsonpath={.pid}{"\t"}{.agents[*].path}'
+
+可选的列：pid, user, uid, name, rss, vms, cpu, threads, fds, main, jar, main_class,
+agent, agents, ports, conns, java, unit, container, cwd, start, cmdline, window
+
+--watch 原地刷新进程表（类似 top），CPU% 为两次刷新之间的使用率，
+新启动的 JVM（+）、已退出的 JVM（-）和 agent 状态变化（*）会高亮显示一段时间。
+可以与 --pid、--agent、--no-agent、--sort 一起使用，按 Ctrl+C 退出`,
+	RunE: runList,
 }
 
 func init() {
@@ -32,8 +57,14 @@ func init() {
 
 	listCmd.Flags().IntVarP(&listPid, "pid", "p", 0, "显示指定 PID 的详细信息")
 	listCmd.Flags().StringVarP(&listAgent, "agent", "a", "", "只显示已附加指定 agent 的进程")
-	listCmd.Flags().BoolVar(&listNoAgent, "no-agent", false, "只显示未附加 agent 的进程")
-	listCmd.Flags().StringVarP(&listFormat, "format", "f", "table", "输出格式 (table, json)")
+	listCmd.Flags().BoolVar(&listNoAgent, "no-agent", false, "只显示未附加 SecPoint 的进程")
+	listCmd.Flags().StringVarP(&listFormat, "output", "o", "table", "输出格式 (table, json, ndjson, yaml, csv, jsonpath=<模板>)")
+	listCmd.Flags().StringVarP(&listFormat, "format", "f", "table", "输出格式")
+	listCmd.Flags().MarkDeprecated("format", "use --output instead")
+	listCmd.Flags().StringSliceVar(&listColumns, "columns", nil, "显示的列，如 pid,user,jar,agents,rss")
+	listCmd.Flags().StringVar(&listSort, "sort", "", "按列排序，以 - 开头时降序，如 --sort=-rss")
+	listCmd.Flags().BoolVarP(&listWatch, "watch", "w", false, "原地刷新进程表，显示进程启动、退出和 agent 状态变化")
+	listCmd.Flags().DurationVar(&listInterval, "interval", watch.DefaultInterval, "--watch 的刷新间隔")
 }
 
 func runList(cmd *cobra.Command, args []string) error {
@@ -48,6 +79,10 @@ func runList(cmd *cobra.Command, args []string) error {
 		filter.PIDs = []int{listPid}
 	}
 
+	if listWatch {
+		return runListWatch(cmd, det, filter)
+	}
+
 	// 发现进程
 	procs, err := det.DiscoverJavaProcesses(ctx, filter)
 	if err != nil {
@@ -57,128 +92,173 @@ func runList(cmd *cobra.Command, args []string) error {
 	// 过滤
 	var filtered []*detector.JavaProcess
 	for _, proc := range procs {
-		if listNoAgent && len(proc.Agents) > 0 {
-			continue
+		if listMatch(proc) {
+			filtered = append(filtered, proc)
 		}
-		if listAgent != "" {
-			has := false
-			for _, agent := range proc.Agents {
-				if agent.Path == listAgent {
-					has = true
-					break
-				}
-			}
-			if !has {
-				continue
-			}
+	}
+
+	windows := pendingWindows(det, filtered)
+	columns := allListColumns(windows)
+
+	// 未指定列时，表格和 CSV 使用默认列（配置了维护窗口时包含下一个窗口）
+	var selected []*listColumn
+	names := listColumns
+	if len(names) == 0 && (listFormat == "table" || listFormat == "csv") {
+		names = defaultListColumns
+		if windows != nil {
+			names = append(names[:len(names):len(names)], "window")
+		}
+	}
+	if len(names) > 0 {
+		if selected, err = selectColumns(columns, names); err != nil {
+			return err
 		}
-		filtered = append(filtered, proc)
+	}
+
+	// 输出完整的进程信息时读取按需加载的信息（列会自行读取需要的信息）
+	if selected == nil {
+		for _, proc := range filtered {
+			proc.LoadDetails()
+		}
+	}
+
+	if listSort != "" {
+		if err := sortProcesses(filtered, columns, listSort); err != nil {
+			return err
+		}
+	}
+
+	// 隐藏命令行、环境变量和 agent 选项中的敏感值
+	r := redact.Default()
+	for i, proc := range filtered {
+		filtered[i] = proc.Redacted(r)
 	}
 
 	// 显示结果
-	switch listFormat {
-	case "json":
-		printJSON(filtered)
-	default:
-		printTable(filtered)
+	if listFormat != "table" {
+		return writeProcesses(os.Stdout, listFormat, filtered, selected)
 	}
+	printTable(filtered, selected)
 
 	return nil
 }
 
-// printTable 打印表格格式
-func printTable(procs []*detector.JavaProcess) {
-	if len(procs) == 0 {
-		color.Yellow("No Java processes found")
-		return
+// runListWatch 原地刷新进程表，直到按下 Ctrl+C
+func runListWatch(cmd *cobra.Command, det *detector.Detector, filter *detector.ProcessFilter) error {
+	if cmd.Flags().Changed("output") || cmd.Flags().Changed("format") || len(listColumns) > 0 {
+		return fmt.Errorf("--watch only supports the default table output")
+	}
+	if listInterval <= 0 {
+		return fmt.Errorf("--interval must be positive")
 	}
 
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	opts := watch.Options{
+		Interval: listInterval,
+		Filter:   filter,
+		Match:    listMatch,
+		Hint:     "Press Ctrl+C to exit",
+	}
+	if listSort != "" {
+		columns := allListColumns(nil)
+		if _, err := selectColumns(columns, []string{strings.TrimPrefix(listSort, "-")}); err != nil {
+			return err
+		}
+		opts.Sort = func(procs []*detector.JavaProcess) {
+			sortProcesses(procs, columns, listSort)
+		}
+	}
 
-	// 表头
-	fmt.Fprintln(w, "PID\tUser\tMemory\tCPU%\tThreads\tFDs\tMain Class/JAR\tAgent")
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	// 数据行
-	green := color.New(color.FgGreen).SprintFunc()
-	red := color.New(color.FgRed).SprintFunc()
+	return watch.New(det, os.Stdout, opts).Run(ctx)
+}
 
-	for _, proc := range procs {
-		// Agent 状态
-		agentStatus := red("✗")
-		if len(proc.Agents) > 0 {
-			agentStatus = green("✓")
+// listMatch 判断进程是否满足 --agent、--no-agent 过滤条件
+func listMatch(proc *detector.JavaProcess) bool {
+	if listNoAgent && proc.HasSecPoint() {
+		return false
+	}
+	if listAgent == "" {
+		return true
+	}
+	for _, agent := range proc.Agents {
+		if agent.Path == listAgent {
+			return true
 		}
+	}
+	return false
+}
 
-		// 主类/JAR 文件
-		main := proc.MainClass
-		if proc.JarFile != "" {
-			main = proc.JarFile
-		}
-		if main == "" {
-			main = "unknown"
-		}
+// pendingWindows 计算待注入进程的下一个维护窗口（PID -> 显示文本）
+// 未配置维护窗口时返回 nil
+func pendingWindows(det *detector.Detector, procs []*detector.JavaProcess) map[int]string {
+	if !GetConfig().HasMaintenanceWindows() {
+		return nil
+	}
 
-		// 格式化内存
-		memStr := formatMemory(proc.MemoryRSS)
+	procMgr := process.NewManager(
+		GetConfig().Restart.GracePeriod,
+		GetConfig().Restart.KillTimeout,
+		GetConfig().Restart.VerifyWait,
+		GetConfig().Restart.MaxRetries,
+	)
+	inj := injector.NewStaticInjector(GetConfig(), det, procMgr)
 
-		fmt.Fprintf(w, "%d\t%s\t%s\t%.1f\t%d\t%d\t%s\t%s\n",
-			proc.PID,
-			proc.User,
-			memStr,
-			proc.CPUPercent,
-			proc.Threads,
-			proc.OpenFDs,
-			truncate(main, 25),
-			agentStatus)
+	now := time.Now()
+	windows := make(map[int]string)
+	for _, proc := range procs {
+		if !inj.NeedsInject(proc) {
+			continue
+		}
+		if active, next := inj.MaintenanceWindow(proc, now); active {
+			windows[proc.PID] = "now"
+		} else {
+			windows[proc.PID] = formatWindow(next)
+		}
 	}
 
-	w.Flush()
-
-	fmt.Printf("\nTotal: %d Java process(es)\n", len(procs))
+	return windows
 }
 
-// formatMemory 格式化内存大小
-func formatMemory(bytes uint64) string {
-	const (
-		KB = 1024
-		MB = 1024 * KB
-		GB = 1024 * MB
-	)
+// printTable 打印表格格式
+func printTable(procs []*detector.JavaProcess, columns []*listColumn) {
+	if len(procs) == 0 {
+		color.Yellow("No Java processes found")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
 
-	switch {
-	case bytes >= GB:
-		return fmt.Sprintf("%.1fG", float64(bytes)/float64(GB))
-	case bytes >= MB:
-		return fmt.Sprintf("%.1fM", float64(bytes)/float64(MB))
-	case bytes >= KB:
-		return fmt.Sprintf("%.1fK", float64(bytes)/float64(KB))
-	default:
-		return fmt.Sprintf("%dB", bytes)
+	// 表头
+	headers := make([]string, len(columns))
+	for i, column := range columns {
+		headers[i] = column.header
 	}
-}
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
 
-// printJSON 打印 JSON 格式
-func printJSON(procs []*detector.JavaProcess) {
-	// 简化实现
+	// 数据行
 	for _, proc := range procs {
-		agentStr := "none"
-		if len(proc.Agents) > 0 {
-			agentStr = ""
-			for i, agent := range proc.Agents {
-				if i > 0 {
-					agentStr += ", "
-				}
-				agentStr += agent.Path
+		cells := make([]string, len(columns))
+		for i, column := range columns {
+			if column.text != nil {
+				cells[i] = column.text(proc)
+			} else {
+				cells[i] = fmt.Sprint(column.value(proc))
 			}
 		}
-		fmt.Printf("PID: %d, User: %s, Agents: [%s]\n", proc.PID, proc.User, agentStr)
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
 	}
+
+	w.Flush()
+
+	fmt.Printf("\nTotal: %d Java process(es)\n", len(procs))
 }
 
-// truncate 截断字符串
-func truncate(s string, maxLen int) string {
-	if len(s) <= maxLen {
-		return s
+// formatAgentStatus 格式化 SecPoint 附加状态（其他 agent 在 agents 列显示）
+func formatAgentStatus(proc *detector.JavaProcess) string {
+	if proc.HasSecPoint() {
+		return color.GreenString("✓")
 	}
-	return s[:maxLen-3] + "..."
+	return color.RedString("✗")
 }
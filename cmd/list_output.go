@@ -0,0 +1,294 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"iast-auto-inject/internal/core/detector"
+	"iast-auto-inject/internal/pkg/jsonpath"
+	"iast-auto-inject/internal/pkg/procfs"
+	"iast-auto-inject/internal/pkg/textutil"
+
+	"gopkg.in/yaml.v3"
+)
+
+// listColumn list 输出的一列
+type listColumn struct {
+	key    string
+	header string
+	value  func(proc *detector.JavaProcess) interface{} // 原始值，用于排序和机器可读格式
+	text   func(proc *detector.JavaProcess) string      // 表格中显示的文本（为空时使用原始值）
+}
+
+// defaultListColumns 表格默认显示的列
+var defaultListColumns = []string{"pid", "user", "rss", "cpu", "threads", "fds", "main", "agent"}
+
+// allListColumns 返回所有可选的列，windows 为待注入进程的下一个维护窗口
+func allListColumns(windows map[int]string) []*listColumn {
+	return []*listColumn{
+		{key: "pid", header: "PID", value: func(p *detector.JavaProcess) interface{} { return p.PID }},
+		{key: "user", header: "User", value: func(p *detector.JavaProcess) interface{} { return p.User }},
+		{key: "uid", header: "UID", value: func(p *detector.JavaProcess) interface{} { return p.UID }},
+		{key: "name", header: "Name", value: func(p *detector.JavaProcess) interface{} { return p.Name }},
+		{key: "rss", header: "Memory",
+			value: func(p *detector.JavaProcess) interface{} { return p.MemoryRSS },
+			text:  func(p *detector.JavaProcess) string { return procfs.FormatMemory(p.MemoryRSS) }},
+		{key: "vms", header: "Virtual",
+			value: func(p *detector.JavaProcess) interface{} { return p.MemoryVMS },
+			text:  func(p *detector.JavaProcess) string { return procfs.FormatMemory(p.MemoryVMS) }},
+		{key: "cpu", header: "CPU%",
+			value: func(p *detector.JavaProcess) interface{} { return p.CPUPercent },
+			text:  func(p *detector.JavaProcess) string { return fmt.Sprintf("%.1f", p.CPUPercent) }},
+		{key: "threads", header: "Threads", value: func(p *detector.JavaProcess) interface{} { return p.Threads }},
+		{key: "fds", header: "FDs", value: func(p *detector.JavaProcess) interface{} { return p.OpenFDs }},
+		{key: "main", header: "Main Class/JAR",
+			value: func(p *detector.JavaProcess) interface{} { return p.MainName() },
+			text:  func(p *detector.JavaProcess) string { return textutil.Truncate(p.MainName(), 25) }},
+		{key: "jar", header: "JAR", value: func(p *detector.JavaProcess) interface{} { return p.JarFile }},
+		{key: "main_class", header: "Main Class", value: func(p *detector.JavaProcess) interface{} { return p.MainClass }},
+		{key: "agent", header: "Agent",
+			value: func(p *detector.JavaProcess) interface{} { return p.HasSecPoint() },
+			text:  formatAgentStatus},
+		{key: "agents", header: "Agents",
+			value: func(p *detector.JavaProcess) interface{} { return agentPaths(p) },
+			text:  func(p *detector.JavaProcess) string { return valueOr(strings.Join(agentPaths(p), ","), "-") }},
+		{key: "ports", header: "Ports",
+			value: func(p *detector.JavaProcess) interface{} { return p.ListenPorts() },
+			text:  func(p *detector.JavaProcess) string { return valueOr(joinPorts(p.ListenPorts(), ","), "-") }},
+		{key: "conns", header: "Conns", value: func(p *detector.JavaProcess) interface{} { p.LoadSockets(); return p.Established }},
+		{key: "java", header: "Java", value: func(p *detector.JavaProcess) interface{} { return p.JavaVersion }},
+		{key: "unit", header: "Unit", value: func(p *detector.JavaProcess) interface{} { p.LoadCgroup(); return p.SystemdUnit }},
+		{key: "container", header: "Container", value: func(p *detector.JavaProcess) interface{} { p.LoadCgroup(); return p.ContainerID }},
+		{key: "cwd", header: "Cwd", value: func(p *detector.JavaProcess) interface{} { return p.Cwd }},
+		{key: "start", header: "Started",
+			value: func(p *detector.JavaProcess) interface{} { return p.StartedAt() },
+			text:  func(p *detector.JavaProcess) string { return p.StartTime }},
+		{key: "cmdline", header: "Command",
+			value: func(p *detector.JavaProcess) interface{} { return strings.Join(p.CmdLine, " ") }},
+		{key: "window", header: "Next Window",
+			value: func(p *detector.JavaProcess) interface{} { return windows[p.PID] },
+			text:  func(p *detector.JavaProcess) string { return valueOr(windows[p.PID], "-") }},
+	}
+}
+
+// selectColumns 按名称选择列，names 为空时使用默认列
+func selectColumns(all []*listColumn, names []string) ([]*listColumn, error) {
+	byKey := make(map[string]*listColumn, len(all))
+	keys := make([]string, 0, len(all))
+	for _, column := range all {
+		byKey[column.key] = column
+		keys = append(keys, column.key)
+	}
+
+	var selected []*listColumn
+	for _, name := range names {
+		column, ok := byKey[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return nil, fmt.Errorf("unknown column %q (available: %s)", name, strings.Join(keys, ", "))
+		}
+		selected = append(selected, column)
+	}
+	return selected, nil
+}
+
+// sortProcesses 按列排序，key 以 - 开头时降序
+func sortProcesses(procs []*detector.JavaProcess, columns []*listColumn, key string) error {
+	desc := strings.HasPrefix(key, "-")
+	selected, err := selectColumns(columns, []string{strings.TrimPrefix(key, "-")})
+	if err != nil {
+		return err
+	}
+	column := selected[0]
+
+	sort.SliceStable(procs, func(i, j int) bool {
+		a, b := column.value(procs[i]), column.value(procs[j])
+		if desc {
+			return lessValue(b, a)
+		}
+		return lessValue(a, b)
+	})
+	return nil
+}
+
+// lessValue 比较两个列值（数字按大小，时间按先后，列表逐项比较，其他按文本）
+func lessValue(a, b interface{}) bool {
+	switch x := a.(type) {
+	case int:
+		return x < b.(int)
+	case uint64:
+		return x < b.(uint64)
+	case float64:
+		return x < b.(float64)
+	case bool:
+		return !x && b.(bool)
+	case string:
+		return x < b.(string)
+	case time.Time:
+		return x.Before(b.(time.Time))
+	case []int:
+		return slices.Compare(x, b.([]int)) < 0
+	case []string:
+		return slices.Compare(x, b.([]string)) < 0
+	}
+	return fmt.Sprint(a) < fmt.Sprint(b)
+}
+
+// writeProcesses 以机器可读格式输出进程：json、ndjson、yaml、csv 或 jsonpath=<模板>
+// 指定列时只输出这些列，否则 json、ndjson、yaml 输出完整的进程信息
+func writeProcesses(w io.Writer, format string, procs []*detector.JavaProcess, columns []*listColumn) error {
+	if expr, ok := strings.CutPrefix(format, "jsonpath="); ok {
+		return writeJSONPath(w, expr, procs, columns)
+	}
+
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(processRecords(procs, columns))
+
+	case "ndjson":
+		enc := json.NewEncoder(w)
+		for _, record := range processRecords(procs, columns) {
+			if err := enc.Encode(record); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case "yaml":
+		// 先转换为 JSON 结构，字段名与 JSON 输出一致
+		records, err := jsonValue(processRecords(procs, columns))
+		if err != nil {
+			return err
+		}
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(records); err != nil {
+			return fmt.Errorf("failed to encode yaml: %w", err)
+		}
+		return enc.Close()
+
+	case "csv":
+		if columns == nil {
+			return fmt.Errorf("csv output requires columns")
+		}
+		return writeCSV(w, procs, columns)
+	}
+
+	return fmt.Errorf("unknown output format %q (expected table, json, ndjson, yaml, csv or jsonpath=<template>)", format)
+}
+
+// processRecords 返回输出的记录：未指定列时为完整的进程信息，否则为 列名 -> 值
+func processRecords(procs []*detector.JavaProcess, columns []*listColumn) []interface{} {
+	records := make([]interface{}, 0, len(procs))
+	for _, proc := range procs {
+		if columns == nil {
+			records = append(records, proc)
+			continue
+		}
+
+		record := make(map[string]interface{}, len(columns))
+		for _, column := range columns {
+			record[column.key] = column.value(proc)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// writeCSV 输出 CSV，第一行为列名
+func writeCSV(w io.Writer, procs []*detector.JavaProcess, columns []*listColumn) error {
+	cw := csv.NewWriter(w)
+
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = column.key
+	}
+	cw.Write(header)
+
+	for _, proc := range procs {
+		row := make([]string, len(columns))
+		for i, column := range columns {
+			row[i] = csvValue(column.value(proc))
+		}
+		cw.Write(row)
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvValue 格式化 CSV 单元格，列表以 ; 连接
+func csvValue(value interface{}) string {
+	switch v := value.(type) {
+	case []string:
+		return strings.Join(v, ";")
+	case []int:
+		return joinPorts(v, ";")
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format(time.RFC3339)
+	}
+	return fmt.Sprint(value)
+}
+
+// writeJSONPath 对每个进程执行 JSONPath 模板，每个进程输出一行
+func writeJSONPath(w io.Writer, expr string, procs []*detector.JavaProcess, columns []*listColumn) error {
+	tmpl, err := jsonpath.Parse(expr)
+	if err != nil {
+		return fmt.Errorf("invalid jsonpath template: %w", err)
+	}
+
+	for _, record := range processRecords(procs, columns) {
+		if err := tmpl.Execute(w, record); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, "\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jsonValue 将值转换为 JSON 解码后的通用结构
+func jsonValue(v interface{}) (interface{}, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal: %w", err)
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal: %w", err)
+	}
+	return value, nil
+}
+
+// joinPorts 以 sep 连接端口
+func joinPorts(ports []int, sep string) string {
+	texts := make([]string, len(ports))
+	for i, port := range ports {
+		texts[i] = strconv.Itoa(port)
+	}
+	return strings.Join(texts, sep)
+}
+
+// agentPaths 返回进程已附加的 agent 路径
+func agentPaths(proc *detector.JavaProcess) []string {
+	paths := make([]string, 0, len(proc.Agents))
+	for _, agent := range proc.Agents {
+		paths = append(paths, agent.Path)
+	}
+	return paths
+}
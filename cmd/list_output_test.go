@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"iast-auto-inject/internal/core/detector"
+)
+
+func TestLessValue(t *testing.T) {
+	early := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	// 10:00 UTC，以 UTC-8 表示时文本比较会排在 early 之前
+	late := time.Date(2026, 3, 2, 2, 0, 0, 0, time.FixedZone("UTC-8", -8*3600))
+
+	tests := []struct {
+		name string
+		a, b interface{}
+		want bool
+	}{
+		{"int", 9, 10, true},
+		{"int reversed", 10, 9, false},
+		{"uint64", uint64(2 << 30), uint64(3 << 30), true},
+		{"float", 2.5, 10.0, true},
+		{"bool", false, true, true},
+		{"bool equal", true, true, false},
+		{"string", "alpha", "beta", true},
+		{"time", early, late, true},
+		{"time reversed", late, early, false},
+		{"time zero first", time.Time{}, early, true},
+		{"ports numeric", []int{9, 80}, []int{10}, true},
+		{"ports prefix", []int{8080}, []int{8080, 8443}, true},
+		{"ports empty first", []int{}, []int{22}, true},
+		{"ports equal", []int{80, 443}, []int{80, 443}, false},
+		{"strings", []string{"/a.jar", "/z.jar"}, []string{"/b.jar"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lessValue(tt.a, tt.b); got != tt.want {
+				t.Fatalf("lessValue(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSVValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{"int", 1234, "1234"},
+		{"float", 12.5, "12.5"},
+		{"float integral", 3.0, "3"},
+		{"bool", true, "true"},
+		{"ports", []int{8080, 8443}, "8080;8443"},
+		{"no ports", []int{}, ""},
+		{"paths", []string{"/a.jar", "/b.jar"}, "/a.jar;/b.jar"},
+		{"time", time.Date(2026, 3, 2, 9, 30, 0, 0, time.FixedZone("CST", 8*3600)), "2026-03-02T09:30:00+08:00"},
+		{"zero time", time.Time{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := csvValue(tt.value); got != tt.want {
+				t.Fatalf("csvValue(%v) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortProcesses(t *testing.T) {
+	procs := []*detector.JavaProcess{
+		{PID: 300, User: "app", Agents: []detector.Agent{{Path: "/b.jar"}}},
+		{PID: 20, User: "root"},
+		{PID: 1000, User: "app", Agents: []detector.Agent{{Path: "/a.jar"}}},
+	}
+	columns := allListColumns(nil)
+
+	pids := func() string {
+		texts := make([]string, len(procs))
+		for i, proc := range procs {
+			texts[i] = strconv.Itoa(proc.PID)
+		}
+		return strings.Join(texts, " ")
+	}
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"pid", "20 300 1000"},
+		{"-pid", "1000 300 20"},
+		{"agents", "20 1000 300"},
+		{"user", "1000 300 20"}, // 稳定排序，相同用户保持之前的顺序
+	}
+	for _, tt := range tests {
+		if err := sortProcesses(procs, columns, tt.key); err != nil {
+			t.Fatal(err)
+		}
+		if got := pids(); got != tt.want {
+			t.Fatalf("sort by %s = %s, want %s", tt.key, got, tt.want)
+		}
+	}
+
+	if err := sortProcesses(procs, columns, "nope"); err == nil || !strings.Contains(err.Error(), "unknown column") {
+		t.Fatalf("sort by unknown column error = %v", err)
+	}
+}
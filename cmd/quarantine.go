@@ -0,0 +1,233 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"iast-auto-inject/internal/core/config"
+	"iast-auto-inject/internal/core/detector"
+	"iast-auto-inject/internal/core/injector"
+	"iast-auto-inject/internal/core/quarantine"
+	"iast-auto-inject/internal/pkg/logger"
+	"iast-auto-inject/internal/pkg/textutil"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var (
+	quarantineReleaseAll bool
+)
+
+// quarantineCmd quarantine 命令
+var quarantineCmd = &cobra.Command{
+	Use:   "quarantine",
+	Short: "管理守护进程的失败重试和隔离记录",
+	Long: `守护进程按应用（用户和 JAR 或主类、工作目录）记录注入失败，失败后指数退避重试，
+连续失败达到 retry.max_attempts 次后隔离，不再自动重试，直到手动释放`,
+}
+
+// quarantineListCmd quarantine list 命令
+var quarantineListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "列出退避中和已隔离的应用",
+	RunE:  runQuarantineList,
+}
+
+// quarantineReleaseCmd quarantine release 命令
+var quarantineReleaseCmd = &cobra.Command{
+	Use:   "release [id]",
+	Short: "释放应用，守护进程下次扫描时重新尝试",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runQuarantineRelease,
+}
+
+func init() {
+	rootCmd.AddCommand(quarantineCmd)
+	quarantineCmd.AddCommand(quarantineListCmd, quarantineReleaseCmd)
+
+	quarantineReleaseCmd.Flags().BoolVarP(&quarantineReleaseAll, "all", "a", false, "释放所有记录")
+}
+
+// newQuarantineStore 创建失败记录存储
+func newQuarantineStore() *quarantine.Store {
+	return quarantineStoreFor(GetConfig())
+}
+
+// quarantineStoreFor 按指定配置创建失败记录存储
+func quarantineStoreFor(cfg *config.Config) *quarantine.Store {
+	retry := cfg.Retry
+	if retry == nil {
+		retry = config.DefaultConfig().Retry
+	}
+	return quarantine.NewStore(retry.StateFile, quarantine.Policy{
+		InitialBackoff: retry.InitialBackoff,
+		MaxBackoff:     retry.MaxBackoff,
+		MaxAttempts:    retry.MaxAttempts,
+	})
+}
+
+// quarantineKey 进程的稳定标识（重启后 PID 变化，标识不变）
+func quarantineKey(proc *detector.JavaProcess) string {
+	return proc.User + "@" + injector.ServiceKey(proc)
+}
+
+func runQuarantineList(cmd *cobra.Command, args []string) error {
+	entries, err := newQuarantineStore().List()
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No failing or quarantined applications")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tStatus\tAttempts\tLast PID\tLast Failure\tNext Retry\tApplication\tLast Error")
+
+	red := color.New(color.FgRed).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+
+	now := time.Now()
+	for _, entry := range entries {
+		status := yellow("backoff")
+		next := entry.NextRetry.Format("2006-01-02 15:04:05")
+		if entry.Quarantined {
+			status = red("quarantined")
+			next = "-"
+		} else if !now.Before(entry.NextRetry) {
+			next = "next scan"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\t%s\t%s\t%s\n",
+			entry.ID, status, entry.Attempts, entry.LastPID,
+			entry.LastFailure.Format("2006-01-02 15:04:05"), next,
+			entry.Key, textutil.Truncate(entry.LastError, 60))
+	}
+
+	w.Flush()
+
+	return nil
+}
+
+func runQuarantineRelease(cmd *cobra.Command, args []string) error {
+	s := newQuarantineStore()
+
+	if quarantineReleaseAll {
+		released, err := s.ReleaseAll()
+		if err != nil {
+			return fmt.Errorf("failed to release: %w", err)
+		}
+		color.Green("Released %d application(s)", len(released))
+		logger.Info("Released all quarantine entries", zap.Int("count", len(released)))
+		return nil
+	}
+
+	if len(args) == 0 {
+		return fmt.Errorf("请指定要释放的 ID（或使用 --all）")
+	}
+
+	entry, err := s.Release(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to release: %w", err)
+	}
+
+	color.Green("Released %s (%s)", entry.ID, entry.Key)
+	logger.Info("Released quarantine entry",
+		zap.String("id", entry.ID),
+		zap.String("key", entry.Key),
+		zap.Int("attempts", entry.Attempts))
+
+	return nil
+}
+
+// skipBackoff 过滤掉处于退避期或已隔离的进程
+func skipBackoff(failures *quarantine.Store, procs []*detector.JavaProcess) []*detector.JavaProcess {
+	if len(procs) == 0 {
+		return procs
+	}
+
+	state, err := failures.Load()
+	if err != nil {
+		logger.Warn("Failed to load failure records", zap.Error(err))
+		return procs
+	}
+
+	now := time.Now()
+	backoff, quarantined := 0, 0
+
+	var allowed []*detector.JavaProcess
+	for _, proc := range procs {
+		ok, entry := state.Allowed(quarantineKey(proc), now)
+		if ok {
+			allowed = append(allowed, proc)
+			continue
+		}
+
+		if entry.Quarantined {
+			quarantined++
+		} else {
+			backoff++
+		}
+		logger.Debug("Skipping process after previous failures",
+			zap.Int("pid", proc.PID),
+			zap.String("id", entry.ID),
+			zap.Int("attempts", entry.Attempts),
+			zap.Bool("quarantined", entry.Quarantined),
+			zap.Time("next_retry", entry.NextRetry))
+	}
+
+	if backoff > 0 || quarantined > 0 {
+		color.Yellow("Skipped after previous failures: %d in backoff, %d quarantined (see 'quarantine list')",
+			backoff, quarantined)
+	}
+
+	return allowed
+}
+
+// recordResults 记录注入结果：失败时增加失败次数，成功时清除记录
+func recordResults(failures *quarantine.Store, procs []*detector.JavaProcess, results []*injector.InjectResult) {
+	byPID := make(map[int]*detector.JavaProcess, len(procs))
+	for _, proc := range procs {
+		byPID[proc.PID] = proc
+	}
+
+	now := time.Now()
+	for _, result := range results {
+		proc, ok := byPID[result.PID]
+		if !ok {
+			continue
+		}
+		key := quarantineKey(proc)
+
+		switch {
+		case result.Success:
+			if err := failures.RecordSuccess(key); err != nil {
+				logger.Warn("Failed to clear failure record", zap.String("key", key), zap.Error(err))
+			}
+		case result.Failed():
+			entry, err := failures.RecordFailure(key, result.PID, result.Error, now)
+			if err != nil {
+				logger.Warn("Failed to record failure", zap.String("key", key), zap.Error(err))
+				continue
+			}
+			if entry.Quarantined {
+				color.Red("Quarantined %s after %d failed attempts", key, entry.Attempts)
+				logger.Warn("Application quarantined",
+					zap.String("id", entry.ID),
+					zap.String("key", key),
+					zap.Int("attempts", entry.Attempts))
+			} else {
+				logger.Info("Backing off after failure",
+					zap.String("id", entry.ID),
+					zap.String("key", key),
+					zap.Int("attempts", entry.Attempts),
+					zap.Time("next_retry", entry.NextRetry))
+			}
+		}
+	}
+}
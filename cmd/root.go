@@ -1,20 +1,25 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"sync/atomic"
 
 	"iast-auto-inject/internal/core/config"
 	"iast-auto-inject/internal/pkg/logger"
+	"iast-auto-inject/internal/pkg/redact"
 
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 )
 
 var (
-	cfgFile string
-	debug   bool
-	globalCfg *config.Config
+	cfgFile   string
+	debug     bool
+	globalCfg atomic.Pointer[config.Config] // 守护进程重新加载配置时整体替换
+	cfgPath   string                        // 实际加载的配置文件，使用默认配置时为空
 )
 
 // rootCmd 根命令
@@ -34,8 +39,15 @@ var rootCmd = &cobra.Command{
 // Execute 执行根命令
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
+		var exitErr *exitError
+		if errors.As(err, &exitErr) {
+			if exitErr.err != nil {
+				fmt.Fprintln(os.Stderr, exitErr.err)
+			}
+			os.Exit(exitErr.code)
+		}
 		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		os.Exit(exitFailed)
 	}
 }
 
@@ -47,11 +59,13 @@ func init() {
 // persistentPreRun 持久化前置运行
 func persistentPreRun(cmd *cobra.Command, args []string) error {
 	// 加载配置
+	var cfg *config.Config
 	var err error
 	if cfgFile != "" {
-		globalCfg, err = config.Load(cfgFile)
+		cfg, err = config.Load(cfgFile)
+		cfgPath = cfgFile
 	} else {
-		globalCfg, err = config.LoadFromDefaultPaths()
+		cfg, cfgPath, err = config.LoadFromDefaultPaths()
 	}
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
@@ -59,27 +73,62 @@ func persistentPreRun(cmd *cobra.Command, args []string) error {
 
 	// 调试模式覆盖
 	if debug {
-		globalCfg.Debug = true
+		cfg.Debug = true
 	}
+	globalCfg.Store(cfg)
+	redact.SetDefault(cfg.Redaction.Redactor())
 
 	// 初始化日志
-	logLevel := globalCfg.Log.Level
-	if globalCfg.Debug {
+	logLevel := cfg.Log.Level
+	if cfg.Debug {
 		logLevel = "debug"
 	}
 
-	if err := logger.Init(logLevel, globalCfg.Log.Format, globalCfg.Log.Output); err != nil {
+	if err := logger.Init(loggerOptions(cfg.Log, logLevel)); err != nil {
 		return fmt.Errorf("failed to init logger: %w", err)
 	}
 
 	logger.Debug("Configuration loaded",
-		zap.String("config_file", cfgFile),
-		zap.Bool("debug", globalCfg.Debug))
+		zap.String("config_file", valueOr(cfgPath, "(defaults)")),
+		zap.Bool("debug", cfg.Debug))
 
 	return nil
 }
 
+// loggerOptions 根据日志配置构建日志选项
+func loggerOptions(cfg *config.LogConfig, level string) logger.Options {
+	return logger.Options{
+		Level:   level,
+		Format:  cfg.Format,
+		Outputs: cfg.Sinks(),
+		Rotation: logger.Rotation{
+			MaxSize:    cfg.MaxSize,
+			MaxBackups: cfg.MaxBackups,
+			MaxAge:     cfg.MaxAge,
+			Compress:   cfg.Compress,
+		},
+	}
+}
+
 // GetConfig 获取全局配置
 func GetConfig() *config.Config {
-	return globalCfg
+	return globalCfg.Load()
+}
+
+// confirm 在重启进程前请求用户确认
+// 指定 --force 或配置 security.require_confirmation 为 false 时不询问
+func confirm(prompt string, force bool) bool {
+	return confirmOn(os.Stdout, prompt, force)
+}
+
+// confirmOn 在指定输出上请求确认（机器可读输出时提示写到 stderr）
+func confirmOn(w io.Writer, prompt string, force bool) bool {
+	if force || (GetConfig().Security != nil && !GetConfig().Security.RequireConfirmation) {
+		return true
+	}
+
+	fmt.Fprintf(w, "\n%s (y/N): ", prompt)
+	var answer string
+	fmt.Scanln(&answer)
+	return answer == "y" || answer == "Y"
 }
@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"iast-auto-inject/internal/core/detector"
+	"iast-auto-inject/internal/core/injector"
+	"iast-auto-inject/internal/core/process"
+	"iast-auto-inject/internal/pkg/jar"
+	"iast-auto-inject/internal/pkg/logger"
+	"iast-auto-inject/internal/pkg/textutil"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var (
+	upgradeAgent  string
+	upgradeTo     string
+	upgradePids   []int
+	upgradeDryRun bool
+	upgradeForce  bool
+)
+
+// upgradeCmd upgrade 命令
+var upgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "升级 Java 进程中已附加的 agent 版本",
+	Long: `查找已附加指定 agent 且版本（来自 jar MANIFEST）低于目标 jar 的进程，
+原位替换已有的 -javaagent 参数并重启进程，不会追加第二个 agent`,
+	Example: `  iast-auto-inject upgrade --agent secpoint --to /opt/secpoint/3.1/SecPoint.jar`,
+	RunE:    runUpgrade,
+}
+
+func init() {
+	rootCmd.AddCommand(upgradeCmd)
+
+	upgradeCmd.Flags().StringVar(&upgradeAgent, "agent", detector.SecPointAgentName, "要升级的 agent 名称（jar 文件名，如 secpoint）")
+	upgradeCmd.Flags().StringVar(&upgradeTo, "to", "", "新版本 agent jar 路径（必需）")
+	upgradeCmd.Flags().IntSliceVarP(&upgradePids, "pid", "p", []int{}, "只升级指定 PID 的进程（可多次指定）")
+	upgradeCmd.Flags().BoolVarP(&upgradeDryRun, "dry-run", "n", false, "模拟运行（不实际升级）")
+	upgradeCmd.Flags().BoolVarP(&upgradeForce, "force", "f", false, "强制升级（跳过确认）")
+}
+
+func runUpgrade(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	// 检查参数
+	if upgradeTo == "" {
+		return fmt.Errorf("请指定新版本 agent jar 路径（使用 --to）")
+	}
+
+	targetVersion, err := jar.ReadVersion(upgradeTo)
+	if err != nil {
+		return fmt.Errorf("failed to read agent version: %w", err)
+	}
+
+	// 创建组件
+	det := detector.NewDetector(GetConfig())
+	procMgr := process.NewManager(
+		GetConfig().Restart.GracePeriod,
+		GetConfig().Restart.KillTimeout,
+		GetConfig().Restart.VerifyWait,
+		GetConfig().Restart.MaxRetries,
+	)
+	inj := injector.NewStaticInjector(GetConfig(), det, procMgr)
+
+	// 验证 agent jar
+	if err := inj.VerifyAgentJar(upgradeTo); err != nil {
+		return fmt.Errorf("agent verification failed: %w", err)
+	}
+
+	logger.Info("Upgrading agent",
+		zap.String("agent", upgradeAgent),
+		zap.String("agent_path", upgradeTo),
+		zap.String("version", targetVersion))
+
+	// 发现进程
+	filter := &detector.ProcessFilter{PIDs: upgradePids, InScope: true}
+	procs, err := det.DiscoverJavaProcesses(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to discover processes: %w", err)
+	}
+
+	// 过滤版本低于目标版本的进程
+	var targetProcs []*detector.JavaProcess
+	for _, proc := range procs {
+		if inj.NeedsUpgrade(proc, upgradeAgent, targetVersion) {
+			targetProcs = append(targetProcs, proc)
+		} else if agent := det.FindAgent(proc, upgradeAgent); agent != nil && agent.Version == "" {
+			logger.Warn("Skipping process with unknown agent version",
+				zap.Int("pid", proc.PID),
+				zap.String("agent_path", agent.Path))
+		}
+	}
+
+	if len(targetProcs) == 0 {
+		color.Yellow("No processes need upgrade (target version: %s)", targetVersion)
+		return nil
+	}
+
+	// 显示目标进程
+	fmt.Println("\nTarget processes:")
+	fmt.Printf("Agent: %s -> %s (version %s)\n\n", upgradeAgent, upgradeTo, targetVersion)
+	printUpgradeTargets(det, targetProcs, upgradeAgent)
+
+	// 确认
+	if !upgradeDryRun && !confirm("Proceed with upgrade?", upgradeForce) {
+		fmt.Println("Upgrade cancelled")
+		return nil
+	}
+
+	// 模拟运行
+	if upgradeDryRun {
+		color.Yellow("\n[DRY RUN] Would upgrade %s to %s in:", upgradeAgent, targetVersion)
+		for _, proc := range targetProcs {
+			fmt.Printf("  PID %d: %s\n", proc.PID, proc.JarFile)
+		}
+		return nil
+	}
+
+	// 执行升级
+	results := inj.BatchUpgrade(ctx, targetProcs, upgradeAgent, upgradeTo)
+
+	// 显示结果
+	printInjectResults(os.Stdout, results)
+
+	successCount := 0
+	for _, result := range results {
+		if result.Success {
+			successCount++
+		}
+	}
+
+	logger.Info("Upgrade completed",
+		zap.Int("total", len(results)),
+		zap.Int("success", successCount),
+		zap.Int("failed", len(results)-successCount))
+
+	return exitWith(cmd, resultsExitCode(results), nil)
+}
+
+// printUpgradeTargets 打印升级目标
+func printUpgradeTargets(det *detector.Detector, procs []*detector.JavaProcess, agentName string) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "PID\tUser\tMain Class/JAR\tAgent Path\tVersion")
+
+	for _, proc := range procs {
+		main := proc.MainClass
+		if proc.JarFile != "" {
+			main = proc.JarFile
+		}
+		if main == "" {
+			main = "unknown"
+		}
+
+		agentPath, version := "-", "-"
+		if agent := det.FindAgent(proc, agentName); agent != nil {
+			agentPath = agent.Path
+			version = agent.Version
+		}
+
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n",
+			proc.PID, proc.User, textutil.Truncate(main, 25), textutil.Truncate(agentPath, 35), version)
+	}
+
+	w.Flush()
+}
@@ -0,0 +1,333 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"iast-auto-inject/internal/pkg/redact"
+)
+
+// genesisHash 第一条记录的 PrevHash
+const genesisHash = "0000000000000000000000000000000000000000000000000000000000000000"
+
+// Record 一条审计记录
+// Hash 为 PrevHash 与去掉 Hash 字段后的记录 JSON 的 SHA-256，修改、删除或插入任何一条记录都会使后续的链校验失败
+type Record struct {
+	Seq      int64     `json:"seq"`
+	Time     time.Time `json:"time"`
+	Operator string    `json:"operator"` // 操作者（SUDO_USER、登录用户或当前用户）
+	UID      int       `json:"uid"`      // 执行命令的有效用户 ID
+	Host     string    `json:"host"`
+	Command  string    `json:"command"` // 执行的命令行
+
+	Action   string `json:"action"` // inject、upgrade、eject
+	PID      int    `json:"pid"`
+	NewPID   int    `json:"new_pid,omitempty"`
+	User     string `json:"user"`    // 目标进程用户
+	Service  string `json:"service"` // 目标应用标识（JAR 或主类和工作目录）
+	Name     string `json:"name"`
+	Strategy string `json:"strategy,omitempty"`
+
+	Agent       string `json:"agent,omitempty"` // agent jar 路径
+	AgentSHA256 string `json:"agent_sha256,omitempty"`
+
+	OldArgv []string `json:"old_argv"`
+	NewArgv []string `json:"new_argv,omitempty"`
+
+	Outcome string `json:"outcome"` // success、failed、denied
+	Code    string `json:"code,omitempty"`
+	Error   string `json:"error,omitempty"`
+
+	PrevHash string `json:"prev_hash"`
+	Hash     string `json:"hash"`
+}
+
+// 审计结果
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailed  = "failed"
+	OutcomeDenied  = "denied"  // 被安全白名单或权限检查拒绝
+	OutcomeStarted = "started" // 即将重启进程，结果记录在之后的一条记录中
+)
+
+// Log 追加写入的审计日志（JSON Lines）
+type Log struct {
+	path string
+}
+
+// Open 创建审计日志，path 为空时返回 nil（不记录）
+func Open(path string) *Log {
+	if path == "" {
+		return nil
+	}
+	return &Log{path: path}
+}
+
+// Path 返回审计日志路径
+func (l *Log) Path() string {
+	return l.path
+}
+
+// Append 计算哈希链并追加记录，多个进程可以同时写入
+// 填充记录的序号、时间、操作者和链字段
+func (l *Log) Append(rec *Record) error {
+	if l == nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0750); err != nil {
+		return fmt.Errorf("failed to create audit directory: %w", err)
+	}
+
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0640)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
+		return fmt.Errorf("failed to lock audit log: %w", err)
+	}
+	defer syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+
+	last, err := lastRecord(file)
+	if err != nil {
+		return err
+	}
+
+	if rec.Time.IsZero() {
+		rec.Time = time.Now().UTC()
+	}
+	if rec.Operator == "" {
+		rec.Operator = Operator()
+		rec.UID = os.Geteuid()
+	}
+	if rec.Host == "" {
+		rec.Host, _ = os.Hostname()
+	}
+	if rec.Command == "" {
+		rec.Command = strings.Join(redact.Default().Args(os.Args), " ")
+	}
+
+	rec.Seq = 1
+	rec.PrevHash = genesisHash
+	if last != nil {
+		rec.Seq = last.Seq + 1
+		rec.PrevHash = last.Hash
+	}
+
+	rec.Hash, err = computeHash(rec)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit record: %w", err)
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+
+	return file.Sync()
+}
+
+// Read 读取 since 之后的记录（since 为零值时读取全部）
+func (l *Log) Read(since time.Time) ([]*Record, error) {
+	file, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	var records []*Record
+	err = scan(file, func(line int, rec *Record) error {
+		if rec == nil {
+			return fmt.Errorf("line %d: invalid record", line)
+		}
+		if !rec.Time.Before(since) {
+			records = append(records, rec)
+		}
+		return nil
+	})
+
+	return records, err
+}
+
+// VerifyResult 哈希链校验结果
+type VerifyResult struct {
+	Records  int    // 校验通过的记录数
+	LastHash string // 最后一条记录的哈希
+	Line     int    // 第一条校验失败的行号（0 表示链本身完整）
+	Problem  string // 失败原因
+}
+
+// OK 判断是否全部通过
+func (r *VerifyResult) OK() bool {
+	return r.Problem == ""
+}
+
+// Verify 校验整个审计日志的哈希链
+// 哈希链本身无法发现截断尾部或重写整个文件，expectHead 为此前发布到系统日志的某条记录哈希，
+// 不为空时要求链中包含该记录
+func (l *Log) Verify(expectHead string) (*VerifyResult, error) {
+	result := &VerifyResult{LastHash: genesisHash}
+	headFound := expectHead == "" || expectHead == genesisHash
+
+	file, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return result, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	var prevSeq int64
+	errStop := fmt.Errorf("stop")
+
+	err = scan(file, func(line int, rec *Record) error {
+		fail := func(format string, args ...interface{}) error {
+			result.Line = line
+			result.Problem = fmt.Sprintf(format, args...)
+			return errStop
+		}
+
+		if rec == nil {
+			return fail("invalid JSON")
+		}
+		if rec.Seq != prevSeq+1 {
+			return fail("sequence %d follows %d", rec.Seq, prevSeq)
+		}
+		if rec.PrevHash != result.LastHash {
+			return fail("prev_hash does not match hash of previous record")
+		}
+		expected, err := computeHash(rec)
+		if err != nil {
+			return err
+		}
+		if rec.Hash != expected {
+			return fail("record hash mismatch (record modified)")
+		}
+
+		prevSeq = rec.Seq
+		result.LastHash = rec.Hash
+		result.Records++
+		if rec.Hash == expectHead {
+			headFound = true
+		}
+		return nil
+	})
+	if err != nil && err != errStop {
+		return nil, err
+	}
+
+	if result.OK() && !headFound {
+		result.Problem = fmt.Sprintf("expected head %s not found (log truncated or rewritten)", expectHead)
+	}
+
+	return result, nil
+}
+
+// computeHash 计算记录哈希：SHA-256(PrevHash + 去掉 Hash 字段的记录 JSON)
+func computeHash(rec *Record) (string, error) {
+	copied := *rec
+	copied.Hash = ""
+
+	data, err := json.Marshal(&copied)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal audit record: %w", err)
+	}
+
+	sum := sha256.Sum256(append([]byte(rec.PrevHash), data...))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// lastRecord 读取最后一条记录（文件为空时返回 nil）
+func lastRecord(file *os.File) (*Record, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	var last *Record
+	err := scan(file, func(line int, rec *Record) error {
+		if rec == nil {
+			return fmt.Errorf("audit log %s line %d is corrupt, refusing to append", file.Name(), line)
+		}
+		last = rec
+		return nil
+	})
+
+	return last, err
+}
+
+// scan 逐行解析记录，无法解析的行以 rec 为 nil 回调
+func scan(r io.Reader, fn func(line int, rec *Record) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+
+		var rec Record
+		if err := json.Unmarshal(raw, &rec); err != nil {
+			if err := fn(line, nil); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := fn(line, &rec); err != nil {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return nil
+}
+
+// Operator 返回发起操作的用户
+// 依次使用 SUDO_USER、登录 UID（/proc/self/loginuid）和当前用户
+func Operator() string {
+	if sudoUser := os.Getenv("SUDO_USER"); sudoUser != "" {
+		return sudoUser
+	}
+
+	if data, err := os.ReadFile("/proc/self/loginuid"); err == nil {
+		// 4294967295 表示未设置登录 UID（如系统服务）
+		if uid := strings.TrimSpace(string(data)); uid != "" && uid != "4294967295" {
+			return lookupUser(uid)
+		}
+	}
+
+	return lookupUser(strconv.Itoa(os.Getuid()))
+}
+
+// lookupUser 将 UID 解析为用户名，失败时返回 uid:<n>
+func lookupUser(uid string) string {
+	if u, err := user.LookupId(uid); err == nil {
+		return u.Username
+	}
+	return "uid:" + uid
+}
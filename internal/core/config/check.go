@@ -0,0 +1,207 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Problem 配置文件中的一个问题
+type Problem struct {
+	Line    int    `json:"line,omitempty"` // 行号（从 1 开始），无法定位时为 0
+	Message string `json:"message"`
+}
+
+// String 返回 "line N: 问题" 格式的文本
+func (p Problem) String() string {
+	if p.Line == 0 {
+		return p.Message
+	}
+	return fmt.Sprintf("line %d: %s", p.Line, p.Message)
+}
+
+var (
+	// yamlLine yaml.v3 语法错误和类型错误中的行号
+	yamlLine = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+	// validateKey Validate 错误开头的配置项路径，如 agent[0]、restart.port_wait
+	validateKey = regexp.MustCompile(`^[a-z_]+(?:\[\d+\])?(?:\.[a-z_]+(?:\[\d+\])?)*`)
+	// keySegment 配置项路径中的一段，如 windows[2]
+	keySegment = regexp.MustCompile(`^([a-z_]+)(?:\[(\d+)\])?$`)
+)
+
+// Check 严格检查配置文件，返回所有可定位的问题：YAML 语法错误、未知配置项、类型错误和配置值错误
+// 与 Load 不同，未知配置项（如拼写错误）也视为问题；读取文件失败时返回错误
+func Check(path string) ([]Problem, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return []Problem{yamlProblem(err.Error())}, nil
+	}
+
+	problems := unknownKeys(&root, reflect.TypeOf(Config{}), "")
+
+	config := DefaultConfig()
+	if err := yaml.Unmarshal(data, config); err != nil {
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			return append(problems, yamlProblem(err.Error())), nil
+		}
+		for _, msg := range typeErr.Errors {
+			problems = append(problems, yamlProblem(msg))
+		}
+	}
+	if len(problems) > 0 {
+		sort.SliceStable(problems, func(i, j int) bool {
+			return problems[i].Line < problems[j].Line
+		})
+		return problems, nil
+	}
+
+	// 配置值只有第一个错误
+	if err := config.Validate(); err != nil {
+		return []Problem{{Line: validateLine(&root, err.Error()), Message: err.Error()}}, nil
+	}
+	return nil, nil
+}
+
+// yamlProblem 从 yaml.v3 的错误信息中提取行号
+func yamlProblem(msg string) Problem {
+	if m := yamlLine.FindStringSubmatch(msg); m != nil {
+		line, _ := strconv.Atoi(m[1])
+		return Problem{Line: line, Message: m[2]}
+	}
+	return Problem{Message: strings.TrimPrefix(msg, "yaml: ")}
+}
+
+// unknownKeys 按配置结构检查节点中的未知配置项，path 为节点的配置项路径
+func unknownKeys(node *yaml.Node, t reflect.Type, path string) []Problem {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil
+		}
+		return unknownKeys(node.Content[0], t, path)
+	case yaml.AliasNode:
+		return unknownKeys(node.Alias, t, path)
+	}
+
+	var problems []Problem
+	switch {
+	case t.Kind() == reflect.Struct && node.Kind == yaml.MappingNode:
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Value == "<<" {
+				continue
+			}
+			field, ok := fields[key.Value]
+			if !ok {
+				msg := fmt.Sprintf("unknown key %q", key.Value)
+				if path != "" {
+					msg += " in " + path
+				}
+				problems = append(problems, Problem{Line: key.Line, Message: msg})
+				continue
+			}
+			problems = append(problems, unknownKeys(value, field.Type, joinKey(path, key.Value))...)
+		}
+	case (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && node.Kind == yaml.SequenceNode:
+		for i, item := range node.Content {
+			problems = append(problems, unknownKeys(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	case t.Kind() == reflect.Map && node.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			problems = append(problems, unknownKeys(node.Content[i+1], t.Elem(), joinKey(path, node.Content[i].Value))...)
+		}
+	}
+	return problems
+}
+
+// yamlFields 返回结构体的 yaml 键名 -> 字段
+func yamlFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = field
+	}
+	return fields
+}
+
+// joinKey 拼接配置项路径
+func joinKey(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// validateLine 根据 Validate 错误开头的配置项路径查找所在行，路径不在文件中（使用默认值）时返回 0
+func validateLine(root *yaml.Node, msg string) int {
+	key := validateKey.FindString(msg)
+	if key == "" {
+		return 0
+	}
+	// Validate 中 agents 的错误以 agent[i] 开头
+	if strings.HasPrefix(key, "agent[") {
+		key = "agents" + strings.TrimPrefix(key, "agent")
+	}
+
+	node := root
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+
+	line := 0
+	for _, segment := range strings.Split(key, ".") {
+		m := keySegment.FindStringSubmatch(segment)
+		if m == nil || node.Kind != yaml.MappingNode {
+			break
+		}
+
+		var value *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == m[1] {
+				line, value = node.Content[i].Line, node.Content[i+1]
+			}
+		}
+		if value == nil {
+			break
+		}
+		node = value
+
+		if m[2] != "" {
+			idx, _ := strconv.Atoi(m[2])
+			if node.Kind != yaml.SequenceNode || idx >= len(node.Content) {
+				break
+			}
+			node = node.Content[idx]
+			line = node.Line
+		}
+	}
+	return line
+}
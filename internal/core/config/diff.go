@@ -0,0 +1,81 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Change 配置项变更
+type Change struct {
+	Key string      `json:"key"` // 配置项路径，如 daemon.interval
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// Diff 比较两份配置，按配置项路径排序返回变更
+// 列表整体比较，不逐项展开
+func Diff(old, new *Config) ([]Change, error) {
+	oldValues, err := flatten(old)
+	if err != nil {
+		return nil, err
+	}
+	newValues, err := flatten(new)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []Change
+	for key, oldValue := range oldValues {
+		newValue, ok := newValues[key]
+		if !ok || !reflect.DeepEqual(oldValue, newValue) {
+			changes = append(changes, Change{Key: key, Old: oldValue, New: newValue})
+		}
+	}
+	for key, newValue := range newValues {
+		if _, ok := oldValues[key]; !ok {
+			changes = append(changes, Change{Key: key, New: newValue})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+
+	return changes, nil
+}
+
+// flatten 将配置按 YAML 结构展开为 路径 -> 值
+func flatten(cfg *Config) (map[string]interface{}, error) {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	var tree map[string]interface{}
+	if err := yaml.Unmarshal(data, &tree); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	}
+
+	values := make(map[string]interface{})
+	flattenInto(values, "", tree)
+	return values, nil
+}
+
+// flattenInto 递归展开嵌套的映射
+func flattenInto(values map[string]interface{}, prefix string, tree map[string]interface{}) {
+	for key, value := range tree {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+
+		if nested, ok := value.(map[string]interface{}); ok {
+			flattenInto(values, path, nested)
+			continue
+		}
+		values[path] = value
+	}
+}
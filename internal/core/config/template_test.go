@@ -0,0 +1,167 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configKeys 按 yaml 标签收集配置项路径，列表元素的路径使用 []，与 templateComments 一致
+func configKeys(t reflect.Type, prefix string, keys map[string]reflect.Type) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "" || name == "-" || !field.IsExported() {
+			continue
+		}
+		key := joinKey(prefix, name)
+		keys[key] = field.Type
+
+		elem := field.Type
+		for elem.Kind() == reflect.Pointer {
+			elem = elem.Elem()
+		}
+		if elem.Kind() == reflect.Slice {
+			configKeys(elem.Elem(), key+"[]", keys)
+			continue
+		}
+		configKeys(elem, key, keys)
+	}
+}
+
+func TestTemplateCommentsMatchConfig(t *testing.T) {
+	keys := make(map[string]reflect.Type)
+	configKeys(reflect.TypeOf(Config{}), "", keys)
+
+	for key := range templateComments {
+		if _, ok := keys[key]; !ok {
+			t.Errorf("templateComments has %q, which is not a config key", key)
+		}
+	}
+	for key := range templateOmit {
+		if _, ok := keys[key]; !ok {
+			t.Errorf("templateOmit has %q, which is not a config key", key)
+		}
+	}
+
+	// 每个顶层段落都需要说明
+	var sections []string
+	for key, typ := range keys {
+		if strings.ContainsAny(key, ".[") || templateOmit[key] {
+			continue
+		}
+		for typ.Kind() == reflect.Pointer || typ.Kind() == reflect.Slice {
+			typ = typ.Elem()
+		}
+		if typ.Kind() == reflect.Struct {
+			sections = append(sections, key)
+		}
+	}
+	sort.Strings(sections)
+	for _, key := range sections {
+		if templateComments[key] == "" {
+			t.Errorf("section %q has no comment in templateComments", key)
+		}
+	}
+}
+
+// decodeStrict 解析配置，拒绝 Config 中不存在的配置项
+func decodeStrict(t *testing.T, data []byte) *Config {
+	t.Helper()
+
+	config := DefaultConfig()
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(config); err != nil {
+		t.Fatal(err)
+	}
+	return config
+}
+
+func TestTemplate(t *testing.T) {
+	data, err := Template()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 按 YAML 比较，空列表和 nil 视为相同
+	got, err := yaml.Marshal(decodeStrict(t, data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := yaml.Marshal(DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("template does not decode to the default config:\n%s\nwant:\n%s", got, want)
+	}
+
+	for key := range templateOmit {
+		if bytes.Contains(data, []byte("\n"+key+":")) {
+			t.Errorf("template contains omitted key %q", key)
+		}
+	}
+}
+
+func TestShippedConfigsMatchConfig(t *testing.T) {
+	for _, name := range []string{"config.yaml", "test-config.yaml"} {
+		t.Run(name, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("..", "..", "..", "configs", name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			decodeStrict(t, data)
+		})
+	}
+}
+
+func TestFindReadable(t *testing.T) {
+	dir := t.TempDir()
+	missing := filepath.Join(dir, "missing.yaml")
+	readable := filepath.Join(dir, "readable.yaml")
+	unreadable := filepath.Join(dir, "unreadable.yaml")
+	for _, path := range []string{readable, unreadable} {
+		if err := os.WriteFile(path, []byte("debug: false\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Chmod(unreadable, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		paths []string
+		want  string
+	}{
+		{"none", nil, ""},
+		{"all missing", []string{missing}, ""},
+		{"first existing", []string{missing, readable}, readable},
+		{"skip unreadable", []string{unreadable, readable}, readable},
+		{"only unreadable", []string{missing, unreadable}, unreadable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if os.Geteuid() == 0 && strings.Contains(strings.Join(tt.paths, " "), unreadable) {
+				t.Skip("root can read files without permission bits")
+			}
+			if got := findReadable(tt.paths); got != tt.want {
+				t.Fatalf("findReadable(%q) = %q, want %q", tt.paths, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package control
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"iast-auto-inject/internal/core/config"
+	"iast-auto-inject/internal/core/quarantine"
+)
+
+// DefaultSocketPath 默认控制 socket 路径
+const DefaultSocketPath = "/var/run/iast-auto-inject.sock"
+
+// 控制命令
+const (
+	CommandStatus   = "status"    // 查询运行状态
+	CommandPause    = "pause"     // 暂停注入
+	CommandResume   = "resume"    // 恢复注入
+	CommandScan     = "scan"      // 立即全量扫描
+	CommandStop     = "stop"      // 优雅停止
+	CommandReload   = "reload"    // 重新加载配置
+	CommandLogLevel = "log-level" // 查询或修改日志级别
+)
+
+// Request 控制请求
+type Request struct {
+	Command string `json:"command"`
+	Level   string `json:"level,omitempty"` // log-level 的新级别（为空时只查询）
+}
+
+// Response 控制响应
+type Response struct {
+	OK      bool            `json:"ok"`
+	Error   string          `json:"error,omitempty"`
+	Status  *Status         `json:"status,omitempty"`
+	Changes []config.Change `json:"changes,omitempty"` // 重新加载后的配置变更
+	Level   string          `json:"level,omitempty"`   // 当前日志级别
+}
+
+// Status 守护进程运行状态
+type Status struct {
+	PID          int           `json:"pid"`
+	StartedAt    time.Time     `json:"started_at"`
+	Uptime       time.Duration `json:"uptime"`
+	Paused       bool          `json:"paused"`
+	Events       bool          `json:"events"` // 是否通过进程事件发现新进程
+	SecPoint     string        `json:"secpoint"`
+	AgentVersion string        `json:"agent_version,omitempty"`
+	Interval     time.Duration `json:"interval"`
+	Flags        Flags         `json:"flags"` // 启动时命令行指定的参数，重启时沿用
+	LastScan     time.Time     `json:"last_scan,omitempty"`
+	NextScan     time.Time     `json:"next_scan,omitempty"`
+
+	ScanCount    int `json:"scans"`
+	InjectCount  int `json:"injections"`
+	UpgradeCount int `json:"upgrades"`
+
+	Pending     []Pending           `json:"pending"`     // 等待维护窗口的进程
+	Quarantined []*quarantine.Entry `json:"quarantined"` // 退避中和已隔离的应用
+}
+
+// Flags 守护进程启动时命令行指定的参数，未指定的为空（使用配置文件中的值）
+type Flags struct {
+	AgentVersion string        `json:"agent_version,omitempty"` // --agent-version
+	Interval     time.Duration `json:"interval,omitempty"`      // --interval
+}
+
+// Pending 等待维护窗口的进程
+type Pending struct {
+	PID        int       `json:"pid"`
+	NextWindow time.Time `json:"next_window"`
+}
+
+// Handler 处理控制请求
+type Handler func(req *Request) *Response
+
+// Server 控制 socket 服务
+type Server struct {
+	path     string
+	listener net.Listener
+	handler  Handler
+}
+
+// Listen 在 UNIX socket 上监听控制请求
+// 调用方需持有守护进程的 PID 文件锁，残留的 socket 文件会被直接删除
+func Listen(path string, handler Handler) (*Server, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create socket directory: %w", err)
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to remove stale control socket: %w", err)
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on control socket: %w", err)
+	}
+
+	// 控制接口可以停止守护进程，只允许属主访问
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to set control socket permissions: %w", err)
+	}
+
+	return &Server{
+		path:     path,
+		listener: listener,
+		handler:  handler,
+	}, nil
+}
+
+// Serve 处理连接，直到 context 取消
+func (s *Server) Serve(ctx context.Context) {
+	go func() {
+		<-ctx.Done()
+		s.listener.Close()
+	}()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.serveConn(conn)
+	}
+}
+
+// Close 关闭监听并删除 socket 文件
+func (s *Server) Close() error {
+	s.listener.Close()
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove control socket: %w", err)
+	}
+	return nil
+}
+
+// serveConn 每个连接处理一个请求
+func (s *Server) serveConn(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(30 * time.Second))
+
+	var req Request
+	if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&req); err != nil {
+		json.NewEncoder(conn).Encode(&Response{Error: fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+
+	resp := s.handler(&req)
+	if resp == nil {
+		resp = &Response{Error: fmt.Sprintf("unknown command %q", req.Command)}
+	}
+	json.NewEncoder(conn).Encode(resp)
+}
+
+// ErrNotRunning 守护进程未运行（socket 不存在或无人监听）
+var ErrNotRunning = errors.New("daemon is not running")
+
+// Client 控制 socket 客户端
+type Client struct {
+	path    string
+	timeout time.Duration
+}
+
+// NewClient 创建客户端
+func NewClient(path string) *Client {
+	return &Client{
+		path:    path,
+		timeout: 10 * time.Second,
+	}
+}
+
+// Status 查询运行状态
+func (c *Client) Status() (*Status, error) {
+	resp, err := c.call(&Request{Command: CommandStatus})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Status == nil {
+		return nil, fmt.Errorf("daemon returned no status")
+	}
+	return resp.Status, nil
+}
+
+// Pause 暂停注入
+func (c *Client) Pause() error {
+	_, err := c.call(&Request{Command: CommandPause})
+	return err
+}
+
+// Resume 恢复注入
+func (c *Client) Resume() error {
+	_, err := c.call(&Request{Command: CommandResume})
+	return err
+}
+
+// Scan 请求立即全量扫描
+func (c *Client) Scan() error {
+	_, err := c.call(&Request{Command: CommandScan})
+	return err
+}
+
+// Stop 请求优雅停止（当前扫描完成后退出）
+func (c *Client) Stop() error {
+	_, err := c.call(&Request{Command: CommandStop})
+	return err
+}
+
+// Reload 请求重新加载配置，返回配置变更（配置无效时返回错误，守护进程保持原配置）
+func (c *Client) Reload() ([]config.Change, error) {
+	resp, err := c.call(&Request{Command: CommandReload})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Changes, nil
+}
+
+// LogLevel 修改日志级别（level 为空时只查询），返回当前级别
+// 修改在重新加载配置或重启后恢复为配置的级别
+func (c *Client) LogLevel(level string) (string, error) {
+	resp, err := c.call(&Request{Command: CommandLogLevel, Level: level})
+	if err != nil {
+		return "", err
+	}
+	return resp.Level, nil
+}
+
+// call 发送请求并读取响应
+func (c *Client) call(req *Request) (*Response, error) {
+	conn, err := net.DialTimeout("unix", c.path, c.timeout)
+	if err != nil {
+		if errors.Is(err, syscall.ENOENT) || errors.Is(err, syscall.ECONNREFUSED) {
+			return nil, ErrNotRunning
+		}
+		return nil, fmt.Errorf("failed to connect to daemon: %w", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(c.timeout))
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if !resp.OK {
+		return nil, fmt.Errorf("daemon: %s", resp.Error)
+	}
+
+	return &resp, nil
+}
@@ -3,67 +3,136 @@ package detector
 import (
 	"context"
 	"fmt"
+	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
 
 	"iast-auto-inject/internal/core/config"
+	"iast-auto-inject/internal/pkg/jar"
+	"iast-auto-inject/internal/pkg/jvmargs"
 	"iast-auto-inject/internal/pkg/logger"
 	"iast-auto-inject/internal/pkg/procfs"
 
 	"go.uber.org/zap"
 )
 
+// SecPointAgentName SecPoint agent 名称（匹配 SecPoint.jar）
+const SecPointAgentName = "secpoint"
+
+// Agent 来源
+const (
+	AgentSourceCmdLine = "cmdline"
+	AgentSourceEnv     = "JAVA_TOOL_OPTIONS"
+)
+
 // Agent Java Agent 信息
 type Agent struct {
-	Path       string `json:"path"`
-	Options    string `json:"options"`
-	FullParam  string `json:"full_param"`
+	Path      string `json:"path"`
+	Options   string `json:"options"`
+	FullParam string `json:"full_param"`
+	Version   string `json:"version,omitempty"` // 来自 jar MANIFEST 的版本
+	Source    string `json:"source"`            // 来源：cmdline 或 JAVA_TOOL_OPTIONS
+}
+
+// Listener 进程监听的 TCP 端口
+type Listener struct {
+	Proto   string `json:"proto"`   // tcp 或 tcp6
+	Address string `json:"address"` // 监听地址，0.0.0.0 或 :: 表示所有地址
+	Port    int    `json:"port"`
 }
 
 // JavaProcess Java 进程信息
 type JavaProcess struct {
-	PID        int       `json:"pid"`
-	Name       string    `json:"name"`
-	User       string    `json:"user"`
-	UID        int       `json:"uid"`
-	CmdLine    []string  `json:"cmdline"`
-	Envs       map[string]string `json:"envs"`
-	StartTime  string    `json:"start_time"`
-	Cwd        string    `json:"cwd"`
-	ExecPath   string    `json:"exec_path"`
-	Agents     []Agent   `json:"agents"`
-	MainClass  string    `json:"main_class"`
-	JarFile    string    `json:"jar_file"`
+	PID       int               `json:"pid"`
+	Name      string            `json:"name"`
+	User      string            `json:"user"`
+	UID       int               `json:"uid"`
+	GID       int               `json:"gid"`
+	Groups    []string          `json:"groups"` // 主组和附加组名称
+	CmdLine   []string          `json:"cmdline"`
+	Envs      map[string]string `json:"envs"`
+	StartTime string            `json:"start_time"`
+	Cwd       string            `json:"cwd"`
+	ExecPath  string            `json:"exec_path"`
+	Agents    []Agent           `json:"agents"`
+	MainClass string            `json:"main_class"`
+	JarFile   string            `json:"jar_file"`
+	// 运行环境（SystemdUnit 和 ContainerID 由 LoadCgroup 按需读取）
+	JavaVersion string `json:"java_version"` // JVM 版本（来自 JAVA_HOME/release）
+	SystemdUnit string `json:"systemd_unit"` // 所属 systemd unit
+	ContainerID string `json:"container_id"` // 所属容器 ID
 	// 进程元数据
-	MemoryRSS  uint64    `json:"memory_rss"`    // 驻留内存大小 (bytes)
-	MemoryVMS  uint64    `json:"memory_vms"`    // 虚拟内存大小 (bytes)
-	CPUPercent float64   `json:"cpu_percent"`   // CPU 使用率
-	Threads    int       `json:"threads"`       // 线程数
-	OpenFDs    int       `json:"open_fds"`      // 打开的文件描述符数量
+	MemoryRSS  uint64  `json:"memory_rss"`  // 驻留内存大小 (bytes)
+	MemoryVMS  uint64  `json:"memory_vms"`  // 虚拟内存大小 (bytes)
+	CPUPercent float64 `json:"cpu_percent"` // CPU 使用率
+	Threads    int     `json:"threads"`     // 线程数
+	OpenFDs    int     `json:"open_fds"`    // 打开的文件描述符数量
+	// 网络（由 LoadSockets 按需读取，读取其他用户进程的 socket 需要 root 权限，无法读取时为空）
+	Listening   []Listener `json:"listening"`   // 监听的 TCP 端口
+	Established int        `json:"established"` // 已建立的 TCP 连接数
+
+	startedAt     time.Time // 进程启动时间
+	cgroupLoaded  bool      // 已读取 cgroup
+	socketsLoaded bool      // 已读取 socket
 }
 
 // ProcessFilter 进程过滤器
 type ProcessFilter struct {
-	PIDs         []int
-	Names        []string
-	Users        []string
-	Patterns     []string
-	HasAgent     *bool // true: 有agent, false: 无agent, nil: 不限制
-	MinUptime    *int  // 最小运行时间（秒）
+	PIDs      []int
+	Names     []string
+	Users     []string
+	Patterns  []string
+	HasAgent  *bool // true: 有agent, false: 无agent, nil: 不限制
+	MinUptime *int  // 最小运行时间（秒）
+	// InScope 只返回配置的进程范围（process.include_pattern 和 process.user_filter）内的进程
+	// 用于注入、升级和移除；查看和清理 agent 等需要看到所有 JVM 的操作不设置
+	InScope bool
 }
 
 // Detector 进程检测器
 type Detector struct {
-	config *config.Config
+	config          *config.Config
+	includePatterns []*regexp.Regexp // process.include_pattern
+
+	mu            sync.Mutex
+	javaVersions  map[string]string       // 可执行文件路径 -> JVM 版本
+	groupNames    map[int]string          // GID -> 组名
+	agentVersions map[string]agentVersion // agent jar 路径 -> 版本（文件修改后重新读取）
+}
+
+// agentVersion 缓存的 agent jar 版本
+type agentVersion struct {
+	modTime time.Time
+	size    int64
+	version string
 }
 
 // NewDetector 创建检测器
 func NewDetector(cfg *config.Config) *Detector {
-	return &Detector{
-		config: cfg,
+	d := &Detector{
+		config:        cfg,
+		javaVersions:  make(map[string]string),
+		groupNames:    make(map[int]string),
+		agentVersions: make(map[string]agentVersion),
 	}
+
+	if cfg.Process != nil {
+		for _, pattern := range cfg.Process.IncludePattern {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				logger.Warn("Invalid include pattern", zap.String("pattern", pattern), zap.Error(err))
+				continue
+			}
+			d.includePatterns = append(d.includePatterns, re)
+		}
+	}
+
+	return d
 }
 
 // DiscoverJavaProcesses 发现所有 Java 进程
@@ -97,6 +166,14 @@ func (d *Detector) DiscoverJavaProcesses(ctx context.Context, filter *ProcessFil
 		// 解析 Java 进程信息
 		javaProc := d.parseJavaProcess(procInfo)
 
+		// 应用配置中的进程范围
+		if filter != nil && filter.InScope && !d.inScope(javaProc) {
+			logger.Debug("Process out of configured scope",
+				zap.Int("pid", javaProc.PID),
+				zap.String("user", javaProc.User))
+			continue
+		}
+
 		// 应用过滤器
 		if filter != nil && !d.matchFilter(javaProc, filter) {
 			continue
@@ -112,13 +189,28 @@ func (d *Detector) DiscoverJavaProcesses(ctx context.Context, filter *ProcessFil
 
 // isJavaProcess 判断是否为 Java 进程
 func (d *Detector) isJavaProcess(proc *procfs.Process) bool {
+	return isJava(proc.ExecPath, proc.CmdLine)
+}
+
+// IsJavaPID 根据可执行文件和命令行快速判断进程是否为 Java 进程（用于处理进程事件）
+func IsJavaPID(pid int) bool {
+	cmdLine, err := procfs.ReadCmdline(pid)
+	if err != nil {
+		return false
+	}
+	execPath, _ := procfs.ReadExe(pid)
+	return isJava(execPath, cmdLine)
+}
+
+// isJava 判断可执行文件和命令行是否属于 Java 进程
+func isJava(execPath string, cmdLine []string) bool {
 	// 检查可执行文件名
-	if filepath.Base(proc.ExecPath) == "java" {
+	if filepath.Base(execPath) == "java" {
 		return true
 	}
 
 	// 检查命令行是否包含 java 特征
-	for _, arg := range proc.CmdLine {
+	for _, arg := range cmdLine {
 		if strings.Contains(arg, "java") || strings.HasSuffix(arg, ".jar") {
 			return true
 		}
@@ -134,54 +226,145 @@ func (d *Detector) parseJavaProcess(proc *procfs.Process) *JavaProcess {
 		Name:       proc.Name,
 		User:       proc.User,
 		UID:        proc.UID,
+		GID:        proc.GID,
+		Groups:     d.groupNamesOf(proc.GID, proc.Groups),
 		CmdLine:    proc.CmdLine,
 		Envs:       proc.Envs,
 		StartTime:  proc.StartTime.Format("2006-01-02 15:04:05"),
 		Cwd:        proc.Cwd,
 		ExecPath:   proc.ExecPath,
-		Agents:     d.extractAgents(proc.CmdLine),
+		Agents:     d.extractAgents(proc.CmdLine, proc.Envs, proc.Cwd),
 		MemoryRSS:  proc.MemoryRSS,
 		MemoryVMS:  proc.MemoryVMS,
 		CPUPercent: proc.CPUPercent,
 		Threads:    proc.Threads,
 		OpenFDs:    proc.OpenFDs,
+		startedAt:  proc.StartTime,
 	}
 
-	// 解析主类或 JAR 文件
-	for _, arg := range proc.CmdLine {
-		if strings.HasSuffix(arg, ".jar") {
-			javaProc.JarFile = arg
-			break
-		}
-		if !strings.HasPrefix(arg, "-") && !strings.Contains(arg, "=") {
-			javaProc.MainClass = arg
+	// 运行环境（JVM 版本按可执行文件缓存，cgroup 和 socket 按需读取）
+	javaProc.JavaVersion = d.javaVersion(proc.ExecPath)
+
+	// 解析主类或 JAR 文件（JVM 选项之后的第一个参数）
+	_, end := JVMOptionsRange(proc.CmdLine)
+	if end < len(proc.CmdLine) {
+		switch arg := proc.CmdLine[end]; arg {
+		case "-jar":
+			if end+1 < len(proc.CmdLine) {
+				javaProc.JarFile = proc.CmdLine[end+1]
+			}
+		case "-m", "--module":
+			if end+1 < len(proc.CmdLine) {
+				javaProc.MainClass = proc.CmdLine[end+1]
+			}
+		default:
+			if strings.HasSuffix(arg, ".jar") {
+				javaProc.JarFile = arg
+			} else {
+				javaProc.MainClass = arg
+			}
 		}
 	}
 
 	return javaProc
 }
 
-// extractAgents 从命令行中提取 Agent 信息（仅检测 SecPoint.jar）
-func (d *Detector) extractAgents(cmdline []string) []Agent {
+// listenersOf 返回监听的端口和已建立的连接数
+func listenersOf(sockets []procfs.Socket) ([]Listener, int) {
+	var listening []Listener
+	established := 0
+	for _, socket := range sockets {
+		switch socket.State {
+		case procfs.StateListen:
+			listening = append(listening, Listener{Proto: socket.Proto, Address: socket.LocalAddr, Port: socket.LocalPort})
+		case procfs.StateEstablished:
+			established++
+		}
+	}
+	return listening, established
+}
+
+// ListenPorts 返回进程监听的端口（去重并排序），未读取 socket 时先读取
+func (p *JavaProcess) ListenPorts() []int {
+	p.LoadSockets()
+	return uniquePorts(p.Listening)
+}
+
+// MainName 返回进程的 JAR 文件或主类，都没有时返回 unknown
+func (p *JavaProcess) MainName() string {
+	if p.JarFile != "" {
+		return p.JarFile
+	}
+	if p.MainClass != "" {
+		return p.MainClass
+	}
+	return "unknown"
+}
+
+// StartedAt 返回进程启动时间
+func (p *JavaProcess) StartedAt() time.Time {
+	return p.startedAt
+}
+
+// ReadListenPorts 读取指定进程当前监听的端口（去重并排序），用于重启后确认新进程已开始监听
+func ReadListenPorts(pid int) ([]int, error) {
+	sockets, err := procfs.ReadSockets(pid)
+	if err != nil {
+		return nil, err
+	}
+	listening, _ := listenersOf(sockets)
+	return uniquePorts(listening), nil
+}
+
+// uniquePorts 返回去重并排序的端口
+func uniquePorts(listening []Listener) []int {
+	seen := make(map[int]bool, len(listening))
+	var ports []int
+	for _, listener := range listening {
+		if !seen[listener.Port] {
+			seen[listener.Port] = true
+			ports = append(ports, listener.Port)
+		}
+	}
+	sort.Ints(ports)
+	return ports
+}
+
+// FindJavaIndex 查找 java 命令的位置，找不到时返回 0
+func FindJavaIndex(cmdLine []string) int {
+	return max(jvmargs.FindJava(cmdLine), 0)
+}
+
+// JVMOptionsRange 返回 JVM 选项在命令行中的范围 [start, end)
+// 主类或 -jar 之后的参数属于应用程序参数，不应被修改
+func JVMOptionsRange(cmdLine []string) (int, int) {
+	return jvmargs.OptionsRange(cmdLine)
+}
+
+// extractAgents 从命令行和 JAVA_TOOL_OPTIONS 中提取所有 -javaagent 的 Agent 信息
+func (d *Detector) extractAgents(cmdline []string, envs map[string]string, cwd string) []Agent {
 	var agents []Agent
 
-	for _, arg := range cmdline {
-		// -javaagent: 或 -javaagent=
-		if strings.HasPrefix(arg, "-javaagent:") || strings.HasPrefix(arg, "-javaagent=") {
-			// 提取路径和选项
-			agent := parseAgentParam(arg)
-			// 只添加 SecPoint.jar 相关的 Agent
-			if agent != nil && strings.Contains(agent.Path, "SecPoint.jar") {
+	collect := func(args []string, source string) {
+		for _, arg := range args {
+			// -javaagent: 或 -javaagent=
+			if agent := ParseAgentParam(arg); agent != nil {
+				agent.Version = d.agentVersion(ResolveAgentPath(agent.Path, cwd))
+				agent.Source = source
 				agents = append(agents, *agent)
 			}
 		}
 	}
 
+	collect(cmdline, AgentSourceCmdLine)
+	// JVM 启动时会读取 JAVA_TOOL_OPTIONS 中的选项
+	collect(ToolOptionValues(envs[AgentSourceEnv]), AgentSourceEnv)
+
 	return agents
 }
 
-// parseAgentParam 解析 -javaagent 参数
-func parseAgentParam(arg string) *Agent {
+// ParseAgentParam 解析 -javaagent 参数
+func ParseAgentParam(arg string) *Agent {
 	// 移除 -javaagent: 或 -javaagent= 前缀
 	var param string
 	if strings.HasPrefix(arg, "-javaagent:") {
@@ -207,18 +390,40 @@ func parseAgentParam(arg string) *Agent {
 	}
 }
 
-// HasAgent 检查进程是否已附加指定 Agent（仅支持 SecPoint.jar）
-func (d *Detector) HasAgent(javaProc *JavaProcess, agentPath string) bool {
-	// 只检查 SecPoint.jar
-	if !strings.Contains(agentPath, "SecPoint.jar") {
+// ResolveAgentPath 解析 agent 的实际路径（相对路径基于进程工作目录）
+func ResolveAgentPath(path string, cwd string) string {
+	if filepath.IsAbs(path) || cwd == "" {
+		return path
+	}
+	return filepath.Join(cwd, path)
+}
+
+// MatchAgentName 检查 Agent 是否匹配指定名称
+// 名称可以是 jar 文件名（忽略大小写和 .jar 后缀，如 secpoint）或完整路径
+func MatchAgentName(agent Agent, name string) bool {
+	if name == "" {
 		return false
 	}
+	if filepath.Clean(agent.Path) == filepath.Clean(name) {
+		return true
+	}
 
-	// 规范化路径
-	normalizedPath := filepath.Clean(agentPath)
+	base := strings.TrimSuffix(strings.ToLower(filepath.Base(agent.Path)), ".jar")
+	return base == strings.TrimSuffix(strings.ToLower(name), ".jar")
+}
+
+// FindAgent 查找进程中匹配名称的 Agent
+func (d *Detector) FindAgent(javaProc *JavaProcess, name string) *Agent {
+	return javaProc.FindAgent(name)
+}
+
+// HasAgent 检查进程是否已附加指定路径的 Agent
+// 进程中的相对路径按工作目录解析，两侧都解析符号链接后比较
+func (d *Detector) HasAgent(javaProc *JavaProcess, agentPath string) bool {
+	normalizedPath := config.ResolvePath(agentPath)
 
 	for _, agent := range javaProc.Agents {
-		if filepath.Clean(agent.Path) == normalizedPath {
+		if config.ResolvePath(ResolveAgentPath(agent.Path, javaProc.Cwd)) == normalizedPath {
 			return true
 		}
 	}
@@ -228,7 +433,70 @@ func (d *Detector) HasAgent(javaProc *JavaProcess, agentPath string) bool {
 
 // HasSecPointAgent 检查进程是否已附加 SecPoint Agent
 func (d *Detector) HasSecPointAgent(javaProc *JavaProcess) bool {
-	return len(javaProc.Agents) > 0
+	return javaProc.HasSecPoint()
+}
+
+// HasSecPoint 检查进程是否已附加 SecPoint Agent（其他 agent 不影响注入）
+func (p *JavaProcess) HasSecPoint() bool {
+	return p.FindAgent(SecPointAgentName) != nil
+}
+
+// FindAgent 查找进程中匹配名称的 Agent
+func (p *JavaProcess) FindAgent(name string) *Agent {
+	for i := range p.Agents {
+		if MatchAgentName(p.Agents[i], name) {
+			return &p.Agents[i]
+		}
+	}
+	return nil
+}
+
+// agentVersion 读取 agent jar 的版本，按路径缓存，文件大小或修改时间变化后重新读取
+func (d *Detector) agentVersion(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+
+	d.mu.Lock()
+	cached, ok := d.agentVersions[path]
+	d.mu.Unlock()
+	if ok && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return cached.version
+	}
+
+	version, _ := jar.ReadVersion(path)
+
+	d.mu.Lock()
+	d.agentVersions[path] = agentVersion{modTime: info.ModTime(), size: info.Size(), version: version}
+	d.mu.Unlock()
+
+	return version
+}
+
+// inScope 检查进程是否在配置的进程范围内（process.include_pattern 和 process.user_filter）
+// 未配置时不限制
+func (d *Detector) inScope(javaProc *JavaProcess) bool {
+	if d.config.Process == nil {
+		return true
+	}
+
+	if len(d.config.Process.UserFilter) > 0 && !containsString(d.config.Process.UserFilter, javaProc.User) {
+		return false
+	}
+
+	if len(d.config.Process.IncludePattern) == 0 {
+		return true
+	}
+	for _, re := range d.includePatterns {
+		if re.MatchString(javaProc.Name) ||
+			re.MatchString(javaProc.JarFile) ||
+			re.MatchString(javaProc.MainClass) {
+			return true
+		}
+	}
+
+	return false
 }
 
 // matchFilter 检查进程是否匹配过滤条件
@@ -261,6 +529,11 @@ func (d *Detector) matchFilter(javaProc *JavaProcess, filter *ProcessFilter) boo
 		}
 	}
 
+	// 运行时间过滤
+	if filter.MinUptime != nil && time.Since(javaProc.startedAt) < time.Duration(*filter.MinUptime)*time.Second {
+		return false
+	}
+
 	// Agent 状态过滤
 	if filter.HasAgent != nil {
 		hasAny := len(javaProc.Agents) > 0
@@ -295,8 +568,12 @@ func (d *Detector) matchFilter(javaProc *JavaProcess, filter *ProcessFilter) boo
 	return true
 }
 
-// CheckPermissions 检查是否有权限操作进程
+// CheckPermissions 检查是否有权限操作进程（security.check_permissions 关闭时不检查）
 func (d *Detector) CheckPermissions(javaProc *JavaProcess) error {
+	if d.config.Security != nil && !d.config.Security.CheckPermissions {
+		return nil
+	}
+
 	// 检查是否是当前用户的进程
 	uid := syscall.Getuid()
 
@@ -307,36 +584,37 @@ func (d *Detector) CheckPermissions(javaProc *JavaProcess) error {
 	return nil
 }
 
-// IsExcluded 检查进程是否在排除列表中
-func (d *Detector) IsExcluded(javaProc *JavaProcess) bool {
-	for _, rule := range d.config.Exclude {
-		// PID 排除
-		for _, pid := range rule.PIDs {
-			if javaProc.PID == pid {
-				return true
-			}
-		}
+// CheckAllowed 检查进程所属用户和用户组是否在安全白名单中
+// allowed_users 为空时不限制用户；allowed_groups 为空时不限制用户组，否则进程的主组或任一附加组需在其中
+func (d *Detector) CheckAllowed(javaProc *JavaProcess) error {
+	security := d.config.Security
+	if security == nil {
+		return nil
+	}
 
-		// 用户排除
-		for _, user := range rule.Users {
-			if javaProc.User == user {
-				return true
-			}
-		}
+	if len(security.AllowedUsers) > 0 && !containsString(security.AllowedUsers, javaProc.User) {
+		return fmt.Errorf("user %s of process %d is not in security.allowed_users", javaProc.User, javaProc.PID)
+	}
 
-		// 模式排除
-		for _, pattern := range rule.Patterns {
-			re, err := regexp.Compile(pattern)
-			if err != nil {
-				continue
-			}
-			if re.MatchString(javaProc.Name) ||
-				re.MatchString(javaProc.JarFile) ||
-				re.MatchString(javaProc.MainClass) {
-				return true
+	if len(security.AllowedGroups) > 0 {
+		for _, group := range javaProc.Groups {
+			if containsString(security.AllowedGroups, group) {
+				return nil
 			}
 		}
+		return fmt.Errorf("groups [%s] of process %d are not in security.allowed_groups",
+			strings.Join(javaProc.Groups, ", "), javaProc.PID)
 	}
 
+	return nil
+}
+
+// containsString 检查切片是否包含指定字符串
+func containsString(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
 	return false
 }
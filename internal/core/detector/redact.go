@@ -0,0 +1,27 @@
+package detector
+
+import "iast-auto-inject/internal/pkg/redact"
+
+// Redacted 返回隐藏敏感值后的副本（命令行、环境变量和 agent 选项），用于输出和记录
+func (p *JavaProcess) Redacted(r *redact.Redactor) *JavaProcess {
+	clone := *p
+	clone.CmdLine = r.Args(p.CmdLine)
+	clone.Envs = r.Env(p.Envs)
+	clone.Agents = RedactAgents(p.Agents, r)
+	return &clone
+}
+
+// RedactAgents 返回隐藏 agent 选项中敏感值后的副本
+func RedactAgents(agents []Agent, r *redact.Redactor) []Agent {
+	if agents == nil {
+		return nil
+	}
+
+	out := make([]Agent, len(agents))
+	for i, agent := range agents {
+		agent.Options = r.Options(agent.Options)
+		agent.FullParam = r.Arg(agent.FullParam)
+		out[i] = agent
+	}
+	return out
+}
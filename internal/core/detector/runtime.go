@@ -0,0 +1,127 @@
+package detector
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"iast-auto-inject/internal/pkg/container"
+	"iast-auto-inject/internal/pkg/procfs"
+)
+
+// LoadDetails 读取发现进程时没有读取的信息（cgroup 和 socket），用于显示进程详情
+func (p *JavaProcess) LoadDetails() {
+	p.LoadCgroup()
+	p.LoadSockets()
+}
+
+// LoadCgroup 读取进程所属的 systemd unit 和容器 ID
+// 发现进程时不读取，只在需要时读取一次（如策略规则使用了 systemd_unit 或 container_labels）
+func (p *JavaProcess) LoadCgroup() {
+	if p.cgroupLoaded {
+		return
+	}
+	p.cgroupLoaded = true
+
+	if cgroups, err := procfs.ReadCgroup(p.PID); err == nil {
+		p.SystemdUnit = container.SystemdUnit(cgroups)
+		p.ContainerID = container.IDFromCgroup(cgroups)
+	}
+}
+
+// LoadSockets 读取进程监听的端口和已建立的连接数
+// 发现进程时不读取，只在需要时读取一次（如策略规则使用了 ports 或重启后需要验证端口）
+func (p *JavaProcess) LoadSockets() {
+	if p.socketsLoaded {
+		return
+	}
+	p.socketsLoaded = true
+
+	if sockets, err := procfs.ReadSockets(p.PID); err == nil {
+		p.Listening, p.Established = listenersOf(sockets)
+	}
+}
+
+// javaVersion 获取 JVM 版本（带缓存）
+func (d *Detector) javaVersion(execPath string) string {
+	if execPath == "" {
+		return ""
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if version, ok := d.javaVersions[execPath]; ok {
+		return version
+	}
+
+	version := ReadJavaVersion(execPath)
+	d.javaVersions[execPath] = version
+	return version
+}
+
+// groupNamesOf 将主组和附加组 GID 解析为组名（带缓存）
+func (d *Detector) groupNamesOf(gid int, groups []int) []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	seen := make(map[int]bool)
+	var names []string
+	for _, g := range append([]int{gid}, groups...) {
+		if seen[g] {
+			continue
+		}
+		seen[g] = true
+
+		name, ok := d.groupNames[g]
+		if !ok {
+			var err error
+			name, err = procfs.GetGroupName(g)
+			if err != nil {
+				name = strconv.Itoa(g)
+			}
+			d.groupNames[g] = name
+		}
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// ReadJavaVersion 从 JDK/JRE 的 release 文件读取 JVM 版本
+// bin/java 位于 JAVA_HOME/bin 或 JAVA_HOME/jre/bin
+func ReadJavaVersion(execPath string) string {
+	if resolved, err := filepath.EvalSymlinks(execPath); err == nil {
+		execPath = resolved
+	}
+
+	home := filepath.Dir(filepath.Dir(execPath))
+	for _, dir := range []string{home, filepath.Dir(home)} {
+		if version := readReleaseVersion(filepath.Join(dir, "release")); version != "" {
+			return version
+		}
+	}
+
+	return ""
+}
+
+// readReleaseVersion 读取 release 文件中的 JAVA_VERSION
+func readReleaseVersion(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "JAVA_VERSION=") {
+			return strings.Trim(strings.TrimPrefix(line, "JAVA_VERSION="), `"`)
+		}
+	}
+
+	return ""
+}
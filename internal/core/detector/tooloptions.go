@@ -0,0 +1,71 @@
+package detector
+
+import "strings"
+
+// SplitToolOptions 按 JVM 解析 JAVA_TOOL_OPTIONS 的规则拆分选项：以空白分隔，单引号或双引号内的空白不分隔
+// 返回保留引号的原始文本，使用 UnquoteToolOption 获取选项的值
+func SplitToolOptions(options string) []string {
+	var fields []string
+	start := -1
+	var quote byte
+
+	for i := 0; i < len(options); i++ {
+		c := options[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+			if start < 0 {
+				start = i
+			}
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if start >= 0 {
+				fields = append(fields, options[start:i])
+				start = -1
+			}
+		default:
+			if start < 0 {
+				start = i
+			}
+		}
+	}
+	if start >= 0 {
+		fields = append(fields, options[start:])
+	}
+
+	return fields
+}
+
+// UnquoteToolOption 去掉 SplitToolOptions 返回的选项中的引号
+func UnquoteToolOption(option string) string {
+	if !strings.ContainsAny(option, `'"`) {
+		return option
+	}
+
+	var b strings.Builder
+	var quote byte
+	for i := 0; i < len(option); i++ {
+		c := option[i]
+		switch {
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '\'' || c == '"'):
+			quote = c
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// ToolOptionValues 返回 JAVA_TOOL_OPTIONS 中去掉引号后的选项
+func ToolOptionValues(options string) []string {
+	fields := SplitToolOptions(options)
+	for i, field := range fields {
+		fields[i] = UnquoteToolOption(field)
+	}
+	return fields
+}
@@ -0,0 +1,99 @@
+package injector
+
+import (
+	"iast-auto-inject/internal/core/audit"
+	"iast-auto-inject/internal/core/config"
+	"iast-auto-inject/internal/core/detector"
+	"iast-auto-inject/internal/pkg/jar"
+	"iast-auto-inject/internal/pkg/logger"
+	"iast-auto-inject/internal/pkg/redact"
+
+	"go.uber.org/zap"
+)
+
+// auditLogFor 返回配置的审计日志，未启用时返回 nil
+func auditLogFor(cfg *config.Config) *audit.Log {
+	if cfg.Audit == nil || !cfg.Audit.Enabled {
+		return nil
+	}
+	return audit.Open(cfg.Audit.File)
+}
+
+// recordIntent 在重启进程前写入一条 started 记录
+// 工具在重启过程中退出时，审计日志中仍留有这次操作
+func (s *StaticInjector) recordIntent(op string, javaProc *detector.JavaProcess, result *InjectResult) {
+	if s.audit == nil {
+		return
+	}
+
+	rec := s.auditRecord(op, javaProc, result)
+	rec.Outcome = audit.OutcomeStarted
+	s.appendAudit(op, javaProc, rec)
+}
+
+// recordAudit 将一次注入、升级或移除的结果写入审计日志
+// 只记录尝试修改进程的操作（重启或被拒绝），已附加、未附加和策略跳过等无变化的结果不记录
+func (s *StaticInjector) recordAudit(op string, javaProc *detector.JavaProcess, result *InjectResult) {
+	if s.audit == nil || (result.NewCmdLine == nil && result.Error == nil) {
+		return
+	}
+
+	rec := s.auditRecord(op, javaProc, result)
+	switch {
+	case result.Success:
+		rec.Outcome = audit.OutcomeSuccess
+	case result.Denied():
+		rec.Outcome = audit.OutcomeDenied
+	}
+	if result.Error != nil {
+		rec.Error = redact.Default().Text(result.Error.Error())
+	}
+
+	s.appendAudit(op, javaProc, rec)
+}
+
+// auditRecord 根据操作结果构建审计记录，Outcome 默认为 failed
+func (s *StaticInjector) auditRecord(op string, javaProc *detector.JavaProcess, result *InjectResult) *audit.Record {
+	r := redact.Default()
+	rec := &audit.Record{
+		Action:  op,
+		PID:     javaProc.PID,
+		NewPID:  result.NewPID,
+		User:    javaProc.User,
+		Service: ServiceKey(javaProc),
+		Name:    javaProc.Name,
+		Agent:   result.Agent,
+		OldArgv: r.Args(result.OldCmdLine),
+		NewArgv: r.Args(result.NewCmdLine),
+		Code:    result.Code,
+		Outcome: audit.OutcomeFailed,
+	}
+	if result.Decision != nil {
+		rec.Strategy = result.Decision.Strategy
+	}
+	if result.Agent != "" {
+		if sum, err := jar.FileSHA256(result.Agent); err == nil {
+			rec.AgentSHA256 = sum
+		}
+	}
+	return rec
+}
+
+// appendAudit 追加审计记录，并将新的链头输出到日志
+// 日志输出到 journald 或远程 syslog 时，可用 'audit verify --expect-head' 发现截断或重写
+func (s *StaticInjector) appendAudit(op string, javaProc *detector.JavaProcess, rec *audit.Record) {
+	if err := s.audit.Append(rec); err != nil {
+		logger.Error("Failed to write audit record",
+			zap.String("path", s.audit.Path()),
+			zap.String("op", op),
+			zap.Int("pid", javaProc.PID),
+			zap.Error(err))
+		return
+	}
+
+	logger.Info("Audit record appended",
+		zap.String("path", s.audit.Path()),
+		zap.Int64("seq", rec.Seq),
+		zap.String("outcome", rec.Outcome),
+		zap.String("hash", rec.Hash))
+}
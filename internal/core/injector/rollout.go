@@ -0,0 +1,240 @@
+package injector
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"iast-auto-inject/internal/core/detector"
+	"iast-auto-inject/internal/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// rolloutFunc 对单个进程执行的操作（注入、升级或移除）
+type rolloutFunc func(ctx context.Context, javaProc *detector.JavaProcess) (*InjectResult, error)
+
+// verifyFunc 验证操作后的新进程
+type verifyFunc func(ctx context.Context, pid int) error
+
+// ServiceGroup 运行同一应用的进程（服务的多个副本）
+type ServiceGroup struct {
+	Key   string
+	Procs []*detector.JavaProcess
+}
+
+// ServiceKey 返回进程所属服务的标识：JAR 绝对路径，或主类加工作目录
+func ServiceKey(javaProc *detector.JavaProcess) string {
+	if javaProc.JarFile != "" {
+		jarPath := javaProc.JarFile
+		if !filepath.IsAbs(jarPath) {
+			jarPath = filepath.Join(javaProc.Cwd, jarPath)
+		}
+		return "jar:" + filepath.Clean(jarPath)
+	}
+	return fmt.Sprintf("main:%s@%s", javaProc.MainClass, javaProc.Cwd)
+}
+
+// GroupServices 按服务对进程分组，保持进程的原有顺序
+func GroupServices(javaProcs []*detector.JavaProcess) []*ServiceGroup {
+	var groups []*ServiceGroup
+	index := make(map[string]*ServiceGroup)
+
+	for _, javaProc := range javaProcs {
+		key := ServiceKey(javaProc)
+		group, ok := index[key]
+		if !ok {
+			group = &ServiceGroup{Key: key}
+			index[key] = group
+			groups = append(groups, group)
+		}
+		group.Procs = append(group.Procs, javaProc)
+	}
+
+	return groups
+}
+
+// rollout 按服务分组滚动执行操作
+// 每个服务先处理一个金丝雀实例并验证，之后每次最多并发处理 max_unavailable 个实例；
+// 任一实例失败后停止整个批次，剩余进程不再重启
+func (s *StaticInjector) rollout(ctx context.Context, javaProcs []*detector.JavaProcess, op string, fn rolloutFunc, verify verifyFunc) []*InjectResult {
+	results := make(map[int]*InjectResult, len(javaProcs))
+	var halted string
+
+	maxUnavailable, canary, canaryWait := 1, false, time.Duration(0)
+	if cfg := s.config.Rollout; cfg != nil {
+		if cfg.MaxUnavailable > 0 {
+			maxUnavailable = cfg.MaxUnavailable
+		}
+		canary = cfg.Canary
+		canaryWait = cfg.CanaryWait
+	}
+
+	for _, group := range GroupServices(javaProcs) {
+		if halted != "" {
+			break
+		}
+
+		logger.Info("Rolling out to service",
+			zap.String("op", op),
+			zap.String("service", group.Key),
+			zap.Int("replicas", len(group.Procs)))
+
+		procs := group.Procs
+
+		// 金丝雀实例：第一个实际重启并验证通过的实例（只有一个副本时无需额外等待）
+		if canary && len(procs) > 1 {
+			for len(procs) > 0 && halted == "" {
+				javaProc := procs[0]
+				procs = procs[1:]
+
+				result := s.rolloutOne(ctx, javaProc, op, fn, verify, canaryWait)
+				results[javaProc.PID] = result
+				if result.Failed() {
+					halted = fmt.Sprintf("canary PID %d of %s failed", javaProc.PID, group.Key)
+				} else if result.NewPID != 0 {
+					break
+				}
+			}
+			if halted != "" {
+				break
+			}
+		}
+
+		// 分批处理剩余实例
+		for start := 0; start < len(procs); start += maxUnavailable {
+			if ctx.Err() != nil {
+				halted = fmt.Sprintf("cancelled: %v", ctx.Err())
+				break
+			}
+
+			end := start + maxUnavailable
+			if end > len(procs) {
+				end = len(procs)
+			}
+			batch := procs[start:end]
+
+			batchResults := make([]*InjectResult, len(batch))
+			var wg sync.WaitGroup
+			for i, javaProc := range batch {
+				wg.Add(1)
+				go func(i int, javaProc *detector.JavaProcess) {
+					defer wg.Done()
+					batchResults[i] = s.rolloutOne(ctx, javaProc, op, fn, verify, 0)
+				}(i, javaProc)
+			}
+			wg.Wait()
+
+			for i, result := range batchResults {
+				results[batch[i].PID] = result
+				if result.Failed() && halted == "" {
+					halted = fmt.Sprintf("PID %d of %s failed", batch[i].PID, group.Key)
+				}
+			}
+			if halted != "" {
+				break
+			}
+		}
+	}
+
+	if halted != "" {
+		logger.Error("Rollout halted", zap.String("op", op), zap.String("reason", halted))
+	}
+
+	// 按输入顺序返回结果，未处理的进程记录停止原因
+	ordered := make([]*InjectResult, 0, len(javaProcs))
+	for _, javaProc := range javaProcs {
+		result, ok := results[javaProc.PID]
+		if !ok {
+			reason := "rollout halted: " + halted
+			result = &InjectResult{
+				PID:        javaProc.PID,
+				OldCmdLine: javaProc.CmdLine,
+				OldAgents:  javaProc.Agents,
+				Reason:     reason,
+				Code:       CodeRolloutHalted,
+				Message:    "Skipped, " + reason,
+			}
+		}
+		ordered = append(ordered, result)
+	}
+
+	return ordered
+}
+
+// rolloutOne 对单个进程执行操作并验证新进程
+// wait 大于 0 时在验证前观察新进程一段时间（用于金丝雀实例）
+func (s *StaticInjector) rolloutOne(ctx context.Context, javaProc *detector.JavaProcess, op string, fn rolloutFunc, verify verifyFunc, wait time.Duration) *InjectResult {
+	// 维护窗口在滚动过程中结束时不再重启，等待下一个窗口
+	if s.enforceWindows {
+		if active, next := s.MaintenanceWindow(javaProc, time.Now()); !active {
+			reason := "maintenance window closed"
+			logger.Info("Maintenance window closed, restart deferred",
+				zap.String("op", op),
+				zap.Int("pid", javaProc.PID),
+				zap.Time("next_window", next))
+			return &InjectResult{
+				PID:        javaProc.PID,
+				OldCmdLine: javaProc.CmdLine,
+				OldAgents:  javaProc.Agents,
+				Reason:     reason,
+				Code:       CodeMaintenance,
+				Message:    "Skipped, " + reason,
+			}
+		}
+	}
+
+	// 重启前读取原进程监听的固定端口（临时端口范围内的端口每次启动都可能不同）
+	var ports []int
+	if s.config.Restart != nil && s.config.Restart.PortWait > 0 {
+		ports = fixedPorts(javaProc.ListenPorts())
+	}
+
+	result, err := fn(ctx, javaProc)
+	if err != nil {
+		logger.Error("Rollout step failed",
+			zap.String("op", op),
+			zap.Int("pid", javaProc.PID),
+			zap.Error(err))
+	}
+	// 验证完成后再记录审计结果（重启前已写入意图记录），结果以验证为准
+	defer s.recordAudit(op, javaProc, result)
+
+	if !result.Success || result.NewPID == 0 {
+		return result
+	}
+
+	if wait > 0 {
+		logger.Info("Waiting before verifying canary",
+			zap.Int("pid", result.NewPID),
+			zap.Duration("wait", wait))
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+		}
+	}
+
+	// 验证新进程，并确认新进程重新监听了重启前的端口
+	err = nil
+	if verify != nil {
+		err = verify(ctx, result.NewPID)
+	}
+	if err == nil {
+		err = s.verifyPorts(ctx, ports, result.NewPID)
+	}
+	if err != nil {
+		result.Success = false
+		result.Error = fmt.Errorf("verification failed: %w", err)
+		result.Code = CodeVerification
+		result.Message = fmt.Sprintf("Restarted as PID %d but verification failed: %v", result.NewPID, err)
+		logger.Error("Rollout verification failed",
+			zap.String("op", op),
+			zap.Int("pid", javaProc.PID),
+			zap.Int("new_pid", result.NewPID),
+			zap.Error(err))
+	}
+
+	return result
+}
@@ -2,36 +2,126 @@ package injector
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"path/filepath"
 	"strings"
+	"time"
 
+	"iast-auto-inject/internal/core/audit"
 	"iast-auto-inject/internal/core/config"
 	"iast-auto-inject/internal/core/detector"
+	"iast-auto-inject/internal/core/policy"
 	"iast-auto-inject/internal/core/process"
+	"iast-auto-inject/internal/pkg/agentopts"
+	"iast-auto-inject/internal/pkg/jar"
 	"iast-auto-inject/internal/pkg/logger"
+	"iast-auto-inject/internal/pkg/redact"
 
 	"go.uber.org/zap"
 )
 
 // StaticInjector 静态注入器
 type StaticInjector struct {
-	config      *config.Config
-	detector    *detector.Detector
-	processMgr  *process.Manager
+	config     *config.Config
+	detector   *detector.Detector
+	processMgr *process.Manager
+	policy     *policy.Engine
+	audit      *audit.Log // 审计日志（未启用时为 nil）
+
+	enforceWindows bool // 滚动重启每个进程前检查维护窗口（守护进程）
 }
 
 // InjectResult 注入结果
 type InjectResult struct {
-	PID         int      `json:"pid"`
-	Success     bool     `json:"success"`
-	OldCmdLine  []string `json:"old_cmdline"`
-	NewCmdLine  []string `json:"new_cmdline"`
-	NewPID      int      `json:"new_pid"`
-	OldAgents   []detector.Agent `json:"old_agents"`
-	NewAgents   []detector.Agent `json:"new_agents"`
-	Error       error    `json:"error,omitempty"`
-	Message     string   `json:"message"`
+	PID        int              `json:"pid"`
+	Success    bool             `json:"success"`
+	OldCmdLine []string         `json:"old_cmdline"`
+	NewCmdLine []string         `json:"new_cmdline"`
+	NewPID     int              `json:"new_pid"`
+	OldAgents  []detector.Agent `json:"old_agents"`
+	NewAgents  []detector.Agent `json:"new_agents"`
+	Agent      string           `json:"agent,omitempty"`    // 注入、升级或移除的 agent jar 路径
+	Decision   *policy.Decision `json:"decision,omitempty"` // 策略决策
+	Reason     string           `json:"reason,omitempty"`   // 拒绝原因（权限、白名单或策略）
+	Code       string           `json:"code,omitempty"`     // 跳过或失败的分类（Code* 常量），用于统计
+	Error      error            `json:"error,omitempty"`
+	Message    string           `json:"message"`
+
+	RestartDuration time.Duration `json:"restart_duration,omitempty"` // 重启进程耗时
+}
+
+// 跳过或失败的分类
+const (
+	CodePolicy            = "policy"              // 策略不允许
+	CodeNotAllowed        = "not_allowed"         // 不在进程范围或安全白名单内
+	CodePermissionDenied  = "permission_denied"   // 没有权限操作进程
+	CodeRolloutHalted     = "rollout_halted"      // 滚动发布已停止
+	CodeMaintenance       = "maintenance_window"  // 维护窗口已结束
+	CodeAgentVerification = "agent_verification"  // agent jar 校验失败
+	CodeAgentOptions      = "agent_options"       // agent 选项渲染失败
+	CodeCmdLine           = "cmdline"             // 无法修改命令行
+	CodeRestartFailed     = "restart_failed"      // 重启进程失败
+	CodeVerification      = "verification_failed" // 新进程验证失败
+	CodeNotFound          = "not_found"           // 指定的进程不存在或不是范围内的 Java 进程
+)
+
+// Failed 检查操作是否失败（被策略、白名单拒绝或滚动停止而跳过的不视为失败）
+func (r *InjectResult) Failed() bool {
+	return r.Error != nil && r.Reason == ""
+}
+
+// Denied 检查操作是否被安全白名单或权限检查拒绝
+func (r *InjectResult) Denied() bool {
+	return r.Code == CodeNotAllowed || r.Code == CodePermissionDenied
+}
+
+// 结果状态
+const (
+	StatusSuccess = "success"
+	StatusSkipped = "skipped" // 无需操作，或被策略、白名单拒绝、滚动停止而跳过
+	StatusFailed  = "failed"
+)
+
+// Status 返回结果状态
+func (r *InjectResult) Status() string {
+	switch {
+	case r.Success:
+		return StatusSuccess
+	case r.Failed():
+		return StatusFailed
+	default:
+		return StatusSkipped
+	}
+}
+
+// MarshalJSON 序列化结果，Error 输出为错误信息（error 接口默认序列化为 {}），并附带结果状态
+// 命令行、agent 选项和错误信息中的敏感值被隐藏
+func (r *InjectResult) MarshalJSON() ([]byte, error) {
+	type plain InjectResult
+
+	red := redact.Default()
+	clone := *r
+	clone.OldCmdLine = red.Args(r.OldCmdLine)
+	clone.NewCmdLine = red.Args(r.NewCmdLine)
+	clone.OldAgents = detector.RedactAgents(r.OldAgents, red)
+	clone.NewAgents = detector.RedactAgents(r.NewAgents, red)
+	clone.Reason = red.Text(r.Reason)
+	clone.Message = red.Text(r.Message)
+
+	var errText string
+	if r.Error != nil {
+		errText = red.Text(r.Error.Error())
+	}
+
+	return json.Marshal(&struct {
+		*plain
+		Status string `json:"status"`
+		Error  string `json:"error,omitempty"`
+	}{
+		plain:  (*plain)(&clone),
+		Status: r.Status(),
+		Error:  errText,
+	})
 }
 
 // NewStaticInjector 创建静态注入器
@@ -40,9 +130,71 @@ func NewStaticInjector(cfg *config.Config, det *detector.Detector, mgr *process.
 		config:     cfg,
 		detector:   det,
 		processMgr: mgr,
+		policy:     policy.NewEngine(cfg),
+		audit:      auditLogFor(cfg),
 	}
 }
 
+// Decide 评估进程的注入策略
+func (s *StaticInjector) Decide(javaProc *detector.JavaProcess) *policy.Decision {
+	return s.policy.Evaluate(javaProc)
+}
+
+// Explain 评估进程的注入策略并返回每条规则的匹配过程
+func (s *StaticInjector) Explain(javaProc *detector.JavaProcess) *policy.Evaluation {
+	return s.policy.Explain(javaProc)
+}
+
+// MaintenanceWindow 检查当前是否处于进程的维护窗口内，并返回下一个窗口的开始时间
+// 未配置维护窗口时任何时间都允许；找不到下一个窗口时返回零值
+func (s *StaticInjector) MaintenanceWindow(javaProc *detector.JavaProcess, now time.Time) (bool, time.Time) {
+	// 注入都需要重启进程，因此总是受维护窗口限制
+	decision := s.policy.Evaluate(javaProc)
+	return decision.Windows.Active(now), decision.Windows.Next(now)
+}
+
+// EnforceMaintenanceWindows 滚动重启时在每个进程重启前重新检查维护窗口
+// 守护进程只在窗口内开始滚动，但批次较多时窗口可能在滚动过程中结束
+func (s *StaticInjector) EnforceMaintenanceWindows() {
+	s.enforceWindows = true
+}
+
+// checkAccess 检查是否允许操作进程，拒绝时在结果中记录原因
+func (s *StaticInjector) checkAccess(javaProc *detector.JavaProcess, result *InjectResult) error {
+	if err := s.detector.CheckAllowed(javaProc); err != nil {
+		result.Error = err
+		result.Reason = err.Error()
+		result.Code = CodeNotAllowed
+		result.Message = fmt.Sprintf("Not allowed: %v", err)
+		return err
+	}
+
+	if err := s.detector.CheckPermissions(javaProc); err != nil {
+		result.Error = err
+		result.Reason = err.Error()
+		result.Code = CodePermissionDenied
+		result.Message = fmt.Sprintf("Permission denied: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// checkPolicy 检查策略是否允许向进程注入指定 agent，不允许时返回原因
+func (s *StaticInjector) checkPolicy(decision *policy.Decision, agentPath string) string {
+	if decision.Action != config.PolicyActionInject {
+		if decision.Rule == "" {
+			return fmt.Sprintf("default policy action is %s", decision.Action)
+		}
+		return fmt.Sprintf("policy rule %s: %s", decision.Rule, decision.Action)
+	}
+	if !decision.AllowsAgent(s.config.FindAgentByPath(agentPath)) {
+		return fmt.Sprintf("policy rule %s does not allow agent %s (allowed: %s)",
+			decision.Rule, agentPath, strings.Join(decision.Agents, ", "))
+	}
+	return ""
+}
+
 // Inject 向指定进程注入 SecPoint Agent
 func (s *StaticInjector) Inject(ctx context.Context, javaProc *detector.JavaProcess, secPointPath string) (*InjectResult, error) {
 	logger.Info("Injecting SecPoint agent",
@@ -53,6 +205,7 @@ func (s *StaticInjector) Inject(ctx context.Context, javaProc *detector.JavaProc
 		PID:        javaProc.PID,
 		OldCmdLine: javaProc.CmdLine,
 		OldAgents:  javaProc.Agents,
+		Agent:      secPointPath,
 	}
 
 	// 检查是否已经有 SecPoint.jar
@@ -61,16 +214,39 @@ func (s *StaticInjector) Inject(ctx context.Context, javaProc *detector.JavaProc
 		return result, nil
 	}
 
-	// 检查权限
-	if err := s.detector.CheckPermissions(javaProc); err != nil {
+	// 检查注入策略
+	decision := s.policy.Evaluate(javaProc)
+	result.Decision = decision
+	if reason := s.checkPolicy(decision, secPointPath); reason != "" {
+		result.Reason = reason
+		result.Code = CodePolicy
+		result.Message = "Skipped by " + reason
+		logger.Info("Injection skipped by policy",
+			zap.Int("pid", javaProc.PID),
+			zap.String("reason", reason))
+		return result, nil
+	}
+
+	// 检查权限和安全白名单
+	if err := s.checkAccess(javaProc, result); err != nil {
+		return result, err
+	}
+
+	// 验证 agent jar
+	if err := s.VerifyAgentJar(secPointPath); err != nil {
 		result.Error = err
-		result.Message = fmt.Sprintf("Permission denied: %v", err)
+		result.Code = CodeAgentVerification
+		result.Message = fmt.Sprintf("Agent verification failed: %v", err)
 		return result, err
 	}
 
 	// 构建 SecPoint agent 参数
-	agent := detector.Agent{
-		Path: secPointPath,
+	agent, err := s.agentFor(javaProc, secPointPath, decision)
+	if err != nil {
+		result.Error = err
+		result.Code = CodeAgentOptions
+		result.Message = fmt.Sprintf("Failed to render agent options: %v", err)
+		return result, err
 	}
 
 	// 构建新的命令行
@@ -78,58 +254,401 @@ func (s *StaticInjector) Inject(ctx context.Context, javaProc *detector.JavaProc
 	result.NewCmdLine = newCmdLine
 
 	// 重启进程
-	restartOpts := &process.RestartOptions{
+	newPid, err := s.restart(ctx, "inject", javaProc, newCmdLine, s.restartOptions(), result)
+	if err != nil {
+		return result, err
+	}
+
+	result.Success = true
+	result.Message = fmt.Sprintf("Successfully injected SecPoint agent and restarted process (new PID: %d)", newPid)
+
+	logger.Info("SecPoint agent injected successfully",
+		zap.Int("old_pid", javaProc.PID),
+		zap.Int("new_pid", newPid))
+
+	return result, nil
+}
+
+// Upgrade 将进程中已附加的指定 Agent 原位替换为新的 jar
+// 命令行和 JAVA_TOOL_OPTIONS 中的 agent 都会被替换，保留原有的 agent 选项，不会追加第二个 -javaagent 参数
+func (s *StaticInjector) Upgrade(ctx context.Context, javaProc *detector.JavaProcess, agentName string, newAgentPath string) (*InjectResult, error) {
+	logger.Info("Upgrading agent",
+		zap.Int("pid", javaProc.PID),
+		zap.String("agent", agentName),
+		zap.String("agent_path", newAgentPath))
+
+	result := &InjectResult{
+		PID:        javaProc.PID,
+		OldCmdLine: javaProc.CmdLine,
+		OldAgents:  javaProc.Agents,
+		Agent:      newAgentPath,
+	}
+
+	oldAgent := s.detector.FindAgent(javaProc, agentName)
+	if oldAgent == nil {
+		result.Message = fmt.Sprintf("Agent %s not attached", agentName)
+		return result, nil
+	}
+
+	// 升级同样需要重启进程，遵循注入策略
+	decision := s.policy.Evaluate(javaProc)
+	result.Decision = decision
+	if reason := s.checkPolicy(decision, newAgentPath); reason != "" {
+		result.Reason = reason
+		result.Code = CodePolicy
+		result.Message = "Skipped by " + reason
+		logger.Info("Upgrade skipped by policy",
+			zap.Int("pid", javaProc.PID),
+			zap.String("reason", reason))
+		return result, nil
+	}
+
+	// 检查权限和安全白名单
+	if err := s.checkAccess(javaProc, result); err != nil {
+		return result, err
+	}
+
+	// 验证新的 agent jar
+	if err := s.VerifyAgentJar(newAgentPath); err != nil {
+		result.Error = err
+		result.Code = CodeAgentVerification
+		result.Message = fmt.Sprintf("Agent verification failed: %v", err)
+		return result, err
+	}
+
+	// 替换命令行中的 agent 参数
+	restartOpts := s.restartOptions()
+	newCmdLine := javaProc.CmdLine
+	replaced := false
+	for _, agent := range javaProc.Agents {
+		if agent.Source != detector.AgentSourceCmdLine || !detector.MatchAgentName(agent, agentName) {
+			continue
+		}
+		newAgent := detector.Agent{Path: newAgentPath, Options: agent.Options}
+		newCmdLine, replaced = s.replaceAgentInCmdLine(javaProc.CmdLine, agentName, newAgent)
+		break
+	}
+
+	// 替换 JAVA_TOOL_OPTIONS 中的 agent 参数，通过环境变量传给新进程
+	for _, agent := range javaProc.Agents {
+		if agent.Source != detector.AgentSourceEnv || !detector.MatchAgentName(agent, agentName) {
+			continue
+		}
+		newAgent := detector.Agent{Path: newAgentPath, Options: agent.Options}
+		newOptions, ok := replaceAgentInOptions(javaProc.Envs[detector.AgentSourceEnv], agentName, s.buildAgentParam(newAgent))
+		if ok {
+			restartOpts.EnvOverrides = map[string]string{detector.AgentSourceEnv: newOptions}
+			replaced = true
+		}
+		break
+	}
+
+	if !replaced {
+		err := fmt.Errorf("agent %s not found in JVM options or %s", agentName, detector.AgentSourceEnv)
+		result.Error = err
+		result.Code = CodeCmdLine
+		result.Message = err.Error()
+		return result, err
+	}
+	result.NewCmdLine = newCmdLine
+
+	// 重启进程
+	newPid, err := s.restart(ctx, "upgrade", javaProc, newCmdLine, restartOpts, result)
+	if err != nil {
+		return result, err
+	}
+
+	result.Success = true
+	result.Message = fmt.Sprintf("Successfully upgraded agent %s and restarted process (new PID: %d)", agentName, newPid)
+
+	logger.Info("Agent upgraded successfully",
+		zap.Int("old_pid", javaProc.PID),
+		zap.Int("new_pid", newPid),
+		zap.String("old_version", oldAgent.Version))
+
+	return result, nil
+}
+
+// BatchUpgrade 批量升级多个进程中的 Agent
+func (s *StaticInjector) BatchUpgrade(ctx context.Context, javaProcs []*detector.JavaProcess, agentName string, newAgentPath string) []*InjectResult {
+	return s.rollout(ctx, javaProcs, "upgrade",
+		func(ctx context.Context, javaProc *detector.JavaProcess) (*InjectResult, error) {
+			return s.Upgrade(ctx, javaProc, agentName, newAgentPath)
+		},
+		func(ctx context.Context, pid int) error {
+			return s.verifyAgent(ctx, pid, agentName, newAgentPath)
+		})
+}
+
+// EjectOptions 移除 Agent 的选项
+type EjectOptions struct {
+	FromCmdLine bool // 从命令行中移除
+	FromEnv     bool // 从 JAVA_TOOL_OPTIONS 中移除
+}
+
+// Eject 从进程中移除指定 Agent 并重启进程
+// 不依赖注入时保存的状态，直接基于当前解析出的命令行和环境变量操作，重启后验证 agent 已不存在
+func (s *StaticInjector) Eject(ctx context.Context, javaProc *detector.JavaProcess, agentName string, opts *EjectOptions) (*InjectResult, error) {
+	if opts == nil {
+		opts = &EjectOptions{FromCmdLine: true, FromEnv: true}
+	}
+
+	logger.Info("Ejecting agent",
+		zap.Int("pid", javaProc.PID),
+		zap.String("agent", agentName),
+		zap.Bool("cmdline", opts.FromCmdLine),
+		zap.Bool("env", opts.FromEnv))
+
+	result := &InjectResult{
+		PID:        javaProc.PID,
+		OldCmdLine: javaProc.CmdLine,
+		OldAgents:  javaProc.Agents,
+	}
+
+	oldAgent := s.detector.FindAgent(javaProc, agentName)
+	if oldAgent == nil {
+		result.Message = fmt.Sprintf("Agent %s not attached", agentName)
+		return result, nil
+	}
+	result.Agent = oldAgent.Path
+
+	// 检查未选择的来源中是否仍有该 agent，避免重启后 agent 依然存在
+	for _, agent := range javaProc.Agents {
+		if !detector.MatchAgentName(agent, agentName) {
+			continue
+		}
+		if (agent.Source == detector.AgentSourceCmdLine && !opts.FromCmdLine) ||
+			(agent.Source == detector.AgentSourceEnv && !opts.FromEnv) {
+			err := fmt.Errorf("agent %s is also attached via %s, which is not selected for removal", agentName, agent.Source)
+			result.Error = err
+			result.Code = CodeCmdLine
+			result.Message = err.Error()
+			return result, err
+		}
+	}
+
+	// 检查权限和安全白名单
+	if err := s.checkAccess(javaProc, result); err != nil {
+		return result, err
+	}
+
+	restartOpts := s.restartOptions()
+	newCmdLine := javaProc.CmdLine
+	removed := 0
+
+	// 从命令行移除
+	if opts.FromCmdLine {
+		var n int
+		newCmdLine, n = s.removeAgentFromCmdLine(javaProc.CmdLine, agentName)
+		removed += n
+	}
+
+	// 从 JAVA_TOOL_OPTIONS 移除
+	if toolOptions, ok := javaProc.Envs[detector.AgentSourceEnv]; ok && opts.FromEnv {
+		newOptions, n := removeAgentFromOptions(toolOptions, agentName)
+		if n > 0 {
+			removed += n
+			if newOptions == "" {
+				restartOpts.UnsetEnvs = []string{detector.AgentSourceEnv}
+			} else {
+				restartOpts.EnvOverrides = map[string]string{detector.AgentSourceEnv: newOptions}
+			}
+		}
+	}
+
+	if removed == 0 {
+		err := fmt.Errorf("agent %s not found in selected sources", agentName)
+		result.Error = err
+		result.Code = CodeCmdLine
+		result.Message = err.Error()
+		return result, err
+	}
+	result.NewCmdLine = newCmdLine
+
+	// 重启进程
+	newPid, err := s.restart(ctx, "eject", javaProc, newCmdLine, restartOpts, result)
+	if err != nil {
+		return result, err
+	}
+
+	// agent 是否已移除由 BatchEject 的滚动验证检查
+	result.Success = true
+	result.Message = fmt.Sprintf("Successfully ejected agent %s and restarted process (new PID: %d)", agentName, newPid)
+
+	logger.Info("Agent ejected successfully",
+		zap.Int("old_pid", javaProc.PID),
+		zap.Int("new_pid", newPid),
+		zap.Int("removed", removed))
+
+	return result, nil
+}
+
+// BatchEject 批量从多个进程中移除 Agent
+func (s *StaticInjector) BatchEject(ctx context.Context, javaProcs []*detector.JavaProcess, agentName string, opts *EjectOptions) []*InjectResult {
+	return s.rollout(ctx, javaProcs, "eject",
+		func(ctx context.Context, javaProc *detector.JavaProcess) (*InjectResult, error) {
+			return s.Eject(ctx, javaProc, agentName, opts)
+		},
+		func(ctx context.Context, pid int) error {
+			return s.VerifyAbsent(ctx, pid, agentName)
+		})
+}
+
+// verifyAgent 验证进程中的指定 Agent 已指向新的 jar
+func (s *StaticInjector) verifyAgent(ctx context.Context, pid int, agentName string, agentPath string) error {
+	procs, err := s.detector.DiscoverJavaProcesses(ctx, &detector.ProcessFilter{PIDs: []int{pid}})
+	if err != nil {
+		return fmt.Errorf("failed to discover process: %w", err)
+	}
+
+	if len(procs) == 0 {
+		return fmt.Errorf("process %d not found", pid)
+	}
+
+	agent := s.detector.FindAgent(procs[0], agentName)
+	if agent == nil {
+		return fmt.Errorf("agent %s not found", agentName)
+	}
+	// 相对路径按新进程的工作目录解析，两边都展开符号链接后比较
+	actual := detector.ResolveAgentPath(agent.Path, procs[0].Cwd)
+	if config.ResolvePath(actual) != config.ResolvePath(agentPath) {
+		return fmt.Errorf("agent %s still points to %s", agentName, agent.Path)
+	}
+
+	return nil
+}
+
+// VerifyAbsent 验证进程中不再包含指定 Agent
+func (s *StaticInjector) VerifyAbsent(ctx context.Context, pid int, agentName string) error {
+	procs, err := s.detector.DiscoverJavaProcesses(ctx, &detector.ProcessFilter{PIDs: []int{pid}})
+	if err != nil {
+		return fmt.Errorf("failed to discover process: %w", err)
+	}
+
+	if len(procs) == 0 {
+		return fmt.Errorf("process %d not found", pid)
+	}
+
+	if agent := s.detector.FindAgent(procs[0], agentName); agent != nil {
+		return fmt.Errorf("agent %s still attached via %s", agentName, agent.Source)
+	}
+
+	return nil
+}
+
+// removeAgentFromOptions 从 JAVA_TOOL_OPTIONS 形式的选项字符串中移除匹配名称的 javaagent
+// 保留其他选项的原始文本（包括引号）
+func removeAgentFromOptions(options string, name string) (string, int) {
+	var kept []string
+	removed := 0
+
+	for _, opt := range detector.SplitToolOptions(options) {
+		if matchAgentArg(detector.UnquoteToolOption(opt), name) {
+			removed++
+			continue
+		}
+		kept = append(kept, opt)
+	}
+
+	return strings.Join(kept, " "), removed
+}
+
+// replaceAgentInOptions 将 JAVA_TOOL_OPTIONS 形式的选项字符串中第一个匹配名称的 javaagent 替换为 param
+// 其余匹配项会被移除，其他选项保留原始文本；param 无法在选项中表示（同时包含两种引号和空白）时返回 false
+func replaceAgentInOptions(options string, name string, param string) (string, bool) {
+	quoted, ok := quoteToolOption(param)
+	if !ok {
+		return options, false
+	}
+
+	var kept []string
+	replaced := false
+	for _, opt := range detector.SplitToolOptions(options) {
+		if matchAgentArg(detector.UnquoteToolOption(opt), name) {
+			if !replaced {
+				kept = append(kept, quoted)
+				replaced = true
+			}
+			continue
+		}
+		kept = append(kept, opt)
+	}
+
+	return strings.Join(kept, " "), replaced
+}
+
+// quoteToolOption 按 SplitToolOptions 的规则为包含空白或引号的选项加引号
+func quoteToolOption(option string) (string, bool) {
+	if !strings.ContainsAny(option, " \t\n\r'\"") {
+		return option, true
+	}
+	if !strings.Contains(option, `"`) {
+		return `"` + option + `"`, true
+	}
+	if !strings.Contains(option, "'") {
+		return "'" + option + "'", true
+	}
+	return "", false
+}
+
+// NeedsUpgrade 检查进程中已附加的 Agent 版本是否低于目标版本
+// 无法读取已附加 agent 版本时返回 false
+func (s *StaticInjector) NeedsUpgrade(javaProc *detector.JavaProcess, agentName string, targetVersion string) bool {
+	if s.detector.CheckAllowed(javaProc) != nil ||
+		s.policy.Evaluate(javaProc).Action != config.PolicyActionInject {
+		return false
+	}
+
+	agent := s.detector.FindAgent(javaProc, agentName)
+	if agent == nil || agent.Version == "" {
+		return false
+	}
+
+	return jar.CompareVersions(agent.Version, targetVersion) < 0
+}
+
+// restartOptions 根据配置构建重启选项
+func (s *StaticInjector) restartOptions() *process.RestartOptions {
+	return &process.RestartOptions{
 		GracePeriod: s.config.Restart.GracePeriod,
 		KillTimeout: s.config.Restart.KillTimeout,
 		VerifyWait:  s.config.Restart.VerifyWait,
 		MaxRetries:  s.config.Restart.MaxRetries,
 	}
+}
 
+// restart 使用新的命令行重启进程，并记录新进程的 Agent 状态
+// 重启前先写入审计意图记录
+func (s *StaticInjector) restart(ctx context.Context, op string, javaProc *detector.JavaProcess, newCmdLine []string, restartOpts *process.RestartOptions, result *InjectResult) (int, error) {
+	s.recordIntent(op, javaProc, result)
+
+	start := time.Now()
 	newPid, err := s.processMgr.Restart(ctx, javaProc.PID, newCmdLine, restartOpts)
+	result.RestartDuration = time.Since(start)
 	if err != nil {
 		result.Error = err
+		result.Code = CodeRestartFailed
 		result.Message = fmt.Sprintf("Failed to restart process: %v", err)
-		return result, err
+		return 0, err
 	}
 
 	result.NewPID = newPid
-	result.Success = true
-	result.Message = fmt.Sprintf("Successfully injected SecPoint agent and restarted process (new PID: %d)", newPid)
 
 	// 获取新进程的 Agent 状态
 	if procInfo, err := s.detector.DiscoverJavaProcesses(ctx, &detector.ProcessFilter{PIDs: []int{newPid}}); err == nil && len(procInfo) > 0 {
 		result.NewAgents = procInfo[0].Agents
 	}
 
-	logger.Info("SecPoint agent injected successfully",
-		zap.Int("old_pid", javaProc.PID),
-		zap.Int("new_pid", newPid))
-
-	return result, nil
+	return newPid, nil
 }
 
 // BatchInject 批量注入多个进程
 func (s *StaticInjector) BatchInject(ctx context.Context, javaProcs []*detector.JavaProcess, secPointPath string) []*InjectResult {
-	results := make([]*InjectResult, 0, len(javaProcs))
-
-	for _, javaProc := range javaProcs {
-		select {
-		case <-ctx.Done():
-			logger.Warn("Batch inject cancelled", zap.Error(ctx.Err()))
-			break
-		default:
-		}
-
-		result, err := s.Inject(ctx, javaProc, secPointPath)
-		if err != nil {
-			logger.Error("Failed to inject SecPoint agent",
-				zap.Int("pid", javaProc.PID),
-				zap.Error(err))
-		}
-		results = append(results, result)
-	}
-
-	return results
+	return s.rollout(ctx, javaProcs, "inject",
+		func(ctx context.Context, javaProc *detector.JavaProcess) (*InjectResult, error) {
+			return s.Inject(ctx, javaProc, secPointPath)
+		},
+		s.Validate)
 }
 
 // buildNewCmdLine 构建新的命令行（插入 javaagent 参数）
@@ -137,18 +656,7 @@ func (s *StaticInjector) buildNewCmdLine(oldCmdLine []string, agents []detector.
 	newCmdLine := make([]string, 0, len(oldCmdLine)+len(agents))
 
 	// 查找 java 命令的位置
-	javaIdx := -1
-	for i, arg := range oldCmdLine {
-		if strings.Contains(filepath.Base(arg), "java") {
-			javaIdx = i
-			break
-		}
-	}
-
-	// 如果找不到 java 命令，直接在开头插入
-	if javaIdx == -1 {
-		javaIdx = 0
-	}
+	javaIdx := detector.FindJavaIndex(oldCmdLine)
 
 	// 复制 java 命令
 	newCmdLine = append(newCmdLine, oldCmdLine[:javaIdx+1]...)
@@ -165,6 +673,99 @@ func (s *StaticInjector) buildNewCmdLine(oldCmdLine []string, agents []detector.
 	return newCmdLine
 }
 
+// replaceAgentInCmdLine 将匹配名称的 javaagent 参数原位替换为新的 agent
+// 只替换 JVM 选项中的第一个匹配项，其余匹配项会被移除，避免重复加载
+func (s *StaticInjector) replaceAgentInCmdLine(oldCmdLine []string, name string, agent detector.Agent) ([]string, bool) {
+	start, end := detector.JVMOptionsRange(oldCmdLine)
+	newCmdLine := make([]string, 0, len(oldCmdLine))
+	replaced := false
+
+	for i, arg := range oldCmdLine {
+		if i >= start && i < end && matchAgentArg(arg, name) {
+			if !replaced {
+				newCmdLine = append(newCmdLine, s.buildAgentParam(agent))
+				replaced = true
+			}
+			continue
+		}
+		newCmdLine = append(newCmdLine, arg)
+	}
+
+	return newCmdLine, replaced
+}
+
+// removeAgentFromCmdLine 从 JVM 选项中移除匹配名称的 javaagent 参数
+func (s *StaticInjector) removeAgentFromCmdLine(oldCmdLine []string, name string) ([]string, int) {
+	start, end := detector.JVMOptionsRange(oldCmdLine)
+	newCmdLine := make([]string, 0, len(oldCmdLine))
+	removed := 0
+
+	for i, arg := range oldCmdLine {
+		if i >= start && i < end && matchAgentArg(arg, name) {
+			removed++
+			continue
+		}
+		newCmdLine = append(newCmdLine, arg)
+	}
+
+	return newCmdLine, removed
+}
+
+// matchAgentArg 检查命令行参数是否为匹配名称的 javaagent 参数
+func matchAgentArg(arg string, name string) bool {
+	if !strings.HasPrefix(arg, "-javaagent:") && !strings.HasPrefix(arg, "-javaagent=") {
+		return false
+	}
+
+	agent := detector.ParseAgentParam(arg)
+	return agent != nil && detector.MatchAgentName(*agent, name)
+}
+
+// BuildAgentArg 构建将注入到指定进程的 -javaagent 参数（用于预览）
+func (s *StaticInjector) BuildAgentArg(javaProc *detector.JavaProcess, agentPath string) (string, error) {
+	agent, err := s.agentFor(javaProc, agentPath, s.policy.Evaluate(javaProc))
+	if err != nil {
+		return "", err
+	}
+	return s.buildAgentParam(agent), nil
+}
+
+// agentFor 构建注入到指定进程的 agent，按进程渲染选项模板
+// 策略规则设置了选项时覆盖配置中的 agent 选项
+func (s *StaticInjector) agentFor(javaProc *detector.JavaProcess, agentPath string, decision *policy.Decision) (detector.Agent, error) {
+	agent := detector.Agent{
+		Path: agentPath,
+	}
+
+	var text string
+	if agentCfg := s.config.FindAgentByPath(agentPath); agentCfg != nil {
+		text = agentCfg.Options
+	}
+	if decision != nil && decision.Options != "" {
+		text = decision.Options
+	}
+	if text == "" {
+		return agent, nil
+	}
+
+	options, err := agentopts.Render(text, &agentopts.Data{
+		PID:       javaProc.PID,
+		User:      javaProc.User,
+		UID:       javaProc.UID,
+		Cwd:       javaProc.Cwd,
+		ExecPath:  javaProc.ExecPath,
+		MainClass: javaProc.MainClass,
+		JarFile:   javaProc.JarFile,
+		Envs:      javaProc.Envs,
+	})
+	if err != nil {
+		return agent, err
+	}
+	agent.Options = options
+
+	return agent, nil
+}
+
 // buildAgentParam 构建 agent 参数
 func (s *StaticInjector) buildAgentParam(agent detector.Agent) string {
 	if agent.Options != "" {
@@ -175,8 +776,13 @@ func (s *StaticInjector) buildAgentParam(agent detector.Agent) string {
 
 // NeedsInject 检查进程是否需要注入 SecPoint Agent
 func (s *StaticInjector) NeedsInject(javaProc *detector.JavaProcess) bool {
-	// 检查是否在排除列表中
-	if s.detector.IsExcluded(javaProc) {
+	// 检查安全白名单
+	if s.detector.CheckAllowed(javaProc) != nil {
+		return false
+	}
+
+	// 检查注入策略（skip 和 observe 都不注入）
+	if s.policy.Evaluate(javaProc).Action != config.PolicyActionInject {
 		return false
 	}
 
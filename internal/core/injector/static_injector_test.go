@@ -0,0 +1,79 @@
+package injector
+
+import "testing"
+
+func TestReplaceAgentInOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options string
+		param   string
+		want    string
+		ok      bool
+	}{
+		{
+			name:    "only agent",
+			options: "-javaagent:/opt/v1/SecPoint.jar",
+			param:   "-javaagent:/opt/v2/SecPoint.jar",
+			want:    "-javaagent:/opt/v2/SecPoint.jar",
+			ok:      true,
+		},
+		{
+			name:    "keeps other options",
+			options: "-Xmx1g  -javaagent:/opt/v1/SecPoint.jar=app=a -Dfoo='a b'",
+			param:   "-javaagent:/opt/v2/SecPoint.jar=app=a",
+			want:    "-Xmx1g -javaagent:/opt/v2/SecPoint.jar=app=a -Dfoo='a b'",
+			ok:      true,
+		},
+		{
+			name:    "quoted old agent",
+			options: `"-javaagent:/opt/my agents/SecPoint.jar" -Xss1m`,
+			param:   "-javaagent:/opt/v2/SecPoint.jar",
+			want:    "-javaagent:/opt/v2/SecPoint.jar -Xss1m",
+			ok:      true,
+		},
+		{
+			name:    "new path with space",
+			options: "-javaagent:/opt/v1/SecPoint.jar",
+			param:   "-javaagent:/opt/new agents/SecPoint.jar",
+			want:    `"-javaagent:/opt/new agents/SecPoint.jar"`,
+			ok:      true,
+		},
+		{
+			name:    "new options with double quote",
+			options: "-javaagent:/opt/v1/SecPoint.jar",
+			param:   `-javaagent:/opt/v2/SecPoint.jar=name="a b"`,
+			want:    `'-javaagent:/opt/v2/SecPoint.jar=name="a b"'`,
+			ok:      true,
+		},
+		{
+			name:    "duplicates removed",
+			options: "-javaagent:/a/SecPoint.jar -Xmx1g -javaagent:/b/SecPoint.jar",
+			param:   "-javaagent:/c/SecPoint.jar",
+			want:    "-javaagent:/c/SecPoint.jar -Xmx1g",
+			ok:      true,
+		},
+		{
+			name:    "other agent untouched",
+			options: "-javaagent:/opt/apm.jar",
+			param:   "-javaagent:/opt/v2/SecPoint.jar",
+			want:    "-javaagent:/opt/apm.jar",
+			ok:      false,
+		},
+		{
+			name:    "cannot quote",
+			options: "-javaagent:/opt/v1/SecPoint.jar",
+			param:   `-javaagent:/opt/v2/SecPoint.jar=a="x y",b='z'`,
+			want:    "-javaagent:/opt/v1/SecPoint.jar",
+			ok:      false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := replaceAgentInOptions(tt.options, "SecPoint", tt.param)
+			if got != tt.want || ok != tt.ok {
+				t.Fatalf("replaceAgentInOptions(%q) = %q, %v, want %q, %v", tt.options, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package injector
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"iast-auto-inject/internal/core/config"
+	"iast-auto-inject/internal/core/detector"
+	"iast-auto-inject/internal/pkg/jar"
+	"iast-auto-inject/internal/pkg/logger"
+	"iast-auto-inject/internal/pkg/procfs"
+
+	"go.uber.org/zap"
+)
+
+// VerifyAgentJar 在注入前验证 agent jar 的完整性
+// 依次检查文件所有者和权限、配置的 SHA-256 摘要以及 jar 签名
+// 所有检查都基于同一个打开的文件，避免检查过程中文件被替换
+func (s *StaticInjector) VerifyAgentJar(agentPath string) error {
+	security := s.config.Security
+
+	file, err := os.Open(agentPath)
+	if err != nil {
+		return fmt.Errorf("failed to open agent jar: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat agent jar: %w", err)
+	}
+
+	// 检查文件所有者和权限
+	if security != nil && security.VerifyAgentJar {
+		if err := checkAgentFileMode(agentPath, info); err != nil {
+			return err
+		}
+	}
+
+	agentCfg := s.config.FindAgentByPath(agentPath)
+
+	// 检查 SHA-256 摘要
+	pins := s.agentPins(agentCfg)
+	if len(pins) == 0 {
+		if security != nil && security.RequirePinnedAgent {
+			return fmt.Errorf("agent jar %s has no pinned sha256 digest in config", agentPath)
+		}
+	} else {
+		digest, err := jar.ReaderSHA256(io.NewSectionReader(file, 0, info.Size()))
+		if err != nil {
+			return err
+		}
+
+		matched := false
+		for _, expected := range pins {
+			if strings.EqualFold(expected, digest) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("agent jar %s sha256 %s does not match any pinned digest", agentPath, digest)
+		}
+	}
+
+	// 检查 jar 签名
+	if agentCfg != nil && agentCfg.TrustedCert != "" {
+		trusted, err := jar.LoadCertificates(agentCfg.TrustedCert)
+		if err != nil {
+			return err
+		}
+		if err := jar.VerifySignatureReader(file, info.Size(), trusted); err != nil {
+			return fmt.Errorf("agent jar %s signature verification failed: %w", agentPath, err)
+		}
+	}
+
+	logger.Debug("Agent jar verified", zap.String("agent_path", agentPath))
+
+	return nil
+}
+
+// agentPins 返回 agent jar 允许的 SHA-256 摘要
+// jar 不对应任何 agent 配置而其他 agent 配置了摘要时，必须匹配其中之一（失败关闭）
+func (s *StaticInjector) agentPins(agentCfg *config.AgentConfig) []string {
+	if agentCfg != nil {
+		return agentCfg.SHA256
+	}
+
+	var pins []string
+	for _, agent := range s.config.Agents {
+		pins = append(pins, agent.SHA256...)
+	}
+	return pins
+}
+
+// checkAgentFileMode 拒绝全局可写、非 root 所有或位于全局可写目录中的 agent jar
+func checkAgentFileMode(agentPath string, info os.FileInfo) error {
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("agent jar %s is not a regular file", agentPath)
+	}
+
+	if info.Mode().Perm()&0o002 != 0 {
+		return fmt.Errorf("agent jar %s is world-writable", agentPath)
+	}
+
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok && stat.Uid != 0 {
+		return fmt.Errorf("agent jar %s is owned by non-root user (uid %d)", agentPath, stat.Uid)
+	}
+
+	// 全局可写且没有 sticky 位的目录允许任意用户替换 jar
+	dirInfo, err := os.Stat(filepath.Dir(agentPath))
+	if err != nil {
+		return fmt.Errorf("failed to stat agent directory: %w", err)
+	}
+	if dirInfo.Mode().Perm()&0o002 != 0 && dirInfo.Mode()&os.ModeSticky == 0 {
+		return fmt.Errorf("agent directory %s is world-writable", filepath.Dir(agentPath))
+	}
+
+	return nil
+}
+
+// portPollInterval 等待新进程监听端口时的检查间隔
+const portPollInterval = 500 * time.Millisecond
+
+// verifyPorts 等待重启后的新进程重新监听原进程监听的固定端口
+// 超过 restart.port_wait 仍未监听时验证失败；原进程没有监听端口或 port_wait 为 0 时不检查
+func (s *StaticInjector) verifyPorts(ctx context.Context, ports []int, pid int) error {
+	if s.config.Restart == nil || s.config.Restart.PortWait <= 0 || len(ports) == 0 {
+		return nil
+	}
+	wait := s.config.Restart.PortWait
+	deadline := time.Now().Add(wait)
+
+	for {
+		current, err := detector.ReadListenPorts(pid)
+		missing := missingPorts(ports, current)
+		if err == nil && len(missing) == 0 {
+			logger.Info("New process is listening on the original ports",
+				zap.Int("new_pid", pid),
+				zap.Ints("ports", ports))
+			return nil
+		}
+
+		if !procfs.IsProcessRunning(pid) {
+			return fmt.Errorf("process %d exited before listening on port(s) %v", pid, missing)
+		}
+		if time.Now().After(deadline) {
+			if err != nil {
+				return fmt.Errorf("failed to read listening ports of process %d: %w", pid, err)
+			}
+			return fmt.Errorf("process %d is not listening on port(s) %v after %s", pid, missing, wait)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(portPollInterval):
+		}
+	}
+}
+
+// fixedPorts 过滤掉本地临时端口范围内的端口
+func fixedPorts(ports []int) []int {
+	low, high := procfs.EphemeralPortRange()
+
+	var fixed []int
+	for _, port := range ports {
+		if port < low || port > high {
+			fixed = append(fixed, port)
+		}
+	}
+	return fixed
+}
+
+// missingPorts 返回 want 中不在 have 里的端口
+func missingPorts(want, have []int) []int {
+	var missing []int
+	for _, port := range want {
+		found := false
+		for _, p := range have {
+			if p == port {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, port)
+		}
+	}
+	return missing
+}
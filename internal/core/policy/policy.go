@@ -0,0 +1,442 @@
+package policy
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"iast-auto-inject/internal/core/config"
+	"iast-auto-inject/internal/core/detector"
+	"iast-auto-inject/internal/pkg/container"
+	"iast-auto-inject/internal/pkg/jar"
+	"iast-auto-inject/internal/pkg/logger"
+	"iast-auto-inject/internal/pkg/redact"
+	"iast-auto-inject/internal/pkg/schedule"
+
+	"go.uber.org/zap"
+)
+
+// Engine 注入策略引擎
+// 规则按顺序匹配，第一个匹配的规则决定动作；没有规则匹配时使用默认动作
+type Engine struct {
+	defaultAction string
+	rules         []*rule
+	windows       schedule.Windows // 全局维护窗口
+
+	mu     sync.Mutex
+	labels map[string]map[string]string // 容器 ID -> 标签（缓存）
+}
+
+// Decision 策略决策
+type Decision struct {
+	Rule     string   `json:"rule"`              // 匹配的规则，为空表示使用默认动作
+	Action   string   `json:"action"`            // inject, skip, observe
+	Agents   []string `json:"agents,omitempty"`  // 允许注入的 agent 名称
+	Options  string   `json:"options,omitempty"` // 覆盖的 agent 选项
+	Strategy string   `json:"strategy"`          // 注入方式
+	Reasons  []string `json:"reasons,omitempty"` // 匹配原因
+
+	Windows schedule.Windows `json:"-"` // 生效的维护窗口（规则窗口或全局窗口）
+}
+
+// RuleTrace 单条规则的匹配过程
+type RuleTrace struct {
+	Rule    string   `json:"rule"`
+	Action  string   `json:"action"`
+	Matched bool     `json:"matched"`
+	Reasons []string `json:"reasons"`
+}
+
+// Evaluation 完整的策略评估结果（用于 explain）
+type Evaluation struct {
+	Decision *Decision   `json:"decision"`
+	Traces   []RuleTrace `json:"traces"`
+}
+
+// rule 编译后的规则
+type rule struct {
+	id     string
+	config config.PolicyRule
+
+	process     *regexp.Regexp
+	cmdLine     *regexp.Regexp
+	jar         *regexp.Regexp
+	mainClass   *regexp.Regexp
+	cwd         *regexp.Regexp
+	systemdUnit *regexp.Regexp
+	env         map[string]*regexp.Regexp
+	labels      map[string]*regexp.Regexp
+	windows     schedule.Windows
+}
+
+// NewEngine 创建策略引擎
+// 旧的 exclude 规则被转换为位于最前面的 skip 规则
+func NewEngine(cfg *config.Config) *Engine {
+	e := &Engine{
+		defaultAction: config.PolicyActionInject,
+		labels:        make(map[string]map[string]string),
+	}
+
+	for i, ex := range cfg.Exclude {
+		for _, r := range excludeRules(ex) {
+			e.rules = append(e.rules, compile(fmt.Sprintf("exclude[%d]", i), r))
+		}
+	}
+
+	if cfg.Maintenance != nil {
+		e.windows = compileWindows("maintenance", cfg.Maintenance.Windows)
+	}
+
+	if cfg.Policy != nil {
+		if cfg.Policy.DefaultAction != "" {
+			e.defaultAction = cfg.Policy.DefaultAction
+		}
+		for i, r := range cfg.Policy.Rules {
+			e.rules = append(e.rules, compile(fmt.Sprintf("rules[%d]", i), r))
+		}
+	}
+
+	return e
+}
+
+// excludeRules 将排除规则转换为策略规则
+// 排除规则中任一条件满足即排除，因此每个条件各自生成一条规则
+func excludeRules(ex config.ExcludeRule) []config.PolicyRule {
+	var rules []config.PolicyRule
+
+	if len(ex.PIDs) > 0 {
+		rules = append(rules, config.PolicyRule{Name: ex.Name, Match: config.PolicyMatch{PIDs: ex.PIDs}})
+	}
+	if len(ex.Users) > 0 {
+		rules = append(rules, config.PolicyRule{Name: ex.Name, Match: config.PolicyMatch{Users: ex.Users}})
+	}
+	for _, pattern := range ex.Patterns {
+		rules = append(rules, config.PolicyRule{Name: ex.Name, Match: config.PolicyMatch{Process: pattern}})
+	}
+
+	for i := range rules {
+		rules[i].Action = config.PolicyActionSkip
+	}
+
+	return rules
+}
+
+// compile 编译规则中的正则表达式
+func compile(id string, cfg config.PolicyRule) *rule {
+	r := &rule{
+		id:     id,
+		config: cfg,
+		env:    make(map[string]*regexp.Regexp),
+		labels: make(map[string]*regexp.Regexp),
+	}
+
+	r.process = r.mustCompile(cfg.Match.Process)
+	r.cmdLine = r.mustCompile(cfg.Match.CmdLine)
+	r.jar = r.mustCompile(cfg.Match.Jar)
+	r.mainClass = r.mustCompile(cfg.Match.MainClass)
+	r.cwd = r.mustCompile(cfg.Match.Cwd)
+	r.systemdUnit = r.mustCompile(cfg.Match.SystemdUnit)
+	for key, pattern := range cfg.Match.Env {
+		r.env[key] = r.mustCompile(pattern)
+	}
+	for key, pattern := range cfg.Match.ContainerLabels {
+		r.labels[key] = r.mustCompile(pattern)
+	}
+	r.windows = compileWindows(id, cfg.Windows)
+
+	return r
+}
+
+// mustCompile 编译正则，为空时返回 nil
+// 配置加载时已验证过正则，编译失败时按失败关闭处理：
+// inject 规则的条件永不匹配，skip 和 observe 规则的条件总是匹配（宁可不注入也不误注入）
+func (r *rule) mustCompile(pattern string) *regexp.Regexp {
+	if pattern == "" {
+		return nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err == nil {
+		return re
+	}
+
+	if r.action() == config.PolicyActionInject {
+		logger.Warn("Invalid policy regex, condition will never match",
+			zap.String("rule", r.id),
+			zap.String("pattern", pattern),
+			zap.Error(err))
+		return regexp.MustCompile(`$.^`)
+	}
+	logger.Warn("Invalid policy regex, condition will always match",
+		zap.String("rule", r.id),
+		zap.String("pattern", pattern),
+		zap.Error(err))
+	return regexp.MustCompile(``)
+}
+
+// compileWindows 解析维护窗口，配置加载时已验证过，解析失败的窗口被忽略
+func compileWindows(id string, windows []config.MaintenanceWindow) schedule.Windows {
+	var compiled schedule.Windows
+	for _, w := range windows {
+		window, err := w.Compile()
+		if err != nil {
+			logger.Warn("Invalid maintenance window, ignored",
+				zap.String("rule", id),
+				zap.String("window", w.Name),
+				zap.Error(err))
+			continue
+		}
+		compiled = append(compiled, window)
+	}
+	return compiled
+}
+
+// name 规则的显示名称
+func (r *rule) name() string {
+	if r.config.Name != "" {
+		return fmt.Sprintf("%s (%s)", r.config.Name, r.id)
+	}
+	return r.id
+}
+
+// action 规则动作（为空视为 inject）
+func (r *rule) action() string {
+	if r.config.Action == "" {
+		return config.PolicyActionInject
+	}
+	return r.config.Action
+}
+
+// Evaluate 评估进程对应的策略决策
+func (e *Engine) Evaluate(javaProc *detector.JavaProcess) *Decision {
+	for _, r := range e.rules {
+		if matched, reasons := e.match(r, javaProc); matched {
+			return e.decision(r, reasons)
+		}
+	}
+	return e.defaultDecision()
+}
+
+// Explain 评估所有规则并记录每条规则的匹配过程
+func (e *Engine) Explain(javaProc *detector.JavaProcess) *Evaluation {
+	eval := &Evaluation{}
+
+	for _, r := range e.rules {
+		matched, reasons := e.match(r, javaProc)
+		eval.Traces = append(eval.Traces, RuleTrace{
+			Rule:    r.name(),
+			Action:  r.action(),
+			Matched: matched,
+			Reasons: reasons,
+		})
+		if matched && eval.Decision == nil {
+			eval.Decision = e.decision(r, reasons)
+		}
+	}
+
+	if eval.Decision == nil {
+		eval.Decision = e.defaultDecision()
+	}
+
+	return eval
+}
+
+// decision 根据匹配的规则生成决策
+func (e *Engine) decision(r *rule, reasons []string) *Decision {
+	strategy := r.config.Strategy
+	if strategy == "" {
+		strategy = config.StrategyRestart
+	}
+	windows := r.windows
+	if len(windows) == 0 {
+		windows = e.windows
+	}
+	return &Decision{
+		Rule:     r.name(),
+		Action:   r.action(),
+		Agents:   r.config.Agents,
+		Options:  r.config.Options,
+		Strategy: strategy,
+		Reasons:  reasons,
+		Windows:  windows,
+	}
+}
+
+// defaultDecision 没有规则匹配时的决策
+func (e *Engine) defaultDecision() *Decision {
+	return &Decision{
+		Action:   e.defaultAction,
+		Strategy: config.StrategyRestart,
+		Reasons:  []string{"no rule matched, using default action"},
+		Windows:  e.windows,
+	}
+}
+
+// match 检查规则是否匹配进程，返回每个条件的检查结果
+// 所有已设置的条件都满足时规则匹配；没有任何条件的规则匹配所有进程
+func (e *Engine) match(r *rule, javaProc *detector.JavaProcess) (bool, []string) {
+	m := r.config.Match
+	matched := true
+	var reasons []string
+
+	check := func(ok bool, reason string) {
+		mark := "✓"
+		if !ok {
+			mark = "✗"
+			matched = false
+		}
+		reasons = append(reasons, mark+" "+reason)
+	}
+
+	if len(m.PIDs) > 0 {
+		check(containsInt(m.PIDs, javaProc.PID),
+			fmt.Sprintf("pid %d in %v", javaProc.PID, m.PIDs))
+	}
+	if len(m.Users) > 0 {
+		check(containsString(m.Users, javaProc.User),
+			fmt.Sprintf("user %q in %v", javaProc.User, m.Users))
+	}
+	if len(m.Groups) > 0 {
+		ok := false
+		for _, group := range javaProc.Groups {
+			if containsString(m.Groups, group) {
+				ok = true
+				break
+			}
+		}
+		check(ok, fmt.Sprintf("groups %v intersect %v", javaProc.Groups, m.Groups))
+	}
+	if r.process != nil {
+		ok := r.process.MatchString(javaProc.Name) ||
+			r.process.MatchString(javaProc.JarFile) ||
+			r.process.MatchString(javaProc.MainClass)
+		check(ok, fmt.Sprintf("process /%s/ matches name, jar or main class", m.Process))
+	}
+	if r.cmdLine != nil {
+		cmdLine := strings.Join(javaProc.CmdLine, " ")
+		check(r.cmdLine.MatchString(cmdLine), fmt.Sprintf("cmdline matches /%s/", m.CmdLine))
+	}
+	if r.jar != nil {
+		check(r.jar.MatchString(javaProc.JarFile),
+			fmt.Sprintf("jar %q matches /%s/", javaProc.JarFile, m.Jar))
+	}
+	if r.mainClass != nil {
+		check(r.mainClass.MatchString(javaProc.MainClass),
+			fmt.Sprintf("main class %q matches /%s/", javaProc.MainClass, m.MainClass))
+	}
+	if r.cwd != nil {
+		check(r.cwd.MatchString(javaProc.Cwd),
+			fmt.Sprintf("cwd %q matches /%s/", javaProc.Cwd, m.Cwd))
+	}
+	for _, key := range sortedKeys(m.Env) {
+		re := r.env[key]
+		value, ok := javaProc.Envs[key]
+		check(ok && re.MatchString(value),
+			fmt.Sprintf("env %s=%q matches /%s/", key, redact.Default().Value(key, value), m.Env[key]))
+	}
+	if r.systemdUnit != nil || len(r.labels) > 0 {
+		javaProc.LoadCgroup()
+	}
+	if r.systemdUnit != nil {
+		check(r.systemdUnit.MatchString(javaProc.SystemdUnit),
+			fmt.Sprintf("systemd unit %q matches /%s/", javaProc.SystemdUnit, m.SystemdUnit))
+	}
+	if len(r.labels) > 0 {
+		labels, err := e.containerLabels(javaProc.ContainerID)
+		for _, key := range sortedKeys(m.ContainerLabels) {
+			re := r.labels[key]
+			if err != nil {
+				check(false, fmt.Sprintf("container label %s: %v", key, err))
+				continue
+			}
+			value, ok := labels[key]
+			check(ok && re.MatchString(value),
+				fmt.Sprintf("container label %s=%q matches /%s/", key, value, m.ContainerLabels[key]))
+		}
+	}
+	if m.JavaVersion != "" {
+		ok := false
+		if javaProc.JavaVersion != "" {
+			ok, _ = jar.MatchConstraint(javaProc.JavaVersion, m.JavaVersion)
+		}
+		check(ok, fmt.Sprintf("java version %q satisfies %q", javaProc.JavaVersion, m.JavaVersion))
+	}
+	if len(m.Ports) > 0 {
+		ports := javaProc.ListenPorts()
+		ok := false
+		for _, port := range ports {
+			if containsInt(m.Ports, port) {
+				ok = true
+				break
+			}
+		}
+		check(ok, fmt.Sprintf("listening ports %v intersect %v", ports, m.Ports))
+	}
+
+	if len(reasons) == 0 {
+		reasons = append(reasons, "✓ rule has no conditions")
+	}
+
+	return matched, reasons
+}
+
+// containerLabels 获取容器标签（带缓存）
+func (e *Engine) containerLabels(id string) (map[string]string, error) {
+	if id == "" {
+		return nil, fmt.Errorf("not in a container")
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if labels, ok := e.labels[id]; ok {
+		return labels, nil
+	}
+
+	labels, err := container.Labels(id)
+	if err != nil {
+		return nil, err
+	}
+	e.labels[id] = labels
+
+	return labels, nil
+}
+
+// AllowsAgent 检查决策是否允许注入指定的 agent 配置
+func (d *Decision) AllowsAgent(agentCfg *config.AgentConfig) bool {
+	if len(d.Agents) == 0 {
+		return true
+	}
+	return agentCfg != nil && containsString(d.Agents, agentCfg.Name)
+}
+
+// sortedKeys 按字母顺序返回 map 的键（保证 explain 输出稳定）
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// containsInt 检查切片是否包含指定整数
+func containsInt(values []int, v int) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// containsString 检查切片是否包含指定字符串
+func containsString(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"strings"
 	"syscall"
 	"time"
 
 	"iast-auto-inject/internal/pkg/logger"
+	"iast-auto-inject/internal/pkg/redact"
 
 	"go.uber.org/zap"
 )
@@ -40,16 +42,19 @@ type StopOptions struct {
 
 // StartOptions 启动选项
 type StartOptions struct {
-	Cwd  string
-	Envs map[string]string
+	Cwd       string
+	Envs      map[string]string
+	UnsetEnvs []string // 需要从环境中移除的变量
 }
 
 // RestartOptions 重启选项
 type RestartOptions struct {
-	GracePeriod time.Duration
-	KillTimeout time.Duration
-	VerifyWait  time.Duration
-	MaxRetries  int
+	GracePeriod  time.Duration
+	KillTimeout  time.Duration
+	VerifyWait   time.Duration
+	MaxRetries   int
+	EnvOverrides map[string]string // 覆盖原进程的环境变量
+	UnsetEnvs    []string          // 从原进程环境中移除的变量
 }
 
 // Stop 停止进程
@@ -109,6 +114,7 @@ func (m *Manager) Stop(ctx context.Context, pid int, opts *StopOptions) error {
 }
 
 // Start 启动进程
+// 新进程的生命周期与 ctx 无关：ctx 取消（Ctrl+C、守护进程退出）只中止后续操作，不会杀死已启动的进程
 func (m *Manager) Start(ctx context.Context, cmdLine []string, opts *StartOptions) (int, error) {
 	if len(cmdLine) == 0 {
 		return 0, fmt.Errorf("command line is empty")
@@ -118,10 +124,10 @@ func (m *Manager) Start(ctx context.Context, cmdLine []string, opts *StartOption
 		opts = &StartOptions{}
 	}
 
-	logger.Info("Starting process", zap.Strings("cmdline", cmdLine), zap.String("cwd", opts.Cwd))
+	logger.Info("Starting process", zap.Strings("cmdline", redact.Default().Args(cmdLine)), zap.String("cwd", opts.Cwd))
 
 	// 创建命令
-	cmd := exec.CommandContext(ctx, cmdLine[0], cmdLine[1:]...)
+	cmd := exec.CommandContext(context.WithoutCancel(ctx), cmdLine[0], cmdLine[1:]...)
 
 	// 设置工作目录
 	if opts.Cwd != "" {
@@ -129,8 +135,8 @@ func (m *Manager) Start(ctx context.Context, cmdLine []string, opts *StartOption
 	}
 
 	// 设置环境变量
-	if opts.Envs != nil {
-		env := os.Environ()
+	if opts.Envs != nil || len(opts.UnsetEnvs) > 0 {
+		env := filterEnv(os.Environ(), opts.UnsetEnvs)
 		for k, v := range opts.Envs {
 			env = append(env, fmt.Sprintf("%s=%s", k, v))
 		}
@@ -167,7 +173,7 @@ func (m *Manager) Restart(ctx context.Context, pid int, newCmdLine []string, opt
 
 	logger.Info("Restarting process",
 		zap.Int("old_pid", pid),
-		zap.Strings("cmdline", newCmdLine))
+		zap.Strings("cmdline", redact.Default().Args(newCmdLine)))
 
 	// 停止原进程
 	stopOpts := &StopOptions{
@@ -181,10 +187,22 @@ func (m *Manager) Restart(ctx context.Context, pid int, newCmdLine []string, opt
 		// 继续尝试启动
 	}
 
-	// 启动新进程
+	// 启动新进程（复制环境变量，不修改调用方的进程信息）
+	envs := make(map[string]string, len(procInfo.Envs))
+	for k, v := range procInfo.Envs {
+		envs[k] = v
+	}
+	for k, v := range opts.EnvOverrides {
+		envs[k] = v
+	}
+	for _, k := range opts.UnsetEnvs {
+		delete(envs, k)
+	}
+
 	startOpts := &StartOptions{
-		Cwd:  procInfo.Cwd,
-		Envs: procInfo.Envs,
+		Cwd:       procInfo.Cwd,
+		Envs:      envs,
+		UnsetEnvs: opts.UnsetEnvs,
 	}
 
 	var newPid int
@@ -343,6 +361,29 @@ func readEnvs(pid int) (map[string]string, error) {
 	return envs, nil
 }
 
+// filterEnv 从 KEY=VALUE 形式的环境变量列表中移除指定的变量
+func filterEnv(env []string, unset []string) []string {
+	if len(unset) == 0 {
+		return env
+	}
+
+	filtered := make([]string, 0, len(env))
+	for _, kv := range env {
+		keep := true
+		for _, key := range unset {
+			if strings.HasPrefix(kv, key+"=") {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			filtered = append(filtered, kv)
+		}
+	}
+
+	return filtered
+}
+
 // isProcessExitedError 检查是否是进程退出错误
 func isProcessExitedError(err error) bool {
 	if err == nil {
@@ -0,0 +1,82 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// processState 返回 /proc/<pid>/stat 中的进程状态，进程不存在时返回空字符串
+func processState(pid int) string {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return ""
+	}
+	// comm 可能包含空格，状态位于最后一个 ')' 之后
+	fields := strings.Fields(string(data[strings.LastIndexByte(string(data), ')')+1:]))
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+// killChild 杀死并回收测试启动的子进程
+func killChild(pid int) {
+	syscall.Kill(pid, syscall.SIGKILL)
+	var status syscall.WaitStatus
+	syscall.Wait4(pid, &status, 0, nil)
+}
+
+// assertRunning 确认进程在一段时间内保持运行（僵尸进程视为已退出）
+func assertRunning(t *testing.T, pid int) {
+	t.Helper()
+
+	deadline := time.Now().Add(500 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		if state := processState(pid); state == "" || state == "Z" {
+			t.Fatalf("process %d exited after the context was cancelled (state %q)", pid, state)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestStartOutlivesContext(t *testing.T) {
+	m := NewManager(time.Second, time.Second, 0, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pid, err := m.Start(ctx, []string{"sleep", "30"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer killChild(pid)
+
+	cancel()
+	assertRunning(t, pid)
+}
+
+func TestRestartedProcessOutlivesContext(t *testing.T) {
+	m := NewManager(time.Second, time.Second, 0, 1)
+
+	// 模拟守护进程：重启后守护进程退出并取消 ctx
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	oldPid, err := m.Start(ctx, []string{"sleep", "30"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer killChild(oldPid)
+
+	newPid, err := m.Restart(ctx, oldPid, []string{"sleep", "31"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer killChild(newPid)
+
+	cancel()
+	assertRunning(t, newPid)
+}
@@ -0,0 +1,294 @@
+package quarantine
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"iast-auto-inject/internal/pkg/redact"
+)
+
+// Entry 单个应用的注入失败记录
+type Entry struct {
+	ID            string    `json:"id"`  // 短 ID（标识的 SHA-256 前缀）
+	Key           string    `json:"key"` // 稳定标识：用户 + 服务（JAR 或主类和工作目录）
+	Attempts      int       `json:"attempts"`
+	LastPID       int       `json:"last_pid"`
+	LastError     string    `json:"last_error"`
+	LastFailure   time.Time `json:"last_failure"`
+	NextRetry     time.Time `json:"next_retry"`
+	Quarantined   bool      `json:"quarantined"`
+	QuarantinedAt time.Time `json:"quarantined_at,omitempty"`
+}
+
+// Policy 退避和隔离策略
+type Policy struct {
+	InitialBackoff time.Duration // 第一次失败后的等待时间
+	MaxBackoff     time.Duration // 最大等待时间
+	MaxAttempts    int           // 连续失败达到该次数后隔离（0 表示不隔离）
+}
+
+// Backoff 返回第 attempts 次失败后的等待时间（指数退避）
+func (p Policy) Backoff(attempts int) time.Duration {
+	delay := p.InitialBackoff
+	for i := 1; i < attempts && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	return delay
+}
+
+// Store 持久化的失败记录
+// 每次修改都在文件锁内读取、修改并原子替换文件，守护进程和命令行可以同时操作
+type Store struct {
+	path   string
+	policy Policy
+}
+
+// NewStore 创建失败记录存储
+func NewStore(path string, policy Policy) *Store {
+	return &Store{
+		path:   path,
+		policy: policy,
+	}
+}
+
+// Path 返回状态文件路径
+func (s *Store) Path() string {
+	return s.path
+}
+
+// State 失败记录快照
+type State struct {
+	Entries map[string]*Entry `json:"entries"` // 标识 -> 记录
+}
+
+// Load 读取失败记录，文件不存在时返回空记录
+func (s *Store) Load() (*State, error) {
+	state := &State{Entries: make(map[string]*Entry)}
+
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse state file %s: %w", s.path, err)
+	}
+	if state.Entries == nil {
+		state.Entries = make(map[string]*Entry)
+	}
+
+	return state, nil
+}
+
+// Allowed 检查是否允许对指定应用再次尝试，不允许时返回记录
+func (st *State) Allowed(key string, now time.Time) (bool, *Entry) {
+	entry, ok := st.Entries[key]
+	if !ok {
+		return true, nil
+	}
+	if entry.Quarantined || now.Before(entry.NextRetry) {
+		return false, entry
+	}
+	return true, entry
+}
+
+// RecordFailure 记录一次失败，计算下一次重试时间，超过最大次数时隔离
+func (s *Store) RecordFailure(key string, pid int, cause error, now time.Time) (*Entry, error) {
+	var result *Entry
+
+	err := s.update(func(state *State) {
+		entry, ok := state.Entries[key]
+		if !ok {
+			entry = &Entry{ID: shortID(key), Key: key}
+			state.Entries[key] = entry
+		}
+
+		entry.Attempts++
+		entry.LastPID = pid
+		entry.LastFailure = now
+		if cause != nil {
+			entry.LastError = redact.Default().Text(cause.Error())
+		}
+		entry.NextRetry = now.Add(s.policy.Backoff(entry.Attempts))
+
+		if s.policy.MaxAttempts > 0 && entry.Attempts >= s.policy.MaxAttempts && !entry.Quarantined {
+			entry.Quarantined = true
+			entry.QuarantinedAt = now
+		}
+
+		copied := *entry
+		result = &copied
+	})
+
+	return result, err
+}
+
+// RecordSuccess 清除成功应用的失败记录
+// 在文件锁内检查记录，没有该应用的记录时不重写文件
+func (s *Store) RecordSuccess(key string) error {
+	// 没有状态文件时无需加锁（避免每次成功都创建锁文件）
+	if _, err := os.Stat(s.path); os.IsNotExist(err) {
+		return nil
+	}
+
+	return s.modify(func(state *State) bool {
+		if _, ok := state.Entries[key]; !ok {
+			return false
+		}
+		delete(state.Entries, key)
+		return true
+	})
+}
+
+// List 按最近失败时间倒序列出记录
+func (s *Store) List() ([]*Entry, error) {
+	state, err := s.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*Entry, 0, len(state.Entries))
+	for _, entry := range state.Entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].LastFailure.After(entries[j].LastFailure)
+	})
+
+	return entries, nil
+}
+
+// Release 释放记录（按完整标识或 ID 前缀匹配），返回被释放的记录
+func (s *Store) Release(idOrKey string) (*Entry, error) {
+	var released *Entry
+	var matchErr error
+
+	err := s.update(func(state *State) {
+		if entry, ok := state.Entries[idOrKey]; ok {
+			released = entry
+		} else {
+			var matches []*Entry
+			for _, entry := range state.Entries {
+				if idOrKey != "" && strings.HasPrefix(entry.ID, idOrKey) {
+					matches = append(matches, entry)
+				}
+			}
+			switch len(matches) {
+			case 0:
+				matchErr = fmt.Errorf("no entry matches %q", idOrKey)
+			case 1:
+				released = matches[0]
+			default:
+				matchErr = fmt.Errorf("%q matches %d entries, use a longer id", idOrKey, len(matches))
+			}
+		}
+
+		if released != nil {
+			delete(state.Entries, released.Key)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return released, matchErr
+}
+
+// ReleaseAll 释放所有记录
+func (s *Store) ReleaseAll() ([]*Entry, error) {
+	var released []*Entry
+
+	err := s.update(func(state *State) {
+		for _, entry := range state.Entries {
+			released = append(released, entry)
+		}
+		state.Entries = make(map[string]*Entry)
+	})
+
+	return released, err
+}
+
+// update 在文件锁内读取、修改并保存记录
+func (s *Store) update(fn func(state *State)) error {
+	return s.modify(func(state *State) bool {
+		fn(state)
+		return true
+	})
+}
+
+// modify 在文件锁内读取并修改记录，fn 返回 true 时保存
+func (s *Store) modify(fn func(state *State) bool) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	lock, err := os.OpenFile(s.path+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open lock file: %w", err)
+	}
+	defer lock.Close()
+
+	if err := syscall.Flock(int(lock.Fd()), syscall.LOCK_EX); err != nil {
+		return fmt.Errorf("failed to lock state file: %w", err)
+	}
+	defer syscall.Flock(int(lock.Fd()), syscall.LOCK_UN)
+
+	state, err := s.Load()
+	if err != nil {
+		return err
+	}
+
+	if !fn(state) {
+		return nil
+	}
+
+	return s.save(state)
+}
+
+// save 原子写入记录文件
+func (s *Store) save(state *State) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".state-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to replace state file: %w", err)
+	}
+
+	return nil
+}
+
+// shortID 生成标识的短 ID
+func shortID(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])[:12]
+}
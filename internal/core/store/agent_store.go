@@ -0,0 +1,342 @@
+package store
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"iast-auto-inject/internal/pkg/jar"
+	"iast-auto-inject/internal/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// currentLink 指向当前激活版本的符号链接名
+const currentLink = "current"
+
+// AgentVersion 仓库中的 agent 版本
+type AgentVersion struct {
+	Name        string    `json:"name"`
+	Version     string    `json:"version"`
+	Path        string    `json:"path"`
+	SHA256      string    `json:"sha256"`
+	Active      bool      `json:"active"`
+	InstalledAt time.Time `json:"installed_at"`
+}
+
+// AgentStore 本地版本化 agent 仓库
+// 目录结构: <root>/<name>/<version>/<jar>，<root>/<name>/current 为指向激活版本的符号链接
+type AgentStore struct {
+	root string
+}
+
+// NewAgentStore 创建 agent 仓库
+func NewAgentStore(root string) *AgentStore {
+	return &AgentStore{
+		root: root,
+	}
+}
+
+// Root 返回仓库根目录
+func (s *AgentStore) Root() string {
+	return s.root
+}
+
+// Install 安装 agent jar 到仓库
+// 版本目录一旦创建不会被覆盖：同一版本内容一致时直接返回，内容不同时报错
+func (s *AgentStore) Install(jarPath string, name string, version string) (*AgentVersion, error) {
+	if name == "" {
+		name = NameFromJar(jarPath)
+	}
+	if err := validateName(name); err != nil {
+		return nil, err
+	}
+
+	if version == "" {
+		v, err := jar.ReadVersion(jarPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to determine agent version (use --version): %w", err)
+		}
+		version = v
+	}
+	if err := validateName(version); err != nil {
+		return nil, fmt.Errorf("invalid version: %w", err)
+	}
+
+	digest, err := jar.FileSHA256(jarPath)
+	if err != nil {
+		return nil, err
+	}
+
+	agentDir := filepath.Join(s.root, name)
+	versionDir := filepath.Join(agentDir, version)
+	target := filepath.Join(versionDir, filepath.Base(jarPath))
+
+	// 已安装的版本
+	if _, err := os.Stat(versionDir); err == nil {
+		existing, err := s.get(name, version)
+		if err != nil {
+			return nil, err
+		}
+		if existing.SHA256 != digest {
+			return nil, fmt.Errorf("version %s of %s is already installed with different content", version, name)
+		}
+		logger.Info("Agent version already installed", zap.String("name", name), zap.String("version", version))
+		return existing, nil
+	}
+
+	if err := os.MkdirAll(agentDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create agent directory: %w", err)
+	}
+
+	// 先写入临时目录，完成后整体重命名，避免出现不完整的版本目录
+	tmpDir, err := os.MkdirTemp(agentDir, ".install-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := os.Chmod(tmpDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to chmod temp directory: %w", err)
+	}
+
+	if err := copyFile(jarPath, filepath.Join(tmpDir, filepath.Base(jarPath))); err != nil {
+		return nil, err
+	}
+
+	if err := os.Rename(tmpDir, versionDir); err != nil {
+		return nil, fmt.Errorf("failed to install version directory: %w", err)
+	}
+
+	logger.Info("Agent version installed",
+		zap.String("name", name),
+		zap.String("version", version),
+		zap.String("path", target))
+
+	return s.get(name, version)
+}
+
+// List 列出仓库中的 agent 版本（name 为空时列出全部）
+func (s *AgentStore) List(name string) ([]*AgentVersion, error) {
+	var names []string
+	if name != "" {
+		names = []string{name}
+	} else {
+		entries, err := os.ReadDir(s.root)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("failed to read agent store: %w", err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+				names = append(names, entry.Name())
+			}
+		}
+	}
+
+	var versions []*AgentVersion
+	for _, n := range names {
+		entries, err := os.ReadDir(filepath.Join(s.root, n))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read agent directory: %w", err)
+		}
+
+		var agentVersions []*AgentVersion
+		for _, entry := range entries {
+			if !entry.IsDir() || entry.Name() == currentLink || strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+			v, err := s.get(n, entry.Name())
+			if err != nil {
+				logger.Warn("Skipping invalid agent version", zap.String("name", n), zap.String("version", entry.Name()), zap.Error(err))
+				continue
+			}
+			agentVersions = append(agentVersions, v)
+		}
+
+		sort.Slice(agentVersions, func(i, j int) bool {
+			return jar.CompareVersions(agentVersions[i].Version, agentVersions[j].Version) < 0
+		})
+		versions = append(versions, agentVersions...)
+	}
+
+	return versions, nil
+}
+
+// Activate 激活指定版本（原子替换 current 符号链接）
+func (s *AgentStore) Activate(name string, version string) (*AgentVersion, error) {
+	v, err := s.get(name, version)
+	if err != nil {
+		return nil, err
+	}
+
+	agentDir := filepath.Join(s.root, name)
+	tmpLink := filepath.Join(agentDir, fmt.Sprintf(".%s-%d", currentLink, os.Getpid()))
+	_ = os.Remove(tmpLink)
+
+	// 使用相对路径，仓库目录整体移动后链接仍然有效
+	if err := os.Symlink(version, tmpLink); err != nil {
+		return nil, fmt.Errorf("failed to create symlink: %w", err)
+	}
+	if err := os.Rename(tmpLink, filepath.Join(agentDir, currentLink)); err != nil {
+		os.Remove(tmpLink)
+		return nil, fmt.Errorf("failed to activate version: %w", err)
+	}
+
+	logger.Info("Agent version activated", zap.String("name", name), zap.String("version", version))
+
+	v.Active = true
+	return v, nil
+}
+
+// Active 返回当前激活的版本
+func (s *AgentStore) Active(name string) (string, error) {
+	target, err := os.Readlink(filepath.Join(s.root, name, currentLink))
+	if err != nil {
+		return "", fmt.Errorf("no active version for %s: %w", name, err)
+	}
+	return filepath.Base(target), nil
+}
+
+// CurrentPath 返回通过 current 链接访问激活版本 jar 的路径
+func (s *AgentStore) CurrentPath(name string) (string, error) {
+	version, err := s.Active(name)
+	if err != nil {
+		return "", err
+	}
+	v, err := s.get(name, version)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(s.root, name, currentLink, filepath.Base(v.Path)), nil
+}
+
+// GC 删除既未激活也未被任何进程引用的版本
+// referenced 为运行中 JVM 引用的文件路径（已解析符号链接）
+func (s *AgentStore) GC(name string, referenced []string, dryRun bool) ([]*AgentVersion, error) {
+	versions, err := s.List(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []*AgentVersion
+	for _, v := range versions {
+		if v.Active {
+			continue
+		}
+
+		versionDir := filepath.Dir(v.Path)
+		resolved, err := filepath.EvalSymlinks(versionDir)
+		if err != nil {
+			resolved = versionDir
+		}
+		if isReferenced(resolved, referenced) {
+			logger.Debug("Agent version still referenced",
+				zap.String("name", v.Name),
+				zap.String("version", v.Version))
+			continue
+		}
+
+		if !dryRun {
+			if err := os.RemoveAll(versionDir); err != nil {
+				return removed, fmt.Errorf("failed to remove %s: %w", versionDir, err)
+			}
+			logger.Info("Agent version removed",
+				zap.String("name", v.Name),
+				zap.String("version", v.Version))
+		}
+		removed = append(removed, v)
+	}
+
+	return removed, nil
+}
+
+// get 读取指定版本信息
+func (s *AgentStore) get(name string, version string) (*AgentVersion, error) {
+	versionDir := filepath.Join(s.root, name, version)
+
+	matches, err := filepath.Glob(filepath.Join(versionDir, "*.jar"))
+	if err != nil || len(matches) == 0 {
+		return nil, fmt.Errorf("version %s of %s not found", version, name)
+	}
+
+	info, err := os.Stat(matches[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat agent jar: %w", err)
+	}
+
+	digest, err := jar.FileSHA256(matches[0])
+	if err != nil {
+		return nil, err
+	}
+
+	active, _ := s.Active(name)
+
+	return &AgentVersion{
+		Name:        name,
+		Version:     version,
+		Path:        matches[0],
+		SHA256:      digest,
+		Active:      active == version,
+		InstalledAt: info.ModTime(),
+	}, nil
+}
+
+// NameFromJar 根据 jar 文件名生成 agent 名称（如 SecPoint.jar -> secpoint）
+func NameFromJar(jarPath string) string {
+	return strings.TrimSuffix(strings.ToLower(filepath.Base(jarPath)), ".jar")
+}
+
+// validateName 检查名称是否可以作为目录名使用
+func validateName(name string) error {
+	if name == "" || name == currentLink || strings.HasPrefix(name, ".") ||
+		strings.ContainsAny(name, "/\\") {
+		return fmt.Errorf("invalid name: %q", name)
+	}
+	return nil
+}
+
+// isReferenced 检查目录下是否有文件被引用
+func isReferenced(dir string, referenced []string) bool {
+	prefix := dir + string(filepath.Separator)
+	for _, path := range referenced {
+		if path == dir || strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// copyFile 复制文件并同步到磁盘
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", src, err)
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", dst, err)
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to copy agent jar: %w", err)
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to sync agent jar: %w", err)
+	}
+
+	return out.Close()
+}
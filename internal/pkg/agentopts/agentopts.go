@@ -0,0 +1,180 @@
+package agentopts
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	tmplparse "text/template/parse"
+)
+
+// Data agent 选项模板的渲染数据
+type Data struct {
+	PID        int               // 目标进程 PID
+	User       string            // 进程所属用户
+	UID        int               // 进程所属用户 UID
+	Cwd        string            // 工作目录
+	ExecPath   string            // 可执行文件路径
+	MainClass  string            // 主类
+	JarFile    string            // JAR 文件路径
+	JarBase    string            // JAR 文件名（不含 .jar 后缀，渲染时自动填充）
+	AppName    string            // 应用名称（JarBase，没有 JAR 时为主类简单名，渲染时自动填充）
+	Hostname   string            // 主机名（渲染时自动填充）
+	InstanceID string            // 唯一实例 ID（渲染时自动生成）
+	Envs       map[string]string // 目标进程的环境变量
+}
+
+// Render 渲染 agent 选项模板
+// 不包含模板语法的字符串原样返回；模板动作输出的值包含选项分隔符 ',' 或 '=' 时返回错误
+func Render(text string, data *Data) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	data.complete()
+
+	tmpl, err := parse(text, data.Envs)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render agent options: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// Validate 验证 agent 选项模板（语法以及字段、函数是否存在）
+func Validate(text string) error {
+	if !strings.Contains(text, "{{") {
+		return nil
+	}
+
+	sample := &Data{
+		PID:       1,
+		User:      "user",
+		Cwd:       "/",
+		ExecPath:  "/usr/bin/java",
+		MainClass: "com.example.Main",
+		JarFile:   "/app/app.jar",
+		Envs:      map[string]string{},
+	}
+	_, err := Render(text, sample)
+	return err
+}
+
+// complete 补全由进程信息派生的字段
+func (d *Data) complete() {
+	if d.Hostname == "" {
+		d.Hostname, _ = os.Hostname()
+	}
+	if d.InstanceID == "" {
+		d.InstanceID = newInstanceID()
+	}
+	if d.JarBase == "" && d.JarFile != "" {
+		d.JarBase = strings.TrimSuffix(filepath.Base(d.JarFile), ".jar")
+	}
+	if d.AppName == "" {
+		if d.JarBase != "" {
+			d.AppName = d.JarBase
+		} else if d.MainClass != "" {
+			d.AppName = d.MainClass[strings.LastIndex(d.MainClass, ".")+1:]
+		}
+	}
+}
+
+// parse 解析模板
+func parse(text string, envs map[string]string) (*template.Template, error) {
+	tmpl, err := template.New("options").
+		Option("missingkey=error").
+		Funcs(funcs(envs)).
+		Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid agent options template: %w", err)
+	}
+
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil {
+			guardValues(t.Tree.Root)
+		}
+	}
+	return tmpl, nil
+}
+
+// guardValues 在每个输出值的动作管道末尾追加 optionValue 检查
+func guardValues(node tmplparse.Node) {
+	switch n := node.(type) {
+	case *tmplparse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			guardValues(child)
+		}
+	case *tmplparse.ActionNode:
+		// 变量声明和赋值不输出
+		if len(n.Pipe.Decl) > 0 {
+			return
+		}
+		n.Pipe.Cmds = append(n.Pipe.Cmds, &tmplparse.CommandNode{
+			NodeType: tmplparse.NodeCommand,
+			Pos:      n.Pos,
+			Args:     []tmplparse.Node{tmplparse.NewIdentifier("optionValue").SetPos(n.Pos)},
+		})
+	case *tmplparse.IfNode:
+		guardValues(n.List)
+		guardValues(n.ElseList)
+	case *tmplparse.RangeNode:
+		guardValues(n.List)
+		guardValues(n.ElseList)
+	case *tmplparse.WithNode:
+		guardValues(n.List)
+		guardValues(n.ElseList)
+	}
+}
+
+// optionValue 拒绝包含选项分隔符的值，避免环境变量等外部输入注入额外的 agent 选项
+func optionValue(value any) (string, error) {
+	s := fmt.Sprint(value)
+	if strings.ContainsAny(s, ",=") {
+		return "", fmt.Errorf("rendered value %q contains ',' or '=' which are agent option separators", s)
+	}
+	return s, nil
+}
+
+// funcs 模板函数
+func funcs(envs map[string]string) template.FuncMap {
+	return template.FuncMap{
+		// env 读取目标进程的环境变量，未设置时为空（不读取当前进程的环境变量，避免泄露注入工具的环境）
+		"env": func(key string) string {
+			return envs[key]
+		},
+		// default 值为空时使用默认值: {{env "DEPLOY_ENV" | default "prod"}}
+		"default": func(def string, value string) string {
+			if value == "" {
+				return def
+			}
+			return value
+		},
+		"lower":   strings.ToLower,
+		"upper":   strings.ToUpper,
+		"replace": func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+
+		"optionValue": optionValue,
+	}
+}
+
+// newInstanceID 生成唯一实例 ID
+func newInstanceID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,102 @@
+package container
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// containerIDPattern 容器 ID（64 位十六进制）
+var containerIDPattern = regexp.MustCompile(`[0-9a-f]{64}`)
+
+// 容器运行时配置文件位置（%s 为容器 ID）
+var (
+	// Docker: Config.Labels
+	dockerConfigPaths = []string{
+		"/var/lib/docker/containers/%s/config.v2.json",
+	}
+	// OCI 运行时配置（podman、cri-o、containerd）: annotations
+	ociConfigPaths = []string{
+		"/var/lib/containers/storage/overlay-containers/%s/userdata/config.json",
+		"/run/containerd/io.containerd.runtime.v2.task/k8s.io/%s/config.json",
+		"/run/containerd/io.containerd.runtime.v2.task/moby/%s/config.json",
+		"/run/containerd/io.containerd.runtime.v2.task/default/%s/config.json",
+	}
+)
+
+// IDFromCgroup 从 cgroup 路径中提取容器 ID，不在容器中时返回空字符串
+func IDFromCgroup(cgroups []string) string {
+	for _, line := range cgroups {
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if id := containerIDPattern.FindString(parts[2]); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// SystemdUnit 从 cgroup 路径中提取 systemd unit 名称
+func SystemdUnit(cgroups []string) string {
+	for _, line := range cgroups {
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		// cgroup v2 (0::) 或 v1 的 name=systemd 层级
+		if parts[0] != "0" && parts[1] != "name=systemd" {
+			continue
+		}
+
+		elems := strings.Split(parts[2], "/")
+		for i := len(elems) - 1; i >= 0; i-- {
+			if strings.HasSuffix(elems[i], ".service") || strings.HasSuffix(elems[i], ".scope") {
+				return elems[i]
+			}
+		}
+	}
+	return ""
+}
+
+// Labels 读取容器标签（Docker 标签或 OCI annotations）
+func Labels(id string) (map[string]string, error) {
+	if id == "" {
+		return nil, fmt.Errorf("empty container id")
+	}
+
+	for _, pattern := range dockerConfigPaths {
+		var cfg struct {
+			Config struct {
+				Labels map[string]string `json:"Labels"`
+			} `json:"Config"`
+		}
+		if err := readJSON(fmt.Sprintf(pattern, id), &cfg); err == nil {
+			return cfg.Config.Labels, nil
+		}
+	}
+
+	for _, pattern := range ociConfigPaths {
+		var cfg struct {
+			Annotations map[string]string `json:"annotations"`
+		}
+		if err := readJSON(fmt.Sprintf(pattern, id), &cfg); err == nil {
+			return cfg.Annotations, nil
+		}
+	}
+
+	return nil, fmt.Errorf("container %s config not found", id)
+}
+
+// readJSON 读取 JSON 文件
+func readJSON(path string, v interface{}) error {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,98 @@
+package jar
+
+import (
+	"archive/zip"
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// manifestPath MANIFEST 在 jar 包中的路径
+const manifestPath = "META-INF/MANIFEST.MF"
+
+// versionKeys 按优先级读取的版本属性
+var versionKeys = []string{
+	"Implementation-Version",
+	"Specification-Version",
+	"Bundle-Version",
+	"Agent-Version",
+}
+
+// ReadManifest 读取 jar 包的 MANIFEST.MF 主属性
+func ReadManifest(path string) (map[string]string, error) {
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open jar: %w", err)
+	}
+	defer reader.Close()
+
+	for _, file := range reader.File {
+		if !strings.EqualFold(file.Name, manifestPath) {
+			continue
+		}
+
+		rc, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open manifest: %w", err)
+		}
+		defer rc.Close()
+
+		return parseManifest(rc)
+	}
+
+	return nil, fmt.Errorf("manifest not found in %s", path)
+}
+
+// parseManifest 解析 MANIFEST 主段（支持续行）
+func parseManifest(r io.Reader) (map[string]string, error) {
+	attrs := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+
+	var lastKey string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		// 空行表示主段结束
+		if line == "" {
+			break
+		}
+
+		// 以空格开头为上一行的续行
+		if strings.HasPrefix(line, " ") {
+			if lastKey != "" {
+				attrs[lastKey] += line[1:]
+			}
+			continue
+		}
+
+		idx := strings.Index(line, ":")
+		if idx <= 0 {
+			continue
+		}
+		lastKey = strings.TrimSpace(line[:idx])
+		attrs[lastKey] = strings.TrimSpace(line[idx+1:])
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	return attrs, nil
+}
+
+// ReadVersion 读取 jar 包版本（来自 MANIFEST）
+func ReadVersion(path string) (string, error) {
+	attrs, err := ReadManifest(path)
+	if err != nil {
+		return "", err
+	}
+
+	for _, key := range versionKeys {
+		if v := attrs[key]; v != "" {
+			return v, nil
+		}
+	}
+
+	return "", fmt.Errorf("no version attribute in manifest of %s", path)
+}
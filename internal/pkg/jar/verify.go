@@ -0,0 +1,568 @@
+package jar
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path"
+	"strings"
+
+	// 注册摘要算法
+	_ "crypto/sha512"
+)
+
+// 支持的摘要算法（不接受 SHA-1 和 MD5）
+var digestAlgorithms = []struct {
+	name string
+	oid  asn1.ObjectIdentifier
+	hash crypto.Hash
+}{
+	{"SHA-256", asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}, crypto.SHA256},
+	{"SHA-384", asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}, crypto.SHA384},
+	{"SHA-512", asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}, crypto.SHA512},
+}
+
+var (
+	oidSignedData    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+)
+
+// PKCS#7 结构（仅包含验证 jar 签名所需字段）
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	ContentInfo      asn1.RawValue
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      []signerInfo  `asn1:"set"`
+}
+
+type issuerAndSerial struct {
+	IssuerName   asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type signerInfo struct {
+	Version                   int
+	IssuerAndSerialNumber     issuerAndSerial
+	DigestAlgorithm           pkix.AlgorithmIdentifier
+	AuthenticatedAttributes   asn1.RawValue `asn1:"optional,tag:0"`
+	DigestEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedDigest           []byte
+	UnauthenticatedAttributes asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue `asn1:"set"`
+}
+
+// manifestSection MANIFEST 中的一个段
+type manifestSection struct {
+	attrs map[string]string
+	raw   []byte
+}
+
+// FileSHA256 计算文件的 SHA-256 摘要（小写十六进制）
+func FileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	return ReaderSHA256(file)
+}
+
+// ReaderSHA256 计算 reader 中全部内容的 SHA-256 摘要（小写十六进制）
+func ReaderSHA256(r io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", fmt.Errorf("failed to hash file: %w", err)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// LoadCertificates 从 PEM 文件加载证书
+func LoadCertificates(path string) ([]*x509.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate: %w", err)
+	}
+
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificate found in %s", path)
+	}
+
+	return certs, nil
+}
+
+// VerifySignature 验证 jar 包签名
+// 要求签名块由受信任证书（或其签发的证书）签名，且 jar 中所有条目都被签名覆盖
+func VerifySignature(jarPath string, trusted []*x509.Certificate) error {
+	file, err := os.Open(jarPath)
+	if err != nil {
+		return fmt.Errorf("failed to open jar: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat jar: %w", err)
+	}
+
+	return VerifySignatureReader(file, info.Size(), trusted)
+}
+
+// VerifySignatureReader 验证 reader 中 jar 包的签名，用于验证已打开的文件，避免检查和使用之间文件被替换
+func VerifySignatureReader(r io.ReaderAt, size int64, trusted []*x509.Certificate) error {
+	reader, err := zip.NewReader(r, size)
+	if err != nil {
+		return fmt.Errorf("failed to open jar: %w", err)
+	}
+
+	files := make(map[string]*zip.File)
+	for _, file := range reader.File {
+		files[file.Name] = file
+	}
+
+	manifestFile := files[manifestPath]
+	if manifestFile == nil {
+		return fmt.Errorf("manifest not found")
+	}
+	manifest, err := readZipFile(manifestFile)
+	if err != nil {
+		return err
+	}
+
+	// 查找签名块及对应的 .SF 文件
+	var lastErr error
+	for _, file := range reader.File {
+		dir, base := path.Split(file.Name)
+		if dir != "META-INF/" || !isSignatureBlock(base) {
+			continue
+		}
+
+		sfName := "META-INF/" + strings.TrimSuffix(base, path.Ext(base)) + ".SF"
+		sfFile := files[sfName]
+		if sfFile == nil {
+			lastErr = fmt.Errorf("signature file %s not found", sfName)
+			continue
+		}
+
+		if err := verifySigner(file, sfFile, manifest, trusted); err != nil {
+			lastErr = fmt.Errorf("%s: %w", file.Name, err)
+			continue
+		}
+
+		// 签名有效，验证 jar 条目摘要
+		return verifyEntries(reader.File, manifest)
+	}
+
+	if lastErr != nil {
+		return lastErr
+	}
+	return fmt.Errorf("jar is not signed")
+}
+
+// verifySigner 验证签名块对 .SF 文件的签名以及 .SF 对 MANIFEST 的摘要
+func verifySigner(blockFile, sfFile *zip.File, manifest []byte, trusted []*x509.Certificate) error {
+	block, err := readZipFile(blockFile)
+	if err != nil {
+		return err
+	}
+	sf, err := readZipFile(sfFile)
+	if err != nil {
+		return err
+	}
+
+	var info contentInfo
+	if _, err := asn1.Unmarshal(block, &info); err != nil {
+		return fmt.Errorf("invalid signature block: %w", err)
+	}
+	if !info.ContentType.Equal(oidSignedData) {
+		return fmt.Errorf("signature block is not PKCS#7 signed data")
+	}
+
+	var sd signedData
+	if _, err := asn1.Unmarshal(info.Content.Bytes, &sd); err != nil {
+		return fmt.Errorf("invalid signed data: %w", err)
+	}
+
+	certs, err := x509.ParseCertificates(sd.Certificates.Bytes)
+	if err != nil {
+		return fmt.Errorf("invalid certificates: %w", err)
+	}
+
+	if len(sd.SignerInfos) == 0 {
+		return fmt.Errorf("no signer info")
+	}
+
+	for _, signer := range sd.SignerInfos {
+		cert := findSignerCert(certs, signer.IssuerAndSerialNumber)
+		if cert == nil {
+			return fmt.Errorf("signer certificate not found")
+		}
+
+		if err := checkTrusted(cert, certs, trusted); err != nil {
+			return err
+		}
+
+		if err := checkSignerInfo(signer, cert, sf); err != nil {
+			return err
+		}
+	}
+
+	return verifySignatureFile(sf, manifest)
+}
+
+// checkSignerInfo 验证 SignerInfo 中的签名
+func checkSignerInfo(signer signerInfo, cert *x509.Certificate, sf []byte) error {
+	hash, ok := hashByOID(signer.DigestAlgorithm.Algorithm)
+	if !ok {
+		return fmt.Errorf("unsupported digest algorithm: %s", signer.DigestAlgorithm.Algorithm)
+	}
+
+	signed := sf
+	if len(signer.AuthenticatedAttributes.Bytes) > 0 {
+		// 存在签名属性时，签名覆盖的是属性集合，属性中的 messageDigest 需与 .SF 摘要一致
+		digest, err := messageDigest(signer.AuthenticatedAttributes.Bytes)
+		if err != nil {
+			return err
+		}
+		h := hash.New()
+		h.Write(sf)
+		if !bytes.Equal(digest, h.Sum(nil)) {
+			return fmt.Errorf("message digest mismatch")
+		}
+
+		// 签名时属性使用 SET OF 标签编码
+		signed = append([]byte{0x31}, signer.AuthenticatedAttributes.FullBytes[1:]...)
+	}
+
+	algo, err := signatureAlgorithm(cert, hash)
+	if err != nil {
+		return err
+	}
+
+	if err := cert.CheckSignature(algo, signed, signer.EncryptedDigest); err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+
+	return nil
+}
+
+// checkTrusted 检查签名证书是否为受信任证书或由其签发
+func checkTrusted(cert *x509.Certificate, chain []*x509.Certificate, trusted []*x509.Certificate) error {
+	roots := x509.NewCertPool()
+	for _, t := range trusted {
+		if t.Equal(cert) {
+			return nil
+		}
+		roots.AddCert(t)
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, c := range chain {
+		if !c.Equal(cert) {
+			intermediates.AddCert(c)
+		}
+	}
+
+	_, err := cert.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return fmt.Errorf("signer %q is not trusted: %w", cert.Subject.String(), err)
+	}
+
+	return nil
+}
+
+// verifySignatureFile 验证 .SF 文件中记录的 MANIFEST 摘要
+func verifySignatureFile(sf []byte, manifest []byte) error {
+	sfSections := parseSections(sf)
+	if len(sfSections) == 0 {
+		return fmt.Errorf("empty signature file")
+	}
+
+	// 优先使用整个 MANIFEST 的摘要
+	if name, expected, hash, ok := findDigest(sfSections[0].attrs, "-Digest-Manifest"); ok {
+		if digestMatches(hash, manifest, expected) {
+			return nil
+		}
+		return fmt.Errorf("%s-Digest-Manifest mismatch", name)
+	}
+
+	// 回退到逐段验证：主段必须有摘要，且 MANIFEST 中的每个条目段都必须被 .SF 覆盖
+	mfSections := parseSections(manifest)
+	if len(mfSections) == 0 {
+		return fmt.Errorf("empty manifest")
+	}
+
+	name, expected, hash, ok := findDigest(sfSections[0].attrs, "-Digest-Manifest-Main-Attributes")
+	if !ok {
+		return fmt.Errorf("no supported manifest digest in signature file")
+	}
+	if !digestMatches(hash, mfSections[0].raw, expected) {
+		return fmt.Errorf("%s-Digest-Manifest-Main-Attributes mismatch", name)
+	}
+
+	sfEntries := make(map[string]manifestSection)
+	for _, section := range sfSections[1:] {
+		sfEntries[section.attrs["Name"]] = section
+	}
+
+	for _, mf := range mfSections[1:] {
+		entry := mf.attrs["Name"]
+		section, exists := sfEntries[entry]
+		if !exists {
+			return fmt.Errorf("manifest section %s is not covered by signature file", entry)
+		}
+		_, expected, hash, ok := findDigest(section.attrs, "-Digest")
+		if !ok {
+			return fmt.Errorf("no supported digest for %s in signature file", entry)
+		}
+		if !digestMatches(hash, mf.raw, expected) {
+			return fmt.Errorf("manifest section digest mismatch for %s", entry)
+		}
+	}
+
+	return nil
+}
+
+// verifyEntries 验证 jar 中所有条目均在 MANIFEST 中且摘要匹配
+func verifyEntries(files []*zip.File, manifest []byte) error {
+	entries := make(map[string]map[string]string)
+	for _, section := range entrySections(manifest) {
+		entries[section.attrs["Name"]] = section.attrs
+	}
+
+	for _, file := range files {
+		if file.FileInfo().IsDir() || isSignatureRelated(file.Name) {
+			continue
+		}
+
+		attrs, ok := entries[file.Name]
+		if !ok {
+			return fmt.Errorf("unsigned entry: %s", file.Name)
+		}
+
+		_, expected, hash, ok := findDigest(attrs, "-Digest")
+		if !ok {
+			return fmt.Errorf("no supported digest for entry %s", file.Name)
+		}
+
+		data, err := readZipFile(file)
+		if err != nil {
+			return err
+		}
+		if !digestMatches(hash, data, expected) {
+			return fmt.Errorf("digest mismatch for entry %s", file.Name)
+		}
+	}
+
+	return nil
+}
+
+// parseSections 将 MANIFEST/.SF 内容拆分为段，保留每段的原始字节
+func parseSections(data []byte) []manifestSection {
+	var sections []manifestSection
+
+	for len(data) > 0 {
+		// 查找段结束位置（空行）
+		end := len(data)
+		for i := 0; i < len(data); {
+			lineEnd := bytes.IndexByte(data[i:], '\n')
+			if lineEnd < 0 {
+				break
+			}
+			line := bytes.TrimRight(data[i:i+lineEnd], "\r")
+			i += lineEnd + 1
+			if len(line) == 0 {
+				end = i
+				break
+			}
+		}
+
+		raw := data[:end]
+		data = data[end:]
+
+		attrs, _ := parseManifest(bytes.NewReader(raw))
+		if len(attrs) == 0 {
+			continue
+		}
+		sections = append(sections, manifestSection{attrs: attrs, raw: raw})
+	}
+
+	return sections
+}
+
+// entrySections 返回除主段外的条目段
+func entrySections(data []byte) []manifestSection {
+	sections := parseSections(data)
+	if len(sections) <= 1 {
+		return nil
+	}
+	return sections[1:]
+}
+
+// findDigest 按算法强度查找形如 SHA-256<suffix> 的摘要属性
+func findDigest(attrs map[string]string, suffix string) (string, string, crypto.Hash, bool) {
+	for i := len(digestAlgorithms) - 1; i >= 0; i-- {
+		algo := digestAlgorithms[i]
+		if v, ok := attrs[algo.name+suffix]; ok {
+			return algo.name, v, algo.hash, true
+		}
+	}
+	return "", "", 0, false
+}
+
+// digestMatches 检查数据摘要是否与 Base64 编码的期望值一致
+func digestMatches(hash crypto.Hash, data []byte, expected string) bool {
+	want, err := base64.StdEncoding.DecodeString(strings.TrimSpace(expected))
+	if err != nil {
+		return false
+	}
+	h := hash.New()
+	h.Write(data)
+	return bytes.Equal(h.Sum(nil), want)
+}
+
+// messageDigest 从签名属性中提取 messageDigest
+func messageDigest(attrsBytes []byte) ([]byte, error) {
+	rest := attrsBytes
+	for len(rest) > 0 {
+		var attr attribute
+		var err error
+		rest, err = asn1.Unmarshal(rest, &attr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid signed attributes: %w", err)
+		}
+		if !attr.Type.Equal(oidMessageDigest) {
+			continue
+		}
+		var digest []byte
+		if _, err := asn1.Unmarshal(attr.Values.Bytes, &digest); err != nil {
+			return nil, fmt.Errorf("invalid message digest: %w", err)
+		}
+		return digest, nil
+	}
+	return nil, fmt.Errorf("message digest attribute not found")
+}
+
+// signatureAlgorithm 根据证书公钥类型和摘要算法确定签名算法
+func signatureAlgorithm(cert *x509.Certificate, hash crypto.Hash) (x509.SignatureAlgorithm, error) {
+	switch cert.PublicKeyAlgorithm {
+	case x509.RSA:
+		switch hash {
+		case crypto.SHA256:
+			return x509.SHA256WithRSA, nil
+		case crypto.SHA384:
+			return x509.SHA384WithRSA, nil
+		case crypto.SHA512:
+			return x509.SHA512WithRSA, nil
+		}
+	case x509.ECDSA:
+		switch hash {
+		case crypto.SHA256:
+			return x509.ECDSAWithSHA256, nil
+		case crypto.SHA384:
+			return x509.ECDSAWithSHA384, nil
+		case crypto.SHA512:
+			return x509.ECDSAWithSHA512, nil
+		}
+	}
+	return x509.UnknownSignatureAlgorithm, fmt.Errorf("unsupported signature algorithm: %s with %s", cert.PublicKeyAlgorithm, hash)
+}
+
+// hashByOID 根据 OID 查找摘要算法
+func hashByOID(oid asn1.ObjectIdentifier) (crypto.Hash, bool) {
+	for _, algo := range digestAlgorithms {
+		if algo.oid.Equal(oid) {
+			return algo.hash, true
+		}
+	}
+	return 0, false
+}
+
+// findSignerCert 根据签发者和序列号查找签名证书
+func findSignerCert(certs []*x509.Certificate, ias issuerAndSerial) *x509.Certificate {
+	for _, cert := range certs {
+		if cert.SerialNumber.Cmp(ias.SerialNumber) == 0 &&
+			bytes.Equal(cert.RawIssuer, ias.IssuerName.FullBytes) {
+			return cert
+		}
+	}
+	return nil
+}
+
+// isSignatureBlock 判断是否为签名块文件
+func isSignatureBlock(name string) bool {
+	ext := strings.ToUpper(path.Ext(name))
+	return ext == ".RSA" || ext == ".DSA" || ext == ".EC"
+}
+
+// isSignatureRelated 判断是否为签名相关文件（不需要被签名覆盖）
+func isSignatureRelated(name string) bool {
+	dir, base := path.Split(name)
+	if dir != "META-INF/" {
+		return false
+	}
+	upper := strings.ToUpper(base)
+	return upper == "MANIFEST.MF" ||
+		strings.HasSuffix(upper, ".SF") ||
+		isSignatureBlock(upper) ||
+		strings.HasPrefix(upper, "SIG-")
+}
+
+// readZipFile 读取 zip 条目内容
+func readZipFile(file *zip.File) ([]byte, error) {
+	rc, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", file.Name, err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", file.Name, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,310 @@
+package jar
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	oidData          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidRSAEncryption = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+)
+
+// zipEntry 测试 jar 中的一个条目
+type zipEntry struct {
+	name string
+	data []byte
+}
+
+// signer 测试用的签名证书和私钥
+type signer struct {
+	key  *rsa.PrivateKey
+	cert *x509.Certificate
+}
+
+func newSigner(t *testing.T, name string) *signer {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &signer{key: key, cert: cert}
+}
+
+func b64sha256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// buildManifest 生成包含条目摘要的 MANIFEST，返回内容和每个条目段的原始字节
+func buildManifest(entries []zipEntry) ([]byte, []string) {
+	var buf bytes.Buffer
+	buf.WriteString("Manifest-Version: 1.0\r\nPremain-Class: com.example.Agent\r\n\r\n")
+
+	sections := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		section := "Name: " + entry.name + "\r\nSHA-256-Digest: " + b64sha256(entry.data) + "\r\n\r\n"
+		sections = append(sections, section)
+		buf.WriteString(section)
+	}
+	return buf.Bytes(), sections
+}
+
+// buildSignatureFile 生成 .SF，whole 为 true 时包含整个 MANIFEST 的摘要
+func buildSignatureFile(manifest []byte, entries []zipEntry, sections []string, whole bool) []byte {
+	main := manifest[:bytes.Index(manifest, []byte("\r\n\r\n"))+4]
+
+	var buf bytes.Buffer
+	buf.WriteString("Signature-Version: 1.0\r\n")
+	buf.WriteString("SHA-256-Digest-Manifest-Main-Attributes: " + b64sha256(main) + "\r\n")
+	if whole {
+		buf.WriteString("SHA-256-Digest-Manifest: " + b64sha256(manifest) + "\r\n")
+	}
+	buf.WriteString("\r\n")
+
+	for i, entry := range entries {
+		buf.WriteString("Name: " + entry.name + "\r\nSHA-256-Digest: " + b64sha256([]byte(sections[i])) + "\r\n\r\n")
+	}
+	return buf.Bytes()
+}
+
+// buildSignatureBlock 生成对 .SF 签名的 PKCS#7 签名块（不含签名属性）
+func buildSignatureBlock(t *testing.T, s *signer, sf []byte) []byte {
+	t.Helper()
+
+	digest := sha256.Sum256(sf)
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := asn1.Marshal(struct{ ContentType asn1.ObjectIdentifier }{oidData})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sd := signedData{
+		Version:          1,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{{Algorithm: digestAlgorithms[0].oid}},
+		ContentInfo:      asn1.RawValue{FullBytes: content},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: s.cert.Raw},
+		SignerInfos: []signerInfo{{
+			Version: 1,
+			IssuerAndSerialNumber: issuerAndSerial{
+				IssuerName:   asn1.RawValue{FullBytes: s.cert.RawIssuer},
+				SerialNumber: s.cert.SerialNumber,
+			},
+			DigestAlgorithm:           pkix.AlgorithmIdentifier{Algorithm: digestAlgorithms[0].oid},
+			DigestEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidRSAEncryption},
+			EncryptedDigest:           signature,
+		}},
+	}
+	sdBytes, err := asn1.Marshal(sd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block, err := asn1.Marshal(contentInfo{
+		ContentType: oidSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: sdBytes},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return block
+}
+
+// signedEntries 生成签名 jar 的全部条目，whole 为 true 时 .SF 包含整个 MANIFEST 的摘要
+func signedEntries(t *testing.T, s *signer, entries []zipEntry, whole bool) []zipEntry {
+	t.Helper()
+
+	manifest, sections := buildManifest(entries)
+	sf := buildSignatureFile(manifest, entries, sections, whole)
+
+	files := []zipEntry{
+		{name: manifestPath, data: manifest},
+		{name: "META-INF/SIGNER.SF", data: sf},
+		{name: "META-INF/SIGNER.RSA", data: buildSignatureBlock(t, s, sf)},
+	}
+	return append(files, entries...)
+}
+
+func writeJar(t *testing.T, files []zipEntry) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "agent.jar")
+	out, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+
+	w := zip.NewWriter(out)
+	for _, file := range files {
+		fw, err := w.Create(file.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fw.Write(file.data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func testEntries() []zipEntry {
+	return []zipEntry{
+		{name: "com/example/Agent.class", data: []byte("agent class")},
+		{name: "com/example/Helper.class", data: []byte("helper class")},
+	}
+}
+
+// replaceEntry 替换 files 中同名条目的内容
+func replaceEntry(files []zipEntry, name string, data []byte) []zipEntry {
+	out := make([]zipEntry, len(files))
+	copy(out, files)
+	for i := range out {
+		if out[i].name == name {
+			out[i].data = data
+		}
+	}
+	return out
+}
+
+func TestVerifySignature(t *testing.T) {
+	s := newSigner(t, "agent signer")
+	other := newSigner(t, "other signer")
+	trusted := []*x509.Certificate{s.cert}
+
+	extra := zipEntry{name: "com/example/Evil.class", data: []byte("evil class")}
+
+	tests := []struct {
+		name    string
+		files   func() []zipEntry
+		wantErr string
+	}{
+		{
+			name:  "signed with manifest digest",
+			files: func() []zipEntry { return signedEntries(t, s, testEntries(), true) },
+		},
+		{
+			name:  "signed with section digests",
+			files: func() []zipEntry { return signedEntries(t, s, testEntries(), false) },
+		},
+		{
+			name: "tampered entry",
+			files: func() []zipEntry {
+				return replaceEntry(signedEntries(t, s, testEntries(), true), "com/example/Agent.class", []byte("tampered"))
+			},
+			wantErr: "digest mismatch for entry com/example/Agent.class",
+		},
+		{
+			name: "added entry",
+			files: func() []zipEntry {
+				return append(signedEntries(t, s, testEntries(), true), extra)
+			},
+			wantErr: "unsigned entry: com/example/Evil.class",
+		},
+		{
+			name: "added entry and manifest section with manifest digest",
+			files: func() []zipEntry {
+				files := signedEntries(t, s, testEntries(), true)
+				manifest, _ := buildManifest(append(testEntries(), extra))
+				return append(replaceEntry(files, manifestPath, manifest), extra)
+			},
+			wantErr: "Digest-Manifest mismatch",
+		},
+		{
+			name: "added entry and manifest section with section digests",
+			files: func() []zipEntry {
+				files := signedEntries(t, s, testEntries(), false)
+				manifest, _ := buildManifest(append(testEntries(), extra))
+				return append(replaceEntry(files, manifestPath, manifest), extra)
+			},
+			wantErr: "manifest section com/example/Evil.class is not covered by signature file",
+		},
+		{
+			name: "tampered main attributes with section digests",
+			files: func() []zipEntry {
+				files := signedEntries(t, s, testEntries(), false)
+				manifest, _ := buildManifest(testEntries())
+				manifest = bytes.Replace(manifest, []byte("com.example.Agent"), []byte("com.example.Evil"), 1)
+				return replaceEntry(files, manifestPath, manifest)
+			},
+			wantErr: "Digest-Manifest-Main-Attributes mismatch",
+		},
+		{
+			name: "unsigned jar",
+			files: func() []zipEntry {
+				manifest, _ := buildManifest(testEntries())
+				return append([]zipEntry{{name: manifestPath, data: manifest}}, testEntries()...)
+			},
+			wantErr: "jar is not signed",
+		},
+		{
+			name:    "untrusted signer",
+			files:   func() []zipEntry { return signedEntries(t, other, testEntries(), true) },
+			wantErr: "is not trusted",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeJar(t, tt.files())
+
+			err := VerifySignature(path, trusted)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("VerifySignature() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("VerifySignature() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReaderSHA256(t *testing.T) {
+	got, err := ReaderSHA256(strings.NewReader("abc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"
+	if got != want {
+		t.Fatalf("ReaderSHA256() = %s, want %s", got, want)
+	}
+}
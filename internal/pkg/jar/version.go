@@ -0,0 +1,152 @@
+package jar
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CompareVersions 比较两个版本号
+// 返回 -1 (a < b)、0 (a == b)、1 (a > b)
+func CompareVersions(a, b string) int {
+	pa := splitVersion(a)
+	pb := splitVersion(b)
+
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var sa, sb string
+		if i < len(pa) {
+			sa = pa[i]
+		}
+		if i < len(pb) {
+			sb = pb[i]
+		}
+		if c := compareSegment(sa, sb); c != 0 {
+			return c
+		}
+	}
+
+	return 0
+}
+
+// splitVersion 拆分版本号（., -, _ 和 + 作为分隔符）
+func splitVersion(v string) []string {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	return strings.FieldsFunc(v, func(r rune) bool {
+		return r == '.' || r == '-' || r == '_' || r == '+'
+	})
+}
+
+// compareSegment 比较单个版本段：数字按数值比较，否则按字符串比较
+// 缺失的段视为 0；非数字段（如 SNAPSHOT、RC1）低于同位置的数字段
+func compareSegment(a, b string) int {
+	if a == "" {
+		a = "0"
+	}
+	if b == "" {
+		b = "0"
+	}
+
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+
+	switch {
+	case errA == nil && errB == nil:
+		switch {
+		case na < nb:
+			return -1
+		case na > nb:
+			return 1
+		}
+		return 0
+	case errA == nil:
+		return 1
+	case errB == nil:
+		return -1
+	}
+
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+// MatchConstraint 检查 Java 版本是否满足约束
+// 约束由逗号分隔的条件组成（全部满足才匹配），条件形如 >=11、<17、!=9；
+// 不带运算符时按前缀匹配（17 匹配 17.0.2）。1.8 形式的旧版本号按 8 处理
+func MatchConstraint(version string, constraint string) (bool, error) {
+	version = normalizeJavaVersion(version)
+
+	for _, term := range strings.Split(constraint, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+
+		op, value := splitOperator(term)
+		if value == "" {
+			return false, fmt.Errorf("invalid version constraint: %q", term)
+		}
+		value = normalizeJavaVersion(value)
+
+		var ok bool
+		switch op {
+		case ">=":
+			ok = CompareVersions(version, value) >= 0
+		case "<=":
+			ok = CompareVersions(version, value) <= 0
+		case ">":
+			ok = CompareVersions(version, value) > 0
+		case "<":
+			ok = CompareVersions(version, value) < 0
+		case "=", "==":
+			ok = CompareVersions(version, value) == 0
+		case "!=":
+			ok = CompareVersions(version, value) != 0
+		default:
+			ok = hasVersionPrefix(version, value)
+		}
+
+		if !ok {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// ValidateConstraint 验证版本约束语法
+func ValidateConstraint(constraint string) error {
+	_, err := MatchConstraint("0", constraint)
+	return err
+}
+
+// splitOperator 拆分条件中的运算符和版本号
+func splitOperator(term string) (string, string) {
+	for _, op := range []string{">=", "<=", "==", "!=", ">", "<", "="} {
+		if strings.HasPrefix(term, op) {
+			return op, strings.TrimSpace(term[len(op):])
+		}
+	}
+	return "", term
+}
+
+// hasVersionPrefix 检查版本号是否以指定的版本段开头
+func hasVersionPrefix(version, prefix string) bool {
+	pv := splitVersion(version)
+	pp := splitVersion(prefix)
+	if len(pp) > len(pv) {
+		return false
+	}
+	for i := range pp {
+		if compareSegment(pv[i], pp[i]) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeJavaVersion 将 1.8.0_292 形式的旧版本号转换为 8.0.292
+func normalizeJavaVersion(version string) string {
+	version = strings.TrimSpace(version)
+	if strings.HasPrefix(version, "1.") {
+		return version[2:]
+	}
+	return version
+}
@@ -0,0 +1,272 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Template kubectl 风格的 JSONPath 模板，如 {.pid}{"\t"}{.agents[*].path}
+// 花括号外的文本原样输出（支持 \t、\n 转义），花括号内为路径表达式或带引号的字符串
+type Template struct {
+	segments []segment
+}
+
+// segment 模板片段：字面文本或路径
+type segment struct {
+	text string
+	path []step // 为 nil 时输出 text
+}
+
+// step 路径中的一步：字段名、数组下标或通配
+type step struct {
+	field string
+	index int
+	all   bool // [*] 或 .*
+}
+
+// Parse 解析模板
+func Parse(template string) (*Template, error) {
+	t := &Template{}
+
+	rest := template
+	for rest != "" {
+		open := strings.IndexByte(rest, '{')
+		if open < 0 {
+			t.segments = append(t.segments, segment{text: unescape(rest)})
+			break
+		}
+		if open > 0 {
+			t.segments = append(t.segments, segment{text: unescape(rest[:open])})
+		}
+
+		end := closingBrace(rest[open:])
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed expression in %q", template)
+		}
+		expr := strings.TrimSpace(rest[open+1 : open+end])
+		rest = rest[open+end+1:]
+
+		if strings.HasPrefix(expr, `"`) || strings.HasPrefix(expr, "'") {
+			text, err := unquote(expr)
+			if err != nil {
+				return nil, err
+			}
+			t.segments = append(t.segments, segment{text: text})
+			continue
+		}
+
+		path, err := parsePath(expr)
+		if err != nil {
+			return nil, err
+		}
+		t.segments = append(t.segments, segment{path: path})
+	}
+
+	return t, nil
+}
+
+// closingBrace 返回与开头的 { 匹配的 } 的位置，引号内的 } 不计入（双引号内支持 \ 转义），未找到时返回 -1
+func closingBrace(s string) int {
+	var quote byte
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '}':
+			return i
+		}
+	}
+	return -1
+}
+
+// parsePath 解析路径表达式：.a.b、.a[0]、.a[*].b、$.a
+func parsePath(expr string) ([]step, error) {
+	s := strings.TrimPrefix(expr, "$")
+	if s == "" {
+		return []step{}, nil
+	}
+	if s[0] != '.' && s[0] != '[' {
+		return nil, fmt.Errorf("invalid expression %q: must start with '.'", expr)
+	}
+
+	path := []step{}
+	for s != "" {
+		switch s[0] {
+		case '.':
+			s = s[1:]
+			if s == "" {
+				// 单独的 . 表示当前对象
+				return path, nil
+			}
+			if s[0] == '*' {
+				path = append(path, step{all: true})
+				s = s[1:]
+				continue
+			}
+			n := 0
+			for n < len(s) && s[n] != '.' && s[n] != '[' {
+				n++
+			}
+			if n == 0 {
+				return nil, fmt.Errorf("invalid expression %q: empty field name", expr)
+			}
+			path = append(path, step{field: s[:n]})
+			s = s[n:]
+
+		case '[':
+			end := strings.IndexByte(s, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid expression %q: unclosed '['", expr)
+			}
+			inner := strings.TrimSpace(s[1:end])
+			s = s[end+1:]
+
+			if inner == "*" {
+				path = append(path, step{all: true})
+				continue
+			}
+			if text, err := unquote(inner); err == nil {
+				path = append(path, step{field: text})
+				continue
+			}
+			index, err := strconv.Atoi(inner)
+			if err != nil {
+				return nil, fmt.Errorf("invalid expression %q: invalid index %q", expr, inner)
+			}
+			path = append(path, step{index: index})
+
+		default:
+			return nil, fmt.Errorf("invalid expression %q: unexpected %q", expr, s[0])
+		}
+	}
+
+	return path, nil
+}
+
+// Execute 对数据执行模板，data 会先转换为 JSON 结构，字段名与 JSON 一致
+// 路径匹配多个值时以空格分隔，路径不存在时输出为空
+func (t *Template) Execute(w io.Writer, data interface{}) error {
+	value, err := toJSONValue(data)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	for _, seg := range t.segments {
+		if seg.path == nil {
+			b.WriteString(seg.text)
+			continue
+		}
+
+		results := evaluate([]interface{}{value}, seg.path)
+		for i, result := range results {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteString(format(result))
+		}
+	}
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// evaluate 逐步求值，返回所有匹配的值
+func evaluate(values []interface{}, path []step) []interface{} {
+	for _, st := range path {
+		var next []interface{}
+		for _, value := range values {
+			switch v := value.(type) {
+			case map[string]interface{}:
+				if st.all {
+					keys := make([]string, 0, len(v))
+					for key := range v {
+						keys = append(keys, key)
+					}
+					sort.Strings(keys)
+					for _, key := range keys {
+						next = append(next, v[key])
+					}
+				} else if item, ok := v[st.field]; ok && st.field != "" {
+					next = append(next, item)
+				}
+			case []interface{}:
+				switch {
+				case st.all:
+					next = append(next, v...)
+				case st.field == "":
+					index := st.index
+					if index < 0 {
+						index += len(v)
+					}
+					if index >= 0 && index < len(v) {
+						next = append(next, v[index])
+					}
+				}
+			}
+		}
+		values = next
+	}
+	return values
+}
+
+// toJSONValue 将任意值转换为 JSON 解码后的通用结构
+func toJSONValue(data interface{}) (interface{}, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal data: %w", err)
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal data: %w", err)
+	}
+	return value, nil
+}
+
+// format 格式化单个值：字符串和数字原样输出，对象和数组输出 JSON
+func format(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(raw)
+	}
+}
+
+// unquote 解析带单引号或双引号的字符串
+func unquote(s string) (string, error) {
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return unescape(s[1 : len(s)-1]), nil
+	}
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return strconv.Unquote(s)
+	}
+	return "", fmt.Errorf("invalid string literal %s", s)
+}
+
+// unescape 处理字面文本中的 \t、\n 转义（命令行参数中通常无法直接输入制表符）
+func unescape(s string) string {
+	return strings.NewReplacer(`\t`, "\t", `\n`, "\n").Replace(s)
+}
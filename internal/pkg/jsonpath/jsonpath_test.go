@@ -0,0 +1,114 @@
+package jsonpath
+
+import (
+	"strings"
+	"testing"
+)
+
+// testProcess 模拟 list 的进程记录
+type testProcess struct {
+	PID    int               `json:"pid"`
+	Name   string            `json:"name"`
+	CPU    float64           `json:"cpu"`
+	Secure bool              `json:"secure"`
+	Agents []testAgent       `json:"agents"`
+	Labels map[string]string `json:"labels"`
+	Ports  []int             `json:"ports"`
+	Parent *testProcess      `json:"parent"`
+}
+
+type testAgent struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+}
+
+func testData() *testProcess {
+	return &testProcess{
+		PID:    1234,
+		Name:   "order-service",
+		CPU:    12.5,
+		Secure: true,
+		Agents: []testAgent{
+			{Path: "/opt/agents/SecPoint.jar", Version: "1.2.0"},
+			{Path: "/opt/agents/apm.jar", Version: "3.0"},
+		},
+		Labels: map[string]string{"team": "payments", "env": "prod", "app.kubernetes.io/name": "order"},
+		Ports:  []int{8080, 8443},
+	}
+}
+
+func TestExecute(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{"field", "{.pid}", "1234"},
+		{"root prefix", "{$.name}", "order-service"},
+		{"float", "{.cpu}", "12.5"},
+		{"bool", "{.secure}", "true"},
+		{"literal text and escapes", `pid={.pid}\tname={.name}\n`, "pid=1234\tname=order-service\n"},
+		{"quoted literal", `{.pid}{"\t"}{.name}`, "1234\torder-service"},
+		{"single quoted literal", `{.pid}{', '}{.name}`, "1234, order-service"},
+		{"literal with closing brace", `{"}"}`, "}"},
+		{"literal with braces and escaped quote", `{"{\"}\"}"}{.pid}`, `{"}"}1234`},
+		{"single quoted literal with brace", `{'}'}{.pid}`, "}1234"},
+		{"index", "{.agents[0].path}", "/opt/agents/SecPoint.jar"},
+		{"negative index", "{.agents[-1].version}", "3.0"},
+		{"index out of range", "{.agents[5].path}", ""},
+		{"wildcard", "{.agents[*].version}", "1.2.0 3.0"},
+		{"dot wildcard", "{.agents.*}", `{"path":"/opt/agents/SecPoint.jar","version":"1.2.0"} {"path":"/opt/agents/apm.jar","version":"3.0"}`},
+		{"map wildcard sorted by key", "{.labels.*}", "order prod payments"},
+		{"quoted field", "{.labels['app.kubernetes.io/name']}", "order"},
+		{"double quoted field", `{.labels["team"]}`, "payments"},
+		{"quoted field with brace", `{.labels['}']}`, ""},
+		{"array as json", "{.ports}", "[8080,8443]"},
+		{"missing field", "{.missing.value}", ""},
+		{"null", "{.parent}", ""},
+		{"array wildcard", "{.ports[*]}", "8080 8443"},
+		{"no expressions", "plain", "plain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := Parse(tt.template)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.template, err)
+			}
+			var b strings.Builder
+			if err := tmpl.Execute(&b, testData()); err != nil {
+				t.Fatal(err)
+			}
+			if got := b.String(); got != tt.want {
+				t.Fatalf("Execute(%q) = %q, want %q", tt.template, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		template string
+		wantErr  string
+	}{
+		{"{.pid", "unclosed expression"},
+		{`{"}`, "unclosed expression"},
+		{`{'}`, "unclosed expression"},
+		{"{pid}", "must start with '.'"},
+		{"{.a..b}", "empty field name"},
+		{"{.a[0}", "unclosed '['"},
+		{"{.a[x]}", "invalid index"},
+		{"{.a[0]x}", "unexpected"},
+		{`{"abc}`, "unclosed expression"},
+		{`{"a"b}`, "invalid string literal"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			_, err := Parse(tt.template)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Parse(%q) error = %v, want %q", tt.template, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package jvmargs
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// valueFlags 值作为下一个参数的 JVM 选项
+var valueFlags = map[string]bool{
+	"-cp":                   true,
+	"-classpath":            true,
+	"--class-path":          true,
+	"-p":                    true,
+	"--module-path":         true,
+	"--upgrade-module-path": true,
+	"--add-modules":         true,
+	"--add-opens":           true,
+	"--add-exports":         true,
+	"--add-reads":           true,
+	"--patch-module":        true,
+	"--limit-modules":       true,
+}
+
+// FindJava 查找 java 命令的位置，找不到时返回 -1
+func FindJava(cmdLine []string) int {
+	for i, arg := range cmdLine {
+		if strings.Contains(filepath.Base(arg), "java") {
+			return i
+		}
+	}
+	return -1
+}
+
+// OptionsRange 返回 JVM 选项在命令行中的范围 [start, end)，end 为主类、-jar 或 -m 的位置
+// 找不到 java 命令时从第一个参数之后开始
+func OptionsRange(cmdLine []string) (int, int) {
+	start := max(FindJava(cmdLine), 0) + 1
+
+	for i := start; i < len(cmdLine); i++ {
+		arg := cmdLine[i]
+		switch {
+		case arg == "-jar" || arg == "-m" || arg == "--module":
+			return start, i
+		case valueFlags[arg]:
+			i++
+		case !strings.HasPrefix(arg, "-"):
+			return start, i
+		}
+	}
+
+	return start, len(cmdLine)
+}
+
+// AppArgsStart 返回应用程序参数（主类或 -jar 文件之后的参数）的起始位置
+func AppArgsStart(cmdLine []string) int {
+	_, end := OptionsRange(cmdLine)
+	if end >= len(cmdLine) {
+		return len(cmdLine)
+	}
+
+	switch cmdLine[end] {
+	case "-jar", "-m", "--module":
+		return min(end+2, len(cmdLine))
+	default:
+		return end + 1
+	}
+}
@@ -0,0 +1,139 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// field 展开后的日志字段
+type field struct {
+	key   string
+	value string
+}
+
+// structuredCore 将每条日志连同字段交给 send 的 core，用于 journald 和 syslog
+// 与 encoder 不同，字段保持独立以便映射为结构化字段
+type structuredCore struct {
+	zapcore.LevelEnabler
+	fields []zapcore.Field
+	send   func(ent zapcore.Entry, fields []field) error
+	sync   func() error
+}
+
+// With 添加上下文字段
+func (c *structuredCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.fields = append(append([]zapcore.Field(nil), c.fields...), fields...)
+	return &clone
+}
+
+// Check 判断是否需要记录
+func (c *structuredCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write 记录一条日志
+func (c *structuredCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	all := fields
+	if len(c.fields) > 0 {
+		all = append(append([]zapcore.Field(nil), c.fields...), fields...)
+	}
+	return c.send(ent, flattenFields(all))
+}
+
+// Sync 刷新
+func (c *structuredCore) Sync() error {
+	if c.sync == nil {
+		return nil
+	}
+	return c.sync()
+}
+
+// flattenFields 将 zap 字段展开为 键 -> 字符串值，嵌套对象以 . 连接，按键排序
+func flattenFields(fields []zapcore.Field) []field {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+
+	var out []field
+	flattenValue(&out, "", enc.Fields)
+
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].key < out[j].key
+	})
+	return out
+}
+
+// flattenValue 递归展开嵌套对象，数组以 JSON 表示
+func flattenValue(out *[]field, key string, value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, nested := range v {
+			if key != "" {
+				k = key + "." + k
+			}
+			flattenValue(out, k, nested)
+		}
+	case string:
+		*out = append(*out, field{key: key, value: v})
+	case fmt.Stringer:
+		*out = append(*out, field{key: key, value: v.String()})
+	case error:
+		*out = append(*out, field{key: key, value: v.Error()})
+	case []interface{}:
+		data, err := json.Marshal(v)
+		if err != nil {
+			data = []byte(fmt.Sprint(v))
+		}
+		*out = append(*out, field{key: key, value: string(data)})
+	default:
+		*out = append(*out, field{key: key, value: fmt.Sprint(v)})
+	}
+}
+
+// priority 返回日志级别对应的 syslog 严重级别（journald PRIORITY 使用相同取值）
+func priority(level zapcore.Level) int {
+	switch level {
+	case zapcore.DebugLevel:
+		return 7
+	case zapcore.InfoLevel:
+		return 6
+	case zapcore.WarnLevel:
+		return 4
+	case zapcore.ErrorLevel:
+		return 3
+	case zapcore.DPanicLevel:
+		return 2
+	case zapcore.PanicLevel:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// fieldName 将字段名转换为大写字母、数字和下划线组成的名称（journald 字段名格式）
+func fieldName(key string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(key) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+
+	// 不能以下划线或数字开头（下划线开头的字段由 journald 填充，不允许客户端写入）
+	name := strings.TrimLeft(b.String(), "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "F_" + name
+	}
+	return name
+}
@@ -0,0 +1,131 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// journalSocket journald 原生协议 socket
+const journalSocket = "/run/systemd/journal/socket"
+
+// journalTargetFields 同时写入 TARGET_PID 的字段（操作的目标进程，区别于 journald 填充的 _PID）
+var journalTargetFields = map[string]string{
+	"pid":     "TARGET_PID",
+	"old_pid": "TARGET_PID",
+	"new_pid": "TARGET_NEW_PID",
+}
+
+// journalWriter 通过 journald 原生协议发送日志
+type journalWriter struct {
+	conn       *net.UnixConn
+	addr       *net.UnixAddr
+	identifier string
+}
+
+// newJournalCore 创建写入 journald 的 core
+// 字段映射为大写的 journal 字段，如 pid -> PID（并写入 TARGET_PID）、agent -> AGENT
+func newJournalCore(enab zapcore.LevelEnabler) (zapcore.Core, error) {
+	if _, err := os.Stat(journalSocket); err != nil {
+		return nil, fmt.Errorf("journald is not available: %w", err)
+	}
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create journald socket: %w", err)
+	}
+
+	w := &journalWriter{
+		conn:       conn,
+		addr:       &net.UnixAddr{Name: journalSocket, Net: "unixgram"},
+		identifier: filepath.Base(os.Args[0]),
+	}
+
+	return &structuredCore{LevelEnabler: enab, send: w.send}, nil
+}
+
+// send 发送一条日志
+func (w *journalWriter) send(ent zapcore.Entry, fields []field) error {
+	var buf bytes.Buffer
+
+	writeJournalField(&buf, "MESSAGE", ent.Message)
+	writeJournalField(&buf, "PRIORITY", strconv.Itoa(priority(ent.Level)))
+	writeJournalField(&buf, "SYSLOG_IDENTIFIER", w.identifier)
+	if ent.LoggerName != "" {
+		writeJournalField(&buf, "LOGGER", ent.LoggerName)
+	}
+	if ent.Caller.Defined {
+		writeJournalField(&buf, "CODE_FILE", ent.Caller.File)
+		writeJournalField(&buf, "CODE_LINE", strconv.Itoa(ent.Caller.Line))
+		if ent.Caller.Function != "" {
+			writeJournalField(&buf, "CODE_FUNC", ent.Caller.Function)
+		}
+	}
+	if ent.Stack != "" {
+		writeJournalField(&buf, "STACKTRACE", ent.Stack)
+	}
+
+	for _, f := range fields {
+		writeJournalField(&buf, fieldName(f.key), f.value)
+		if target, ok := journalTargetFields[f.key]; ok {
+			writeJournalField(&buf, target, f.value)
+		}
+	}
+
+	_, _, err := w.conn.WriteMsgUnix(buf.Bytes(), nil, w.addr)
+	if err == nil {
+		return nil
+	}
+
+	// 超过 datagram 大小限制时通过文件描述符传递（journald 原生协议支持）
+	if errors.Is(err, syscall.EMSGSIZE) || errors.Is(err, syscall.ENOBUFS) {
+		return w.sendFile(buf.Bytes())
+	}
+	return fmt.Errorf("failed to write to journald: %w", err)
+}
+
+// sendFile 将日志写入已删除的临时文件并传递其文件描述符
+func (w *journalWriter) sendFile(data []byte) error {
+	file, err := os.CreateTemp("/dev/shm", "iast-journal-")
+	if err != nil {
+		return fmt.Errorf("failed to create journald payload: %w", err)
+	}
+	defer file.Close()
+	os.Remove(file.Name())
+
+	if _, err := file.Write(data); err != nil {
+		return fmt.Errorf("failed to write journald payload: %w", err)
+	}
+
+	rights := syscall.UnixRights(int(file.Fd()))
+	if _, _, err := w.conn.WriteMsgUnix(nil, rights, w.addr); err != nil {
+		return fmt.Errorf("failed to write to journald: %w", err)
+	}
+	return nil
+}
+
+// writeJournalField 按原生协议写入字段：KEY=value，值包含换行时使用 KEY\n<64 位小端长度><value>
+func writeJournalField(buf *bytes.Buffer, name, value string) {
+	if !strings.ContainsRune(value, '\n') {
+		buf.WriteString(name)
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+		return
+	}
+
+	buf.WriteString(name)
+	buf.WriteByte('\n')
+	binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
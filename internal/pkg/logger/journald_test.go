@@ -0,0 +1,119 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// parseJournalMessage 按 journald 原生协议解析一条消息
+func parseJournalMessage(t *testing.T, data []byte) map[string][]string {
+	t.Helper()
+
+	fields := make(map[string][]string)
+	for len(data) > 0 {
+		end := bytes.IndexByte(data, '\n')
+		if end < 0 {
+			t.Fatalf("truncated field: %q", data)
+		}
+		line := data[:end]
+		data = data[end+1:]
+
+		if name, value, ok := bytes.Cut(line, []byte("=")); ok {
+			fields[string(name)] = append(fields[string(name)], string(value))
+			continue
+		}
+
+		// KEY\n<64 位小端长度><value>\n
+		if len(data) < 8 {
+			t.Fatalf("truncated binary field %s", line)
+		}
+		size := binary.LittleEndian.Uint64(data[:8])
+		data = data[8:]
+		if uint64(len(data)) < size+1 || data[size] != '\n' {
+			t.Fatalf("invalid binary field %s", line)
+		}
+		fields[string(line)] = append(fields[string(line)], string(data[:size]))
+		data = data[size+1:]
+	}
+	return fields
+}
+
+func TestJournalWriter(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "journal.socket")
+	server, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	client, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	w := &journalWriter{
+		conn:       client,
+		addr:       &net.UnixAddr{Name: socket, Net: "unixgram"},
+		identifier: "iast-auto-inject",
+	}
+	core := &structuredCore{LevelEnabler: zapcore.DebugLevel, send: w.send}
+
+	zap.New(core).Named("daemon").Error("Restart failed",
+		zap.Int("pid", 42),
+		zap.Int("new_pid", 43),
+		zap.String("agent", "SecPoint"),
+		zap.String("error", "line one\nline two"),
+		zap.Namespace("_internal"),
+		zap.String("x", "y"))
+
+	buf := make([]byte, 64*1024)
+	server.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, err := server.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := parseJournalMessage(t, buf[:n])
+
+	want := map[string]string{
+		"MESSAGE":           "Restart failed",
+		"PRIORITY":          "3",
+		"SYSLOG_IDENTIFIER": "iast-auto-inject",
+		"LOGGER":            "daemon",
+		"PID":               "42",
+		"TARGET_PID":        "42",
+		"NEW_PID":           "43",
+		"TARGET_NEW_PID":    "43",
+		"AGENT":             "SecPoint",
+		"ERROR":             "line one\nline two",
+		"INTERNAL_X":        "y",
+	}
+	for name, value := range want {
+		if got := fields[name]; len(got) != 1 || got[0] != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+}
+
+func TestFieldName(t *testing.T) {
+	tests := map[string]string{
+		"pid":        "PID",
+		"new-pid":    "NEW_PID",
+		"agent.name": "AGENT_NAME",
+		"_source":    "SOURCE",
+		"1st":        "F_1ST",
+		"__":         "F_",
+	}
+	for key, want := range tests {
+		if got := fieldName(key); got != want {
+			t.Errorf("fieldName(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package logger
 import (
 	"fmt"
 	"os"
+	"strings"
 	"sync"
 
 	"go.uber.org/zap"
@@ -11,30 +12,90 @@ import (
 
 var (
 	globalLogger *zap.Logger
+	callerLogger *zap.Logger // 跳过包级函数一层调用，记录实际的调用位置（journald CODE_FILE 等）
 	once         sync.Once
+
+	// level 所有输出共享的日志级别，可在运行时修改
+	level = zap.NewAtomicLevel()
 )
 
+// Options 日志配置
+type Options struct {
+	Level    string
+	Format   string   // console, json
+	Outputs  []string // 同时写入的输出：stdout、stderr、journald、syslog://host:port 或文件路径
+	Rotation Rotation // 文件输出的轮转配置
+}
+
 // Init 初始化全局日志
-func Init(level string, format string, output string) error {
+func Init(opts Options) error {
 	var err error
 	once.Do(func() {
-		err = initLogger(level, format, output)
+		err = initLogger(opts)
 	})
 	return err
 }
 
 // initLogger 初始化日志
-func initLogger(level string, format string, output string) error {
+func initLogger(opts Options) error {
 	// 解析日志级别
-	var zapLevel zapcore.Level
-	if err := zapLevel.UnmarshalText([]byte(level)); err != nil {
-		return fmt.Errorf("invalid log level: %s", level)
+	if err := SetLevel(opts.Level); err != nil {
+		return err
 	}
 
+	outputs := opts.Outputs
+	if len(outputs) == 0 {
+		outputs = []string{"stdout"}
+	}
+
+	// 每个输出一个 core，共享日志级别
+	cores := make([]zapcore.Core, 0, len(outputs))
+	for _, output := range outputs {
+		core, err := newCore(output, opts)
+		if err != nil {
+			return err
+		}
+		cores = append(cores, newRedactCore(core))
+	}
+
+	// 创建 logger
+	globalLogger = zap.New(zapcore.NewTee(cores...), zap.AddCaller(), zap.AddStacktrace(zapcore.ErrorLevel))
+	callerLogger = globalLogger.WithOptions(zap.AddCallerSkip(1))
+
+	return nil
+}
+
+// newCore 创建单个输出的 core
+// 终端输出使用彩色级别，文件输出不包含颜色控制字符；journald 和 syslog 输出保留结构化字段
+func newCore(output string, opts Options) (zapcore.Core, error) {
+	var writer zapcore.WriteSyncer
+	terminal := false
+
+	switch {
+	case output == "" || output == "stdout":
+		writer, terminal = zapcore.AddSync(os.Stdout), true
+	case output == "stderr":
+		writer, terminal = zapcore.AddSync(os.Stderr), true
+	case output == "journald":
+		return newJournalCore(level)
+	case IsSyslogOutput(output):
+		return newSyslogCore(output, level)
+	default:
+		file, err := openRotatingFile(output, opts.Rotation)
+		if err != nil {
+			return nil, err
+		}
+		writer = file
+	}
+
+	return zapcore.NewCore(newEncoder(opts.Format, terminal), writer, level), nil
+}
+
+// newEncoder 创建 encoder
+func newEncoder(format string, terminal bool) zapcore.Encoder {
 	// 配置 encoder
-	var encoderConfig zapcore.EncoderConfig
 	if format == "json" {
-		encoderConfig = zapcore.EncoderConfig{
+		return zapcore.NewJSONEncoder(zapcore.EncoderConfig{
 			TimeKey:        "time",
 			LevelKey:       "level",
 			NameKey:        "logger",
@@ -46,64 +107,60 @@ func initLogger(level string, format string, output string) error {
 			EncodeTime:     zapcore.ISO8601TimeEncoder,
 			EncodeDuration: zapcore.SecondsDurationEncoder,
 			EncodeCaller:   zapcore.ShortCallerEncoder,
-		}
-	} else {
-		encoderConfig = zapcore.EncoderConfig{
-			TimeKey:        "time",
-			LevelKey:       "level",
-			NameKey:        "logger",
-			CallerKey:      "caller",
-			FunctionKey:    zapcore.OmitKey,
-			MessageKey:     "msg",
-			StacktraceKey:  "stacktrace",
-			LineEnding:     zapcore.DefaultLineEnding,
-			EncodeLevel:    zapcore.CapitalColorLevelEncoder,
-			EncodeTime:     zapcore.ISO8601TimeEncoder,
-			EncodeDuration: zapcore.StringDurationEncoder,
-			EncodeCaller:   zapcore.ShortCallerEncoder,
-		}
-	}
-
-	// 配置输出
-	var writer zapcore.WriteSyncer
-	if output == "" || output == "stdout" {
-		writer = zapcore.AddSync(os.Stdout)
-	} else if output == "stderr" {
-		writer = zapcore.AddSync(os.Stderr)
-	} else {
-		file, err := os.OpenFile(output, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
-		if err != nil {
-			return fmt.Errorf("failed to open log file: %w", err)
-		}
-		writer = zapcore.AddSync(file)
+		})
 	}
 
-	// 创建 encoder
-	var encoder zapcore.Encoder
-	if format == "json" {
-		encoder = zapcore.NewJSONEncoder(encoderConfig)
-	} else {
-		encoder = zapcore.NewConsoleEncoder(encoderConfig)
+	encodeLevel := zapcore.CapitalLevelEncoder
+	if terminal {
+		encodeLevel = zapcore.CapitalColorLevelEncoder
 	}
 
-	// 创建 core
-	core := zapcore.NewCore(encoder, writer, zapLevel)
-
-	// 创建 logger
-	globalLogger = zap.New(core, zap.AddCaller(), zap.AddStacktrace(zapcore.ErrorLevel))
+	return zapcore.NewConsoleEncoder(zapcore.EncoderConfig{
+		TimeKey:        "time",
+		LevelKey:       "level",
+		NameKey:        "logger",
+		CallerKey:      "caller",
+		FunctionKey:    zapcore.OmitKey,
+		MessageKey:     "msg",
+		StacktraceKey:  "stacktrace",
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeLevel:    encodeLevel,
+		EncodeTime:     zapcore.ISO8601TimeEncoder,
+		EncodeDuration: zapcore.StringDurationEncoder,
+		EncodeCaller:   zapcore.ShortCallerEncoder,
+	})
+}
 
+// SetLevel 修改日志级别，立即对所有输出生效
+func SetLevel(name string) error {
+	var zapLevel zapcore.Level
+	if err := zapLevel.UnmarshalText([]byte(strings.ToLower(name))); err != nil {
+		return fmt.Errorf("invalid log level: %s", name)
+	}
+	level.SetLevel(zapLevel)
 	return nil
 }
 
+// Level 返回当前日志级别
+func Level() string {
+	return level.Level().String()
+}
+
 // Get 获取全局日志实例
 func Get() *zap.Logger {
 	if globalLogger == nil {
 		// 默认初始化
-		_ = Init("info", "console", "stdout")
+		_ = Init(Options{Level: "info", Format: "console"})
 	}
 	return globalLogger
 }
 
+// caller 返回包级日志函数使用的 logger
+func caller() *zap.Logger {
+	Get()
+	return callerLogger
+}
+
 // Sync 同步日志
 func Sync() error {
 	if globalLogger != nil {
@@ -114,27 +171,27 @@ func Sync() error {
 
 // Debug 调试日志
 func Debug(msg string, fields ...zap.Field) {
-	Get().Debug(msg, fields...)
+	caller().Debug(msg, fields...)
 }
 
 // Info 信息日志
 func Info(msg string, fields ...zap.Field) {
-	Get().Info(msg, fields...)
+	caller().Info(msg, fields...)
 }
 
 // Warn 警告日志
 func Warn(msg string, fields ...zap.Field) {
-	Get().Warn(msg, fields...)
+	caller().Warn(msg, fields...)
 }
 
 // Error 错误日志
 func Error(msg string, fields ...zap.Field) {
-	Get().Error(msg, fields...)
+	caller().Error(msg, fields...)
 }
 
 // Fatal 致命错误日志
 func Fatal(msg string, fields ...zap.Field) {
-	Get().Fatal(msg, fields...)
+	caller().Fatal(msg, fields...)
 }
 
 // With 创建带有预设字段的 logger
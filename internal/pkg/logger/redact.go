@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"iast-auto-inject/internal/pkg/redact"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// redactCore 写入前隐藏消息和字段中的敏感值，使用 redact.Default()，重新加载配置后立即生效
+type redactCore struct {
+	zapcore.Core
+}
+
+// newRedactCore 包装 core，隐藏所有输出中的敏感值
+func newRedactCore(core zapcore.Core) zapcore.Core {
+	return &redactCore{Core: core}
+}
+
+// With 隐藏预设字段后创建子 core
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(redactFields(fields))}
+}
+
+// Check 级别满足时由本 core 写入，保证经过 Write 的隐藏处理
+func (c *redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write 隐藏消息和字段后写入
+func (c *redactCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ent.Message = redact.Default().Text(ent.Message)
+	return c.Core.Write(ent, redactFields(fields))
+}
+
+// redactFields 隐藏字符串和错误字段中的敏感值，字符串列表需调用方使用 redact.Args 处理
+func redactFields(fields []zapcore.Field) []zapcore.Field {
+	r := redact.Default()
+
+	out := make([]zapcore.Field, len(fields))
+	for i, field := range fields {
+		switch field.Type {
+		case zapcore.StringType:
+			field.String = r.Value(field.Key, field.String)
+		case zapcore.ErrorType:
+			if err, ok := field.Interface.(error); ok && err != nil {
+				field = zap.String(field.Key, r.Text(err.Error()))
+			}
+		}
+		out[i] = field
+	}
+	return out
+}
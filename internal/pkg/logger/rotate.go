@@ -0,0 +1,310 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// backupTimeFormat 轮转文件名中的时间格式
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// Rotation 日志文件轮转配置
+type Rotation struct {
+	MaxSize    int  // 单个文件最大大小（MB），0 表示不按大小轮转
+	MaxBackups int  // 保留的旧文件数量，0 表示不限制
+	MaxAge     int  // 旧文件保留天数，0 表示不限制
+	Compress   bool // 使用 gzip 压缩旧文件
+}
+
+// rotatingFile 按大小轮转的日志文件
+// 旧文件命名为 <name>-<time><ext>（压缩后追加 .gz），轮转后清理超出数量或天数的旧文件
+type rotatingFile struct {
+	path     string
+	rotation Rotation
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+
+	cleanup chan struct{} // 通知后台压缩和清理旧文件
+}
+
+// openRotatingFile 打开日志文件（追加写入）
+func openRotatingFile(path string, rotation Rotation) (*rotatingFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	r := &rotatingFile{
+		path:     path,
+		rotation: rotation,
+		cleanup:  make(chan struct{}, 1),
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+
+	go r.cleanupLoop()
+	r.triggerCleanup()
+
+	return r, nil
+}
+
+// open 打开当前日志文件并记录已有大小
+func (r *rotatingFile) open() error {
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	r.file = file
+	r.size = info.Size()
+	return nil
+}
+
+// Write 写入日志，超过大小限制时先轮转
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if max := r.maxBytes(); max > 0 && r.size+int64(len(p)) > max {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Sync 刷新到磁盘
+func (r *rotatingFile) Sync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Sync()
+}
+
+// maxBytes 单个文件的最大字节数
+func (r *rotatingFile) maxBytes() int64 {
+	return int64(r.rotation.MaxSize) * 1024 * 1024
+}
+
+// rotate 将当前文件重命名为旧文件并打开新文件
+// 同一个文件可能被多个进程写入（守护进程和命令行），文件已被其他进程轮转时只重新打开
+func (r *rotatingFile) rotate() error {
+	rotated, err := r.rotatedElsewhere()
+	if err != nil {
+		return err
+	}
+
+	if !rotated {
+		name := backupName(r.path, time.Now())
+		if err := os.Rename(r.path, name); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to rotate log file: %w", err)
+		}
+	}
+
+	r.file.Close()
+	if err := r.open(); err != nil {
+		return err
+	}
+
+	r.triggerCleanup()
+	return nil
+}
+
+// rotatedElsewhere 检查日志路径是否已指向其他文件（被其他进程轮转或删除）
+func (r *rotatingFile) rotatedElsewhere() (bool, error) {
+	current, err := os.Stat(r.path)
+	if os.IsNotExist(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	opened, err := r.file.Stat()
+	if err != nil {
+		return false, fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	return !os.SameFile(current, opened), nil
+}
+
+// triggerCleanup 通知后台处理旧文件（已有待处理请求时合并）
+func (r *rotatingFile) triggerCleanup() {
+	select {
+	case r.cleanup <- struct{}{}:
+	default:
+	}
+}
+
+// cleanupLoop 后台压缩和清理旧文件，避免阻塞日志写入
+func (r *rotatingFile) cleanupLoop() {
+	for range r.cleanup {
+		if err := r.cleanupBackups(); err != nil {
+			fmt.Fprintf(os.Stderr, "log rotation: %v\n", err)
+		}
+	}
+}
+
+// cleanupBackups 删除超出数量或天数的旧文件，并压缩剩余未压缩的旧文件
+func (r *rotatingFile) cleanupBackups() error {
+	backups, err := r.listBackups()
+	if err != nil {
+		return err
+	}
+
+	var cutoff time.Time
+	if r.rotation.MaxAge > 0 {
+		cutoff = time.Now().Add(-time.Duration(r.rotation.MaxAge) * 24 * time.Hour)
+	}
+
+	var keep []backup
+	for i, b := range backups {
+		expired := (r.rotation.MaxBackups > 0 && i >= r.rotation.MaxBackups) ||
+			(!cutoff.IsZero() && b.time.Before(cutoff))
+		if !expired {
+			keep = append(keep, b)
+			continue
+		}
+		if err := os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove old log file: %w", err)
+		}
+	}
+
+	if !r.rotation.Compress {
+		return nil
+	}
+	for _, b := range keep {
+		if strings.HasSuffix(b.path, ".gz") {
+			continue
+		}
+		if err := compressFile(b.path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// backup 轮转产生的旧文件
+type backup struct {
+	path string
+	time time.Time
+}
+
+// listBackups 列出旧文件，按轮转时间从新到旧排列
+func (r *rotatingFile) listBackups() ([]backup, error) {
+	dir := filepath.Dir(r.path)
+	prefix, ext := backupPrefix(r.path)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log directory: %w", err)
+	}
+
+	var backups []backup
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		stamp := strings.TrimPrefix(name, prefix)
+		stamp = strings.TrimSuffix(stamp, ".gz")
+		if !strings.HasSuffix(stamp, ext) {
+			continue
+		}
+		t, err := time.ParseInLocation(backupTimeFormat, strings.TrimSuffix(stamp, ext), time.Local)
+		if err != nil {
+			continue
+		}
+
+		backups = append(backups, backup{path: filepath.Join(dir, name), time: t})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].time.After(backups[j].time)
+	})
+
+	return backups, nil
+}
+
+// backupPrefix 返回旧文件名的前缀（<name>-）和扩展名
+func backupPrefix(path string) (string, string) {
+	base := filepath.Base(path)
+	ext := filepath.Ext(base)
+	return strings.TrimSuffix(base, ext) + "-", ext
+}
+
+// backupName 返回轮转时的旧文件路径
+func backupName(path string, t time.Time) string {
+	prefix, ext := backupPrefix(path)
+	return filepath.Join(filepath.Dir(path), prefix+t.Format(backupTimeFormat)+ext)
+}
+
+// compressFile 将文件压缩为 <path>.gz 后删除原文件
+// 多个进程可能同时清理，通过 flock 避免重复压缩
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open log file for compression: %w", err)
+	}
+	defer src.Close()
+
+	if err := syscall.Flock(int(src.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		return nil
+	}
+	defer syscall.Flock(int(src.Fd()), syscall.LOCK_UN)
+
+	info, err := src.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	tmp := path + ".gz.tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, info.Mode().Perm())
+	if err != nil {
+		return fmt.Errorf("failed to create compressed log file: %w", err)
+	}
+
+	gz := gzip.NewWriter(dst)
+	_, err = io.Copy(gz, src)
+	if err == nil {
+		err = gz.Close()
+	}
+	if err == nil {
+		err = dst.Sync()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path+".gz")
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to compress log file: %w", err)
+	}
+
+	return os.Remove(path)
+}
@@ -0,0 +1,262 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// syslogSDID 结构化数据 ID（32473 为文档示例使用的企业号）
+const syslogSDID = "fields@32473"
+
+const (
+	syslogDialTimeout  = 2 * time.Second  // 建立连接的超时
+	syslogWriteTimeout = 2 * time.Second  // 单条日志的写入超时，超时的日志被丢弃
+	syslogRetryDelay   = 10 * time.Second // 连接或写入失败后，在此时间内的日志直接丢弃
+)
+
+// syslogFacilities 支持的 facility
+var syslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+	"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// syslogTarget 解析后的 syslog 输出
+type syslogTarget struct {
+	network  string // udp、tcp、unixgram、unix
+	address  string
+	facility int
+}
+
+// IsSyslogOutput 判断输出是否为 syslog URL
+func IsSyslogOutput(output string) bool {
+	return strings.HasPrefix(output, "syslog://") || strings.HasPrefix(output, "syslog+")
+}
+
+// parseSyslogOutput 解析 syslog 输出：
+//
+//	syslog://host:port          UDP（默认端口 514）
+//	syslog+tcp://host:port      TCP，按 RFC 6587 八位组计数分帧
+//	syslog+unix:///dev/log      本地 UNIX socket
+//
+// 可以通过 ?facility=local0 指定 facility（默认 daemon）
+func parseSyslogOutput(output string) (*syslogTarget, error) {
+	u, err := url.Parse(output)
+	if err != nil {
+		return nil, fmt.Errorf("invalid syslog output %q: %w", output, err)
+	}
+
+	target := &syslogTarget{facility: syslogFacilities["daemon"]}
+	if name := u.Query().Get("facility"); name != "" {
+		facility, ok := syslogFacilities[name]
+		if !ok {
+			return nil, fmt.Errorf("invalid syslog output %q: unknown facility %s", output, name)
+		}
+		target.facility = facility
+	}
+
+	switch u.Scheme {
+	case "syslog", "syslog+udp":
+		target.network = "udp"
+	case "syslog+tcp":
+		target.network = "tcp"
+	case "syslog+unix":
+		target.network = "unixgram"
+		target.address = u.Path
+		if target.address == "" {
+			return nil, fmt.Errorf("invalid syslog output %q: missing socket path", output)
+		}
+		return target, nil
+	default:
+		return nil, fmt.Errorf("invalid syslog output %q: unsupported scheme %s", output, u.Scheme)
+	}
+
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid syslog output %q: missing host", output)
+	}
+	target.address = u.Host
+	if u.Port() == "" {
+		target.address = net.JoinHostPort(u.Hostname(), "514")
+	}
+
+	return target, nil
+}
+
+// ValidateOutput 验证日志输出：stdout、stderr、journald、syslog URL 或文件路径
+func ValidateOutput(output string) error {
+	if IsSyslogOutput(output) {
+		_, err := parseSyslogOutput(output)
+		return err
+	}
+	return nil
+}
+
+// syslogWriter 以 RFC 5424 格式发送日志
+// 在第一条日志时才建立连接，连接断开后在下一条日志时重新连接；
+// syslog 不可用或写入阻塞时丢弃日志，不阻塞调用方
+type syslogWriter struct {
+	target   *syslogTarget
+	hostname string
+	appName  string
+	procID   string
+	timeout  time.Duration // 连接和写入超时
+
+	mu         sync.Mutex
+	conn       net.Conn
+	retryAfter time.Time // 失败后在此时间之前丢弃日志
+}
+
+// newSyslogCore 创建写入 syslog 的 core，字段写入结构化数据
+func newSyslogCore(output string, enab zapcore.LevelEnabler) (zapcore.Core, error) {
+	target, err := parseSyslogOutput(output)
+	if err != nil {
+		return nil, err
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+
+	w := &syslogWriter{
+		target:   target,
+		hostname: hostname,
+		appName:  filepath.Base(os.Args[0]),
+		procID:   fmt.Sprintf("%d", os.Getpid()),
+		timeout:  syslogWriteTimeout,
+	}
+
+	return &structuredCore{LevelEnabler: enab, send: w.send}, nil
+}
+
+// connect 建立连接，unix datagram 不可用时尝试 stream
+func (w *syslogWriter) connect() error {
+	conn, err := net.DialTimeout(w.target.network, w.target.address, syslogDialTimeout)
+	if err != nil && w.target.network == "unixgram" {
+		var streamErr error
+		if conn, streamErr = net.DialTimeout("unix", w.target.address, syslogDialTimeout); streamErr == nil {
+			w.target.network = "unix"
+			err = nil
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to syslog %s: %w", w.target.address, err)
+	}
+
+	w.conn = conn
+	return nil
+}
+
+// send 发送一条日志，写入失败时重新连接并重试一次
+// 写入超时时不重试（对端阻塞），连接或写入失败后 syslogRetryDelay 内的日志直接丢弃
+func (w *syslogWriter) send(ent zapcore.Entry, fields []field) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if time.Now().Before(w.retryAfter) {
+		return nil
+	}
+
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if w.conn == nil {
+			if err = w.connect(); err != nil {
+				break
+			}
+		}
+
+		// 分帧方式取决于连接时确定的传输类型
+		msg := w.format(ent, fields)
+		w.conn.SetWriteDeadline(time.Now().Add(w.timeout))
+		if _, err = w.conn.Write(msg); err == nil {
+			return nil
+		}
+
+		// stream 连接上的部分写入会破坏分帧，需要重新连接
+		w.conn.Close()
+		w.conn = nil
+
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			break
+		}
+	}
+
+	w.retryAfter = time.Now().Add(syslogRetryDelay)
+	return fmt.Errorf("failed to write to syslog, dropping messages for %s: %w", syslogRetryDelay, err)
+}
+
+// format 构建 RFC 5424 消息：<PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID [SD] MSG
+// stream 传输（TCP、unix）使用 RFC 6587 八位组计数分帧
+func (w *syslogWriter) format(ent zapcore.Entry, fields []field) []byte {
+	msgID := "-"
+	if ent.LoggerName != "" {
+		msgID = syslogName(ent.LoggerName, 32)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<%d>1 %s %s %s %s %s ",
+		w.target.facility*8+priority(ent.Level),
+		ent.Time.UTC().Format("2006-01-02T15:04:05.000000Z07:00"),
+		syslogName(w.hostname, 255), syslogName(w.appName, 48), w.procID, msgID)
+
+	if len(fields) == 0 {
+		b.WriteString("-")
+	} else {
+		b.WriteString("[" + syslogSDID)
+		for _, f := range fields {
+			fmt.Fprintf(&b, " %s=\"%s\"", syslogName(f.key, 32), escapeSDValue(f.value))
+		}
+		b.WriteString("]")
+	}
+
+	b.WriteString(" ")
+	b.WriteString(ent.Message)
+	if ent.Stack != "" {
+		b.WriteString("\n")
+		b.WriteString(ent.Stack)
+	}
+
+	msg := b.String()
+	if w.target.network == "tcp" || w.target.network == "unix" {
+		msg = fmt.Sprintf("%d %s", len(msg), msg)
+	}
+	return []byte(msg)
+}
+
+// syslogName 将名称限制为可打印 ASCII（不含空格、=、]、"）并截断到最大长度
+func syslogName(name string, max int) string {
+	var b strings.Builder
+	for _, r := range name {
+		if r <= ' ' || r > '~' || r == '=' || r == ']' || r == '"' {
+			b.WriteByte('_')
+		} else {
+			b.WriteRune(r)
+		}
+	}
+
+	s := b.String()
+	if s == "" {
+		return "-"
+	}
+	if len(s) > max {
+		s = s[:max]
+	}
+	return s
+}
+
+// escapeSDValue 转义结构化数据参数值中的 "、\ 和 ]
+func escapeSDValue(value string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+	return r.Replace(value)
+}
@@ -0,0 +1,205 @@
+package logger
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestParseSyslogOutput(t *testing.T) {
+	tests := []struct {
+		output   string
+		network  string
+		address  string
+		facility int
+		wantErr  string
+	}{
+		{output: "syslog://logs.example.com", network: "udp", address: "logs.example.com:514", facility: 3},
+		{output: "syslog+udp://10.0.0.1:1514", network: "udp", address: "10.0.0.1:1514", facility: 3},
+		{output: "syslog+tcp://logs.example.com:6514?facility=local0", network: "tcp", address: "logs.example.com:6514", facility: 16},
+		{output: "syslog+unix:///dev/log", network: "unixgram", address: "/dev/log", facility: 3},
+		{output: "syslog+unix://", wantErr: "missing socket path"},
+		{output: "syslog://", wantErr: "missing host"},
+		{output: "syslog+http://host", wantErr: "unsupported scheme"},
+		{output: "syslog://host?facility=nope", wantErr: "unknown facility nope"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.output, func(t *testing.T) {
+			target, err := parseSyslogOutput(tt.output)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseSyslogOutput() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if target.network != tt.network || target.address != tt.address || target.facility != tt.facility {
+				t.Fatalf("parseSyslogOutput() = %+v, want %s %s %d", target, tt.network, tt.address, tt.facility)
+			}
+		})
+	}
+}
+
+func TestSyslogUDP(t *testing.T) {
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	core, err := newSyslogCore("syslog://"+listener.LocalAddr().String()+"?facility=local0", zapcore.DebugLevel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zap.New(core).Named("daemon").Warn("Injected agent",
+		zap.Int("pid", 42), zap.String("agent", `a"b]c\d`))
+
+	buf := make([]byte, 4096)
+	listener.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := listener.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := string(buf[:n])
+
+	// local0(16)*8 + warning(4) = 132
+	if !strings.HasPrefix(msg, "<132>1 ") {
+		t.Fatalf("unexpected header: %q", msg)
+	}
+	if !strings.Contains(msg, ` daemon [fields@32473 agent="a\"b\]c\\d" pid="42"] Injected agent`) {
+		t.Fatalf("unexpected message: %q", msg)
+	}
+}
+
+func TestSyslogTCPFraming(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		buf := make([]byte, 4096)
+		n, _ := conn.Read(buf)
+		received <- string(buf[:n])
+	}()
+
+	core, err := newSyslogCore("syslog+tcp://"+listener.Addr().String(), zapcore.InfoLevel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zap.New(core).Info("hello")
+
+	select {
+	case msg := <-received:
+		length, rest, ok := strings.Cut(msg, " ")
+		if !ok || length != strconv.Itoa(len(rest)) {
+			t.Fatalf("message is not octet-counted: %q", msg)
+		}
+		if !strings.HasSuffix(rest, " - hello") {
+			t.Fatalf("unexpected message: %q", rest)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+	}
+}
+
+func TestSyslogUnreachable(t *testing.T) {
+	// 找一个没有监听的端口
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	// 创建时不连接，不可达的 syslog 不影响初始化
+	core, err := newSyslogCore("syslog+tcp://"+addr, zapcore.InfoLevel)
+	if err != nil {
+		t.Fatalf("newSyslogCore() error = %v, want lazy connect", err)
+	}
+
+	ent := zapcore.Entry{Level: zapcore.InfoLevel, Time: time.Now(), Message: "hello"}
+	if err := core.Write(ent, nil); err == nil {
+		t.Fatal("Write() to unreachable syslog succeeded")
+	}
+	// 重试间隔内的日志直接丢弃
+	if err := core.Write(ent, nil); err != nil {
+		t.Fatalf("Write() during retry delay error = %v, want dropped", err)
+	}
+}
+
+func TestSyslogStalledRelay(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	// 接受连接但从不读取
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		if conn, err := listener.Accept(); err == nil {
+			accepted <- conn
+		}
+	}()
+	defer func() {
+		select {
+		case conn := <-accepted:
+			conn.Close()
+		default:
+		}
+	}()
+
+	target, err := parseSyslogOutput("syslog+tcp://" + listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &syslogWriter{target: target, hostname: "host", appName: "app", procID: "1", timeout: 50 * time.Millisecond}
+
+	ent := zapcore.Entry{Level: zapcore.InfoLevel, Time: time.Now(), Message: strings.Repeat("x", 64*1024)}
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		start := time.Now()
+		err := w.send(ent, nil)
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Fatalf("send() blocked for %s", elapsed)
+		}
+		if err != nil {
+			if !strings.Contains(err.Error(), "timeout") {
+				t.Fatalf("send() error = %v, want timeout", err)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("send() never timed out")
+		}
+	}
+
+	if err := w.send(ent, nil); err != nil {
+		t.Fatalf("send() during retry delay error = %v, want dropped", err)
+	}
+}
+
+func TestEscapeSDValue(t *testing.T) {
+	if got := escapeSDValue(`a"b]c\d`); got != `a\"b\]c\\d` {
+		t.Fatalf("escapeSDValue() = %q", got)
+	}
+	if got := syslogName("my app=1", 5); got != "my_ap" {
+		t.Fatalf("syslogName() = %q", got)
+	}
+}
@@ -0,0 +1,178 @@
+package pidfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// RunningError 另一个实例正持有 PID 文件锁
+type RunningError struct {
+	Path string
+	PID  int // 持有锁的进程 PID（无法读取时为 0）
+}
+
+func (e *RunningError) Error() string {
+	if e.PID > 0 {
+		return fmt.Sprintf("another instance is already running (pid %d, pid file %s)", e.PID, e.Path)
+	}
+	return fmt.Sprintf("another instance is already running (pid file %s)", e.Path)
+}
+
+// IsRunning 判断错误是否表示另一个实例正在运行
+func IsRunning(err error) bool {
+	var running *RunningError
+	return errors.As(err, &running)
+}
+
+// PidFile 持有 flock 的 PID 文件
+// 锁随进程退出由内核自动释放，因此进程崩溃后残留的文件不会阻止下一个实例启动
+type PidFile struct {
+	path  string
+	file  *os.File
+	stale int // 接管的过期 PID 文件中记录的 PID
+}
+
+// Acquire 创建并锁定 PID 文件，写入当前进程 PID
+// 其他进程已持有锁时返回 *RunningError；文件存在但未被锁定（上次异常退出）时视为过期并接管
+func Acquire(path string) (*PidFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create pid file directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pid file: %w", err)
+	}
+
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		pid, _ := readPID(file)
+		file.Close()
+		if err == syscall.EWOULDBLOCK {
+			return nil, &RunningError{Path: path, PID: pid}
+		}
+		return nil, fmt.Errorf("failed to lock pid file: %w", err)
+	}
+
+	// 加锁期间文件可能已被上一个实例删除，此时锁住的是一个已脱离路径的 inode
+	if !samePath(file, path) {
+		file.Close()
+		return Acquire(path)
+	}
+
+	stale, _ := readPID(file)
+
+	if err := file.Truncate(0); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to truncate pid file: %w", err)
+	}
+	if _, err := file.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to write pid file: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to sync pid file: %w", err)
+	}
+
+	return &PidFile{path: path, file: file, stale: stale}, nil
+}
+
+// StalePID 返回接管的过期 PID 文件中记录的 PID（没有过期文件时为 0）
+func (p *PidFile) StalePID() int {
+	return p.stale
+}
+
+// Path 返回 PID 文件路径
+func (p *PidFile) Path() string {
+	return p.path
+}
+
+// Release 删除 PID 文件并释放锁
+func (p *PidFile) Release() error {
+	if p.file == nil {
+		return nil
+	}
+
+	// 先删除再解锁，避免删除其他实例刚写入的文件
+	err := os.Remove(p.path)
+	if closeErr := p.file.Close(); err == nil {
+		err = closeErr
+	}
+	p.file = nil
+
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove pid file: %w", err)
+	}
+	return nil
+}
+
+// Check 检查 PID 文件是否被某个运行中的实例锁定，返回该实例的 PID
+// 文件不存在或未被锁定（过期）时返回 0
+func Check(path string) (int, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to open pid file: %w", err)
+	}
+	defer file.Close()
+
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_SH|syscall.LOCK_NB); err != nil {
+		if err == syscall.EWOULDBLOCK {
+			pid, _ := readPID(file)
+			return pid, nil
+		}
+		return 0, fmt.Errorf("failed to check pid file lock: %w", err)
+	}
+	syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+
+	return 0, nil
+}
+
+// readPID 读取 PID 文件内容
+func readPID(file *os.File) (int, error) {
+	buf := make([]byte, 32)
+	n, err := file.ReadAt(buf, 0)
+	if n == 0 && err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(buf[:n])))
+}
+
+// samePath 判断打开的文件是否仍是 path 指向的文件
+func samePath(file *os.File, path string) bool {
+	opened, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	current, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(opened, current)
+}
+
+// WaitReleased 等待持有 PID 文件锁的进程退出，超时后返回错误
+func WaitReleased(path string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		running, err := Check(path)
+		if err != nil {
+			return err
+		}
+		if running == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("daemon (pid %d) did not exit within %s", running, timeout)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
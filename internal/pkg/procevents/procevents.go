@@ -0,0 +1,194 @@
+package procevents
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+)
+
+// netlink 进程连接器常量（linux/connector.h、linux/cn_proc.h）
+const (
+	cnIdxProc = 1
+	cnValProc = 1
+
+	procCnMcastListen = 1
+	procCnMcastIgnore = 2
+
+	procEventExec = 0x00000002
+	procEventExit = 0x80000000
+
+	nlmsgHdrLen  = syscall.NLMSG_HDRLEN
+	cnMsgHdrLen  = 20 // idx, val, seq, ack (u32) + len, flags (u16)
+	procEventLen = 16 // what, cpu (u32) + timestamp_ns (u64)
+)
+
+// EventType 进程事件类型
+type EventType int
+
+const (
+	Exec EventType = iota // 进程执行了新程序
+	Exit                  // 进程退出
+)
+
+// String 返回事件类型名称
+func (t EventType) String() string {
+	switch t {
+	case Exec:
+		return "exec"
+	case Exit:
+		return "exit"
+	}
+	return "unknown"
+}
+
+// Event 进程事件
+type Event struct {
+	Type EventType
+	PID  int
+}
+
+// Listener netlink 进程事件监听器（需要 CAP_NET_ADMIN）
+type Listener struct {
+	fd int
+}
+
+// Listen 订阅内核进程事件
+func Listen() (*Listener, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_DGRAM, syscall.NETLINK_CONNECTOR)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create netlink socket: %w", err)
+	}
+
+	addr := &syscall.SockaddrNetlink{
+		Family: syscall.AF_NETLINK,
+		Groups: cnIdxProc,
+		Pid:    uint32(os.Getpid()),
+	}
+	if err := syscall.Bind(fd, addr); err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("failed to bind netlink socket: %w", err)
+	}
+
+	// 定期超时返回，以便检查 context 是否已取消
+	tv := syscall.Timeval{Sec: 1}
+	if err := syscall.SetsockoptTimeval(fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &tv); err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("failed to set socket timeout: %w", err)
+	}
+
+	l := &Listener{fd: fd}
+	if err := l.control(procCnMcastListen); err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("failed to subscribe to process events: %w", err)
+	}
+
+	return l, nil
+}
+
+// Run 接收进程事件并发送到 events，直到 context 取消或出错
+func (l *Listener) Run(ctx context.Context, events chan<- Event) error {
+	buf := make([]byte, os.Getpagesize())
+
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		n, _, err := syscall.Recvfrom(l.fd, buf, 0)
+		if err != nil {
+			if err == syscall.EAGAIN || err == syscall.EWOULDBLOCK || err == syscall.EINTR {
+				continue
+			}
+			// 事件过多时内核丢弃消息，继续接收（由定期全量扫描补偿）
+			if err == syscall.ENOBUFS {
+				continue
+			}
+			return fmt.Errorf("failed to receive process events: %w", err)
+		}
+
+		msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			continue
+		}
+
+		for _, msg := range msgs {
+			event, ok := parseEvent(msg.Data)
+			if !ok {
+				continue
+			}
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+// Close 取消订阅并关闭监听器
+func (l *Listener) Close() error {
+	l.control(procCnMcastIgnore)
+	return syscall.Close(l.fd)
+}
+
+// control 发送订阅控制消息
+func (l *Listener) control(op uint32) error {
+	buf := make([]byte, nlmsgHdrLen+cnMsgHdrLen+4)
+	order := binary.NativeEndian
+
+	// nlmsghdr
+	order.PutUint32(buf[0:], uint32(len(buf)))
+	order.PutUint16(buf[4:], syscall.NLMSG_DONE)
+	order.PutUint16(buf[6:], 0)
+	order.PutUint32(buf[8:], 0)
+	order.PutUint32(buf[12:], uint32(os.Getpid()))
+
+	// cn_msg
+	cn := buf[nlmsgHdrLen:]
+	order.PutUint32(cn[0:], cnIdxProc)
+	order.PutUint32(cn[4:], cnValProc)
+	order.PutUint32(cn[8:], uint32(time.Now().Unix()))
+	order.PutUint32(cn[12:], 0)
+	order.PutUint16(cn[16:], 4)
+	order.PutUint16(cn[18:], 0)
+
+	// proc_cn_mcast_op
+	order.PutUint32(cn[cnMsgHdrLen:], op)
+
+	return syscall.Sendto(l.fd, buf, 0, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK})
+}
+
+// parseEvent 解析 cn_msg 中的 proc_event，只关心进程（非线程）的 exec 和 exit
+func parseEvent(data []byte) (Event, bool) {
+	if len(data) < cnMsgHdrLen+procEventLen+8 {
+		return Event{}, false
+	}
+	order := binary.NativeEndian
+
+	if order.Uint32(data[0:]) != cnIdxProc || order.Uint32(data[4:]) != cnValProc {
+		return Event{}, false
+	}
+
+	ev := data[cnMsgHdrLen:]
+	what := order.Uint32(ev[0:])
+	body := ev[procEventLen:]
+	pid := int(order.Uint32(body[0:]))
+	tgid := int(order.Uint32(body[4:]))
+
+	// 线程事件的 pid 与 tgid 不同
+	if pid != tgid {
+		return Event{}, false
+	}
+
+	switch what {
+	case procEventExec:
+		return Event{Type: Exec, PID: tgid}, true
+	case procEventExit:
+		return Event{Type: Exit, PID: tgid}, true
+	}
+
+	return Event{}, false
+}
@@ -0,0 +1,216 @@
+package procfs
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Socket 进程的 TCP socket
+type Socket struct {
+	Proto      string `json:"proto"` // tcp 或 tcp6
+	LocalAddr  string `json:"local_addr"`
+	LocalPort  int    `json:"local_port"`
+	RemoteAddr string `json:"remote_addr,omitempty"`
+	RemotePort int    `json:"remote_port,omitempty"`
+	State      string `json:"state"` // LISTEN、ESTABLISHED 等
+	Inode      uint64 `json:"-"`
+}
+
+// TCP 状态，参考 include/net/tcp_states.h
+const (
+	StateEstablished = "ESTABLISHED"
+	StateListen      = "LISTEN"
+)
+
+// tcpStates /proc/net/tcp 中 st 字段对应的状态
+var tcpStates = map[string]string{
+	"01": StateEstablished,
+	"02": "SYN_SENT",
+	"03": "SYN_RECV",
+	"04": "FIN_WAIT1",
+	"05": "FIN_WAIT2",
+	"06": "TIME_WAIT",
+	"07": "CLOSE",
+	"08": "CLOSE_WAIT",
+	"09": "LAST_ACK",
+	"0A": StateListen,
+	"0B": "CLOSING",
+}
+
+// ReadSockets 读取进程打开的 TCP socket
+// 通过 /proc/[pid]/fd 中的 socket:[inode] 与进程所在网络命名空间的 /proc/[pid]/net/tcp{,6} 关联，
+// 读取其他用户进程的 fd 需要 root 权限
+func ReadSockets(pid int) ([]Socket, error) {
+	inodes, err := socketInodes(pid)
+	if err != nil {
+		return nil, err
+	}
+	if len(inodes) == 0 {
+		return nil, nil
+	}
+
+	var sockets []Socket
+	for _, proto := range []string{"tcp", "tcp6"} {
+		entries, err := readTCPTable(fmt.Sprintf("/proc/%d/net/%s", pid, proto), proto)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if inodes[entry.Inode] {
+				sockets = append(sockets, entry)
+			}
+		}
+	}
+
+	sort.SliceStable(sockets, func(i, j int) bool {
+		if sockets[i].State != sockets[j].State {
+			return sockets[i].State == StateListen
+		}
+		return sockets[i].LocalPort < sockets[j].LocalPort
+	})
+
+	return sockets, nil
+}
+
+// socketInodes 读取进程打开的 socket inode
+func socketInodes(pid int) (map[uint64]bool, error) {
+	dir := fmt.Sprintf("/proc/%d/fd", pid)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fds: %w", err)
+	}
+
+	inodes := make(map[uint64]bool)
+	for _, entry := range entries {
+		link, err := os.Readlink(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		value, ok := strings.CutPrefix(link, "socket:[")
+		if !ok {
+			continue
+		}
+		if inode, err := strconv.ParseUint(strings.TrimSuffix(value, "]"), 10, 64); err == nil {
+			inodes[inode] = true
+		}
+	}
+
+	return inodes, nil
+}
+
+// readTCPTable 解析 /proc/net/tcp 格式的文件，文件不存在（如未启用 IPv6）时返回空
+func readTCPTable(path, proto string) ([]Socket, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	defer file.Close()
+
+	var sockets []Socket
+	scanner := bufio.NewScanner(file)
+	scanner.Scan() // 表头
+	for scanner.Scan() {
+		// sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode ...
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			continue
+		}
+
+		localAddr, localPort, err := parseSocketAddr(fields[1])
+		if err != nil {
+			continue
+		}
+		remoteAddr, remotePort, err := parseSocketAddr(fields[2])
+		if err != nil {
+			continue
+		}
+		inode, err := strconv.ParseUint(fields[9], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		state, ok := tcpStates[fields[3]]
+		if !ok {
+			state = fields[3]
+		}
+
+		socket := Socket{
+			Proto:     proto,
+			LocalAddr: localAddr,
+			LocalPort: localPort,
+			State:     state,
+			Inode:     inode,
+		}
+		if state != StateListen {
+			socket.RemoteAddr, socket.RemotePort = remoteAddr, remotePort
+		}
+		sockets = append(sockets, socket)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return sockets, nil
+}
+
+// parseSocketAddr 解析 地址:端口，地址为按 32 位字以本机字节序存储的十六进制
+func parseSocketAddr(s string) (string, int, error) {
+	addrHex, portHex, ok := strings.Cut(s, ":")
+	if !ok {
+		return "", 0, fmt.Errorf("invalid address %q", s)
+	}
+
+	raw, err := hex.DecodeString(addrHex)
+	if err != nil || (len(raw) != net.IPv4len && len(raw) != net.IPv6len) {
+		return "", 0, fmt.Errorf("invalid address %q", s)
+	}
+	port, err := strconv.ParseUint(portHex, 16, 16)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid port %q", s)
+	}
+
+	ip := make(net.IP, len(raw))
+	for i := 0; i < len(raw); i += 4 {
+		binary.BigEndian.PutUint32(ip[i:], binary.NativeEndian.Uint32(raw[i:]))
+	}
+
+	return ip.String(), int(port), nil
+}
+
+// 内核默认的本地临时端口范围
+const (
+	defaultEphemeralLow  = 32768
+	defaultEphemeralHigh = 60999
+)
+
+// EphemeralPortRange 返回本地临时端口范围（/proc/sys/net/ipv4/ip_local_port_range），读取失败时返回内核默认值
+// 监听 0 端口的服务（JMX RMI、server.port=0 等）每次启动分配的端口都在此范围内
+func EphemeralPortRange() (int, int) {
+	data, err := os.ReadFile("/proc/sys/net/ipv4/ip_local_port_range")
+	if err != nil {
+		return defaultEphemeralLow, defaultEphemeralHigh
+	}
+
+	fields := strings.Fields(string(data))
+	if len(fields) != 2 {
+		return defaultEphemeralLow, defaultEphemeralHigh
+	}
+	low, errLow := strconv.Atoi(fields[0])
+	high, errHigh := strconv.Atoi(fields[1])
+	if errLow != nil || errHigh != nil || low > high {
+		return defaultEphemeralLow, defaultEphemeralHigh
+	}
+	return low, high
+}
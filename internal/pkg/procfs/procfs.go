@@ -7,36 +7,38 @@ import (
 	"os/user"
 	"strconv"
 	"strings"
-	"syscall"
+	"sync"
 	"time"
 )
 
 // Process 进程信息
 type Process struct {
-	PID        int            `json:"pid"`
-	Name       string         `json:"name"`
-	CmdLine    []string       `json:"cmdline"`
-	Envs       map[string]string `json:"envs"`
-	User       string         `json:"user"`
-	UID        int            `json:"uid"`
-	StartTime  time.Time      `json:"start_time"`
-	Cwd        string         `json:"cwd"`
-	ExecPath   string         `json:"exec_path"`
+	PID       int               `json:"pid"`
+	Name      string            `json:"name"`
+	CmdLine   []string          `json:"cmdline"`
+	Envs      map[string]string `json:"envs"`
+	User      string            `json:"user"`
+	UID       int               `json:"uid"`
+	GID       int               `json:"gid"`
+	Groups    []int             `json:"groups"` // 附加组 GID
+	StartTime time.Time         `json:"start_time"`
+	Cwd       string            `json:"cwd"`
+	ExecPath  string            `json:"exec_path"`
 	// 新增元数据
-	MemoryRSS  uint64         `json:"memory_rss"`    // 驻留内存大小 (bytes)
-	MemoryVMS  uint64         `json:"memory_vms"`    // 虚拟内存大小 (bytes)
-	CPUPercent float64        `json:"cpu_percent"`   // CPU 使用率
-	Threads    int            `json:"threads"`       // 线程数
-	OpenFDs    int            `json:"open_fds"`      // 打开的文件描述符数量
+	MemoryRSS  uint64  `json:"memory_rss"`  // 驻留内存大小 (bytes)
+	MemoryVMS  uint64  `json:"memory_vms"`  // 虚拟内存大小 (bytes)
+	CPUPercent float64 `json:"cpu_percent"` // CPU 使用率
+	Threads    int     `json:"threads"`     // 线程数
+	OpenFDs    int     `json:"open_fds"`    // 打开的文件描述符数量
 }
 
 // MemoryStats 内存统计信息
 type MemoryStats struct {
-	RSS    uint64  // 驻留集大小
-	VMS    uint64  // 虚拟内存大小
-	Shared uint64  // 共享内存大小
-	Text   uint64  // 代码段大小
-	Data   uint64  // 数据段大小
+	RSS    uint64 `json:"rss"`    // 驻留集大小
+	VMS    uint64 `json:"vms"`    // 虚拟内存大小
+	Shared uint64 `json:"shared"` // 共享内存大小
+	Text   uint64 `json:"text"`   // 代码段大小
+	Data   uint64 `json:"data"`   // 数据段大小
 }
 
 // ReadCmdline 读取进程命令行参数
@@ -145,6 +147,13 @@ func ReadStatus(pid int) (*ProcessStatus, error) {
 					status.GID = gid
 				}
 			}
+		case "Groups":
+			// 附加组列表
+			for _, field := range strings.Fields(value) {
+				if gid, err := strconv.Atoi(field); err == nil {
+					status.Groups = append(status.Groups, gid)
+				}
+			}
 		}
 	}
 
@@ -159,6 +168,7 @@ type ProcessStatus struct {
 	PPID   int
 	UID    int
 	GID    int
+	Groups []int // 附加组 GID
 }
 
 // ReadCwd 读取进程工作目录
@@ -185,6 +195,40 @@ func ReadExe(pid int) (string, error) {
 	return exe, nil
 }
 
+// userHZ /proc 中时间字段的时钟节拍频率
+const userHZ = 100
+
+var (
+	bootTimeOnce sync.Once
+	bootTime     time.Time
+	bootTimeErr  error
+)
+
+// BootTime 返回系统启动时间（/proc/stat 中的 btime）
+// 与用当前时间减去 uptime 不同，btime 是固定值，多次计算的进程启动时间不会因取整而抖动
+func BootTime() (time.Time, error) {
+	bootTimeOnce.Do(func() {
+		data, err := os.ReadFile("/proc/stat")
+		if err != nil {
+			bootTimeErr = fmt.Errorf("failed to read /proc/stat: %w", err)
+			return
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if value, ok := strings.CutPrefix(line, "btime "); ok {
+				seconds, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+				if err != nil {
+					bootTimeErr = fmt.Errorf("invalid btime in /proc/stat: %w", err)
+					return
+				}
+				bootTime = time.Unix(seconds, 0)
+				return
+			}
+		}
+		bootTimeErr = fmt.Errorf("btime not found in /proc/stat")
+	})
+	return bootTime, bootTimeErr
+}
+
 // GetStartTime 获取进程启动时间
 func GetStartTime(pid int) (time.Time, error) {
 	path := fmt.Sprintf("/proc/%d/stat", pid)
@@ -195,28 +239,31 @@ func GetStartTime(pid int) (time.Time, error) {
 	}
 
 	// stat 文件格式，参考 man 5 proc
-	// 获取 starttime（字段 22）
-	parts := strings.Fields(string(data))
-	if len(parts) < 22 {
+	// 进程名（字段 2）可能包含空格，从最后一个 ')' 之后开始解析，starttime 为字段 22
+	stat := string(data)
+	idx := strings.LastIndex(stat, ")")
+	if idx < 0 {
+		return time.Time{}, fmt.Errorf("invalid stat format")
+	}
+	parts := strings.Fields(stat[idx+1:])
+	if len(parts) < 20 {
 		return time.Time{}, fmt.Errorf("invalid stat format")
 	}
 
 	// 获取系统启动时间
-	var sysInfo syscall.Sysinfo_t
-	if err := syscall.Sysinfo(&sysInfo); err != nil {
-		return time.Time{}, fmt.Errorf("failed to get sysinfo: %w", err)
+	bootTime, err := BootTime()
+	if err != nil {
+		return time.Time{}, err
 	}
 
-	bootTime := time.Now().Add(-time.Duration(sysInfo.Uptime) * time.Second)
-
 	// 解析 starttime（单位是 jiffies，即 clock ticks）
-	startTimeTicks, err := strconv.ParseInt(parts[21], 10, 64)
+	startTimeTicks, err := strconv.ParseInt(parts[19], 10, 64)
 	if err != nil {
 		return time.Time{}, fmt.Errorf("failed to parse starttime: %w", err)
 	}
 
-	// 获取 clock ticks
-	clockTicks := int64(syscall.Getpagesize())
+	// 获取 clock ticks（USER_HZ，Linux 上固定为 100）
+	clockTicks := int64(userHZ)
 
 	// 计算启动时间
 	startTime := bootTime.Add(time.Duration(startTimeTicks*1000/clockTicks) * time.Millisecond)
@@ -224,6 +271,34 @@ func GetStartTime(pid int) (time.Time, error) {
 	return startTime, nil
 }
 
+// ReadCgroup 读取进程 cgroup 信息（每行格式：hierarchy-ID:controllers:path）
+func ReadCgroup(pid int) ([]string, error) {
+	path := fmt.Sprintf("/proc/%d/cgroup", pid)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cgroup: %w", err)
+	}
+
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines, nil
+}
+
+// GetGroupName 获取组名
+func GetGroupName(gid int) (string, error) {
+	g, err := user.LookupGroupId(strconv.Itoa(gid))
+	if err != nil {
+		return "", fmt.Errorf("failed to lookup group: %w", err)
+	}
+	return g.Name, nil
+}
+
 // GetUserName 获取用户名
 func GetUserName(uid int) (string, error) {
 	u, err := user.LookupId(strconv.Itoa(uid))
@@ -303,6 +378,8 @@ func GetProcessInfo(pid int) (*Process, error) {
 		Envs:       envs,
 		User:       userName,
 		UID:        status.UID,
+		GID:        status.GID,
+		Groups:     status.Groups,
 		StartTime:  startTime,
 		Cwd:        cwd,
 		ExecPath:   exe,
@@ -347,20 +424,20 @@ func ReadMemoryStats(pid int) (*MemoryStats, error) {
 		return nil, fmt.Errorf("failed to read statm: %w", err)
 	}
 
-	// statm 格式：rss vms shared text data lib dt
+	// statm 格式：size(vms) resident(rss) shared text lib data dt，单位为页
 	fields := strings.Fields(string(data))
 	if len(fields) < 6 {
 		return nil, fmt.Errorf("invalid statm format")
 	}
 
-	rss, _ := strconv.ParseUint(fields[0], 10, 64)
-	vms, _ := strconv.ParseUint(fields[1], 10, 64)
+	vms, _ := strconv.ParseUint(fields[0], 10, 64)
+	rss, _ := strconv.ParseUint(fields[1], 10, 64)
 	shared, _ := strconv.ParseUint(fields[2], 10, 64)
 	text, _ := strconv.ParseUint(fields[3], 10, 64)
 	dataSize, _ := strconv.ParseUint(fields[5], 10, 64)
 
-	// 将页面大小转换为字节（通常一页是 4KB）
-	const pageSize = 4096
+	// 将页面数转换为字节
+	pageSize := uint64(os.Getpagesize())
 
 	return &MemoryStats{
 		RSS:    rss * pageSize,
@@ -410,6 +487,29 @@ func ReadOpenFDs(pid int) int {
 	return len(entries)
 }
 
+// ReadOpenFiles 读取进程打开的文件路径（/proc/[pid]/fd 符号链接目标）
+// 无权限读取其他用户进程的 fd 目录时返回错误，调用方不能把它当作没有打开的文件
+func ReadOpenFiles(pid int) ([]string, error) {
+	dir := fmt.Sprintf("/proc/%d/fd", pid)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		target, err := os.Readlink(dir + "/" + entry.Name())
+		if err != nil || !strings.HasPrefix(target, "/") {
+			// 跳过 socket:[...]、pipe:[...] 等非文件描述符
+			continue
+		}
+		files = append(files, target)
+	}
+
+	return files, nil
+}
+
 // CalculateCPUPercent 计算 CPU 使用率（简化版）
 func CalculateCPUPercent(pid int) float64 {
 	// 从 /proc/[pid]/stat 读取 CPU 时间
@@ -433,7 +533,40 @@ func CalculateCPUPercent(pid int) float64 {
 	return 0
 }
 
-// FormatMemory 格式化内存大小
+// ReadCPUTime 读取进程累计使用的 CPU 时间（utime + stime）
+// 两次采样的差值除以采样间隔即为该时段的 CPU 使用率
+func ReadCPUTime(pid int) (time.Duration, error) {
+	path := fmt.Sprintf("/proc/%d/stat", pid)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read stat: %w", err)
+	}
+
+	// utime、stime 为字段 14、15，从最后一个 ')' 之后开始解析
+	stat := string(data)
+	idx := strings.LastIndex(stat, ")")
+	if idx < 0 {
+		return 0, fmt.Errorf("invalid stat format")
+	}
+	parts := strings.Fields(stat[idx+1:])
+	if len(parts) < 13 {
+		return 0, fmt.Errorf("invalid stat format")
+	}
+
+	utime, err := strconv.ParseInt(parts[11], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse utime: %w", err)
+	}
+	stime, err := strconv.ParseInt(parts[12], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse stime: %w", err)
+	}
+
+	return time.Duration((utime+stime)*1000/userHZ) * time.Millisecond, nil
+}
+
+// FormatMemory 格式化内存大小（如 1.5G），用于表格和报告显示
 func FormatMemory(bytes uint64) string {
 	const (
 		KB = 1024
@@ -443,13 +576,12 @@ func FormatMemory(bytes uint64) string {
 
 	switch {
 	case bytes >= GB:
-		return fmt.Sprintf("%.2f GB", float64(bytes)/float64(GB))
+		return fmt.Sprintf("%.1fG", float64(bytes)/float64(GB))
 	case bytes >= MB:
-		return fmt.Sprintf("%.2f MB", float64(bytes)/float64(MB))
+		return fmt.Sprintf("%.1fM", float64(bytes)/float64(MB))
 	case bytes >= KB:
-		return fmt.Sprintf("%.2f KB", float64(bytes)/float64(KB))
+		return fmt.Sprintf("%.1fK", float64(bytes)/float64(KB))
 	default:
-		return fmt.Sprintf("%d B", bytes)
+		return fmt.Sprintf("%dB", bytes)
 	}
 }
-
@@ -0,0 +1,249 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSearchDays 查找下一次触发时间的最大天数
+const maxSearchDays = 366 * 5
+
+// Cron 5 字段 cron 表达式（分 时 日 月 周）
+type Cron struct {
+	minutes  [60]bool
+	hours    [24]bool
+	days     [32]bool
+	months   [13]bool
+	weekdays [7]bool
+
+	// 日和周字段都不以 * 开头时满足任一即可（与 Vixie cron 一致）
+	daysRestricted     bool
+	weekdaysRestricted bool
+}
+
+// field cron 字段定义
+type field struct {
+	name     string
+	min, max int
+}
+
+var fields = []field{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// ParseCron 解析 cron 表达式
+// 支持 *、列表（1,15）、范围（1-5）和步长（*/10、8-18/2），周日可写作 0 或 7
+func ParseCron(expr string) (*Cron, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 fields", expr)
+	}
+
+	c := &Cron{}
+	targets := [][]bool{c.minutes[:], c.hours[:], c.days[:], c.months[:], nil}
+
+	for i, part := range parts {
+		values, err := parseField(part, fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %w", expr, err)
+		}
+
+		if i == 4 {
+			for _, v := range values {
+				c.weekdays[v%7] = true
+			}
+			continue
+		}
+		for _, v := range values {
+			targets[i][v] = true
+		}
+	}
+
+	// 与 Vixie cron 一致，以 * 开头的字段（包括 */2）不视为受限
+	c.daysRestricted = !strings.HasPrefix(parts[2], "*")
+	c.weekdaysRestricted = !strings.HasPrefix(parts[4], "*")
+
+	return c, nil
+}
+
+// parseField 解析单个 cron 字段
+func parseField(text string, f field) ([]int, error) {
+	var values []int
+
+	for _, item := range strings.Split(text, ",") {
+		step := 1
+		if idx := strings.Index(item, "/"); idx >= 0 {
+			n, err := strconv.Atoi(item[idx+1:])
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid step in %s field: %q", f.name, item)
+			}
+			step = n
+			item = item[:idx]
+		}
+
+		lo, hi := f.min, f.max
+		switch {
+		case item == "*":
+		case strings.Contains(item, "-"):
+			bounds := strings.SplitN(item, "-", 2)
+			var err1, err2 error
+			lo, err1 = strconv.Atoi(bounds[0])
+			hi, err2 = strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil {
+				return nil, fmt.Errorf("invalid range in %s field: %q", f.name, item)
+			}
+		default:
+			n, err := strconv.Atoi(item)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value in %s field: %q", f.name, item)
+			}
+			lo = n
+			// 带步长的单个值表示从该值开始到最大值
+			if step == 1 {
+				hi = n
+			}
+		}
+
+		if lo < f.min || hi > f.max || lo > hi {
+			return nil, fmt.Errorf("%s field out of range [%d-%d]: %q", f.name, f.min, f.max, item)
+		}
+
+		for v := lo; v <= hi; v += step {
+			values = append(values, v)
+		}
+	}
+
+	return values, nil
+}
+
+// Next 返回严格晚于 t 的下一次触发时间（精确到分钟），找不到时返回零值
+func (c *Cron) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	loc := t.Location()
+
+	for day := 0; day < maxSearchDays; day++ {
+		if !c.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+
+		for ; t.Hour() < 24; t = t.Add(time.Minute) {
+			if c.hours[t.Hour()] && c.minutes[t.Minute()] {
+				return t
+			}
+			// 跨天时进入下一天的匹配
+			if t.Hour() == 23 && t.Minute() == 59 {
+				break
+			}
+		}
+		t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+	}
+
+	return time.Time{}
+}
+
+// matchDay 检查日期是否匹配日、月、周字段
+func (c *Cron) matchDay(t time.Time) bool {
+	if !c.months[t.Month()] {
+		return false
+	}
+
+	day := c.days[t.Day()]
+	weekday := c.weekdays[t.Weekday()]
+
+	// 日和周都受限时满足任一即可，否则两者都要满足（不受限字段的取值仍可能带步长，如 */2）
+	if c.daysRestricted && c.weekdaysRestricted {
+		return day || weekday
+	}
+	return day && weekday
+}
+
+// Window 维护窗口：从 cron 触发时间开始，持续指定时长
+type Window struct {
+	Name     string
+	Cron     *Cron
+	Duration time.Duration
+	Location *time.Location
+}
+
+// NewWindow 创建维护窗口，timezone 为空时使用本地时区
+func NewWindow(name, expr string, duration time.Duration, timezone string) (*Window, error) {
+	if duration <= 0 {
+		return nil, fmt.Errorf("window duration must be positive")
+	}
+
+	cron, err := ParseCron(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	loc := time.Local
+	if timezone != "" {
+		loc, err = time.LoadLocation(timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone %q: %w", timezone, err)
+		}
+	}
+
+	return &Window{Name: name, Cron: cron, Duration: duration, Location: loc}, nil
+}
+
+// Active 检查时间是否在窗口内，返回所在窗口的开始时间
+func (w *Window) Active(t time.Time) (time.Time, bool) {
+	t = t.In(w.Location)
+	// 在 (t-duration, t] 内存在触发时间即处于窗口中
+	start := w.Cron.Next(t.Add(-w.Duration))
+	if start.IsZero() || start.After(t) {
+		return time.Time{}, false
+	}
+	return start, true
+}
+
+// Next 返回不早于 t 的下一个窗口开始时间（当前处于窗口中时返回 t）
+func (w *Window) Next(t time.Time) time.Time {
+	if _, ok := w.Active(t); ok {
+		return t
+	}
+	return w.Cron.Next(t.In(w.Location))
+}
+
+// Windows 维护窗口集合，为空表示不限制
+type Windows []*Window
+
+// Active 检查时间是否在任一窗口内
+func (ws Windows) Active(t time.Time) bool {
+	if len(ws) == 0 {
+		return true
+	}
+	for _, w := range ws {
+		if _, ok := w.Active(t); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Next 返回最近的窗口开始时间（当前处于窗口中时返回 t），找不到时返回零值
+func (ws Windows) Next(t time.Time) time.Time {
+	if len(ws) == 0 {
+		return t
+	}
+
+	var next time.Time
+	for _, w := range ws {
+		n := w.Next(t)
+		if n.IsZero() {
+			continue
+		}
+		if next.IsZero() || n.Before(next) {
+			next = n
+		}
+	}
+	return next
+}
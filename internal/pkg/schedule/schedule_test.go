@@ -0,0 +1,206 @@
+package schedule
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCronErrors(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr string
+	}{
+		{"* * * *", "expected 5 fields"},
+		{"* * * * * *", "expected 5 fields"},
+		{"60 * * * *", "minute field out of range"},
+		{"* 24 * * *", "hour field out of range"},
+		{"* * 0 * *", "day of month field out of range"},
+		{"* * * 13 *", "month field out of range"},
+		{"* * * * 8", "day of week field out of range"},
+		{"5-1 * * * *", "out of range"},
+		{"*/0 * * * *", "invalid step"},
+		{"*/x * * * *", "invalid step"},
+		{"a * * * *", "invalid value"},
+		{"1-x * * * *", "invalid range"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := ParseCron(tt.expr)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ParseCron(%q) error = %v, want %q", tt.expr, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	// 2026-03-02 是星期一
+	from := time.Date(2026, 3, 2, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want []time.Time // 连续的触发时间
+	}{
+		{
+			name: "every minute is strictly after",
+			expr: "* * * * *",
+			from: from,
+			want: []time.Time{
+				time.Date(2026, 3, 2, 10, 31, 0, 0, time.UTC),
+				time.Date(2026, 3, 2, 10, 32, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "seconds are truncated",
+			expr: "* * * * *",
+			from: from.Add(45 * time.Second),
+			want: []time.Time{time.Date(2026, 3, 2, 10, 31, 0, 0, time.UTC)},
+		},
+		{
+			name: "daily at 02:00",
+			expr: "0 2 * * *",
+			from: from,
+			want: []time.Time{
+				time.Date(2026, 3, 3, 2, 0, 0, 0, time.UTC),
+				time.Date(2026, 3, 4, 2, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "list range and step",
+			expr: "0,30 8-18/4 * * *",
+			from: from,
+			want: []time.Time{
+				time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC),
+				time.Date(2026, 3, 2, 12, 30, 0, 0, time.UTC),
+				time.Date(2026, 3, 2, 16, 0, 0, 0, time.UTC),
+				time.Date(2026, 3, 2, 16, 30, 0, 0, time.UTC),
+				time.Date(2026, 3, 3, 8, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "single value with step runs to max",
+			expr: "50/5 10 * * *",
+			from: from,
+			want: []time.Time{
+				time.Date(2026, 3, 2, 10, 50, 0, 0, time.UTC),
+				time.Date(2026, 3, 2, 10, 55, 0, 0, time.UTC),
+				time.Date(2026, 3, 3, 10, 50, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "sunday as 7",
+			expr: "0 0 * * 7",
+			from: from,
+			want: []time.Time{time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name: "day of month and day of week are ORed when both restricted",
+			expr: "0 0 15 * 5",
+			from: from,
+			want: []time.Time{
+				time.Date(2026, 3, 6, 0, 0, 0, 0, time.UTC),  // 星期五
+				time.Date(2026, 3, 13, 0, 0, 0, 0, time.UTC), // 星期五
+				time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC), // 15 日
+				time.Date(2026, 3, 20, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "day of month step with star day of week",
+			expr: "0 0 */10 * *",
+			from: from,
+			want: []time.Time{
+				time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 3, 21, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "day of month step is not ORed with day of week",
+			expr: "0 0 */2 * 1",
+			from: from,
+			want: []time.Time{
+				time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC),  // 星期一且为奇数日
+				time.Date(2026, 3, 23, 0, 0, 0, 0, time.UTC), // 16 日是偶数日，不匹配
+			},
+		},
+		{
+			name: "day of week step is not ORed with day of month",
+			expr: "0 0 1 * */7",
+			from: from,
+			want: []time.Time{time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)}, // 下一个星期日的 1 日
+		},
+		{
+			name: "month restriction",
+			expr: "0 0 1 1 *",
+			from: from,
+			want: []time.Time{time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name: "leap day",
+			expr: "0 0 29 2 *",
+			from: from,
+			want: []time.Time{time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name: "impossible date",
+			expr: "0 0 31 2 *",
+			from: from,
+			want: []time.Time{{}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron(%q) error = %v", tt.expr, err)
+			}
+			next := tt.from
+			for _, want := range tt.want {
+				next = c.Next(next)
+				if !next.Equal(want) {
+					t.Fatalf("Next() = %v, want %v", next, want)
+				}
+			}
+		})
+	}
+}
+
+func TestWindow(t *testing.T) {
+	w, err := NewWindow("nightly", "0 2 * * *", 2*time.Hour, "UTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		at         time.Time
+		wantActive bool
+		wantNext   time.Time
+	}{
+		{"before window", time.Date(2026, 3, 2, 1, 59, 0, 0, time.UTC), false, time.Date(2026, 3, 2, 2, 0, 0, 0, time.UTC)},
+		{"window start", time.Date(2026, 3, 2, 2, 0, 0, 0, time.UTC), true, time.Date(2026, 3, 2, 2, 0, 0, 0, time.UTC)},
+		{"inside window", time.Date(2026, 3, 2, 3, 30, 0, 0, time.UTC), true, time.Date(2026, 3, 2, 3, 30, 0, 0, time.UTC)},
+		{"window end", time.Date(2026, 3, 2, 4, 0, 0, 0, time.UTC), false, time.Date(2026, 3, 3, 2, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, active := w.Active(tt.at); active != tt.wantActive {
+				t.Fatalf("Active() = %v, want %v", active, tt.wantActive)
+			}
+			if next := w.Next(tt.at); !next.Equal(tt.wantNext) {
+				t.Fatalf("Next() = %v, want %v", next, tt.wantNext)
+			}
+		})
+	}
+
+	if !(Windows{}).Active(time.Now()) {
+		t.Fatal("empty windows should always be active")
+	}
+}
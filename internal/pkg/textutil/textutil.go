@@ -0,0 +1,9 @@
+package textutil
+
+// Truncate 截断字符串，超过 maxLen 时以 ... 结尾
+func Truncate(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	return s[:maxLen-3] + "..."
+}
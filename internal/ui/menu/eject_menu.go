@@ -0,0 +1,106 @@
+package menu
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"iast-auto-inject/internal/core/detector"
+
+	"github.com/fatih/color"
+)
+
+// showEjectMenu 显示移除 Agent 菜单
+func (m *Menu) showEjectMenu() {
+	m.clearScreen()
+	m.printHeader()
+
+	fmt.Println()
+	color.Cyan("                  SecPoint Agent 移除")
+	fmt.Println()
+
+	ctx := context.Background()
+	allProcs, _ := m.detector.DiscoverJavaProcesses(ctx, &detector.ProcessFilter{InScope: true})
+
+	// 列出已附加 SecPoint 的进程
+	var attachedProcs []*detector.JavaProcess
+	for _, proc := range allProcs {
+		if m.detector.FindAgent(proc, detector.SecPointAgentName) != nil {
+			attachedProcs = append(attachedProcs, proc)
+		}
+	}
+
+	if len(attachedProcs) == 0 {
+		color.Yellow("没有已附加 SecPoint Agent 的进程")
+		m.pause()
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PID\tUser\tMain Class/JAR\t来源")
+
+	for _, proc := range attachedProcs {
+		main := proc.MainClass
+		if proc.JarFile != "" {
+			main = proc.JarFile
+		}
+
+		sources := ""
+		for i, agent := range proc.Agents {
+			if i > 0 {
+				sources += ", "
+			}
+			sources += agent.Source
+		}
+
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", proc.PID, proc.User, main, sources)
+	}
+	w.Flush()
+
+	fmt.Println()
+	pid, err := m.readIntInput("输入要移除 SecPoint 的进程 PID: ")
+	if err != nil {
+		color.Red("无效的 PID")
+		m.pause()
+		return
+	}
+
+	var target *detector.JavaProcess
+	for _, proc := range attachedProcs {
+		if proc.PID == pid {
+			target = proc
+			break
+		}
+	}
+
+	if target == nil {
+		color.Red("PID 为 %d 的进程未附加 SecPoint Agent", pid)
+		m.pause()
+		return
+	}
+
+	fmt.Println()
+	confirm := m.readInput("移除将重启该进程，确认移除? (y/N): ")
+	if confirm != "y" && confirm != "Y" {
+		color.Yellow("已取消")
+		m.pause()
+		return
+	}
+
+	// 执行移除
+	fmt.Println()
+	color.Cyan("开始移除...")
+
+	// 通过批量接口执行，与命令行一致地验证新进程并写入审计日志
+	result := m.injector.BatchEject(ctx, []*detector.JavaProcess{target}, detector.SecPointAgentName, nil)[0]
+
+	fmt.Println()
+	if !result.Success {
+		color.Red("✗ 移除失败: %s", result.Message)
+	} else {
+		color.Green("✓ 移除成功，新 PID: %d", result.NewPID)
+	}
+
+	m.pause()
+}
@@ -39,7 +39,7 @@ func (m *Menu) showInjectMenu() {
 
 	var targetProcs []*detector.JavaProcess
 	ctx := context.Background()
-	allProcs, _ := m.detector.DiscoverJavaProcesses(ctx, nil)
+	allProcs, _ := m.detector.DiscoverJavaProcesses(ctx, &detector.ProcessFilter{InScope: true})
 
 	switch choice {
 	case "1":
@@ -100,7 +100,7 @@ func (m *Menu) showInjectMenu() {
 		}
 
 		secPointStatus := red("✗")
-		if len(proc.Agents) > 0 {
+		if proc.HasSecPoint() {
 			secPointStatus = green("✓")
 		}
 
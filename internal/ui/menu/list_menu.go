@@ -4,9 +4,12 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"text/tabwriter"
 
 	"iast-auto-inject/internal/core/detector"
+	"iast-auto-inject/internal/pkg/procfs"
+	"iast-auto-inject/internal/ui/watch"
 
 	"github.com/fatih/color"
 )
@@ -45,7 +48,7 @@ func (m *Menu) showProcessListMenu() {
 
 	for _, proc := range procs {
 		var agentStatus string
-		if len(proc.Agents) > 0 {
+		if proc.HasSecPoint() {
 			agentStatus = green("✓")
 		} else {
 			agentStatus = red("✗")
@@ -60,7 +63,7 @@ func (m *Menu) showProcessListMenu() {
 		}
 
 		// 格式化内存
-		memStr := formatMemory(proc.MemoryRSS)
+		memStr := procfs.FormatMemory(proc.MemoryRSS)
 
 		fmt.Fprintf(w, "%d\t%s\t%s\t%.1f\t%d\t%d\t%s\t\t%s\n",
 			proc.PID, proc.User, memStr, proc.CPUPercent,
@@ -73,23 +76,15 @@ func (m *Menu) showProcessListMenu() {
 	m.pause()
 }
 
-// formatMemory 格式化内存大小
-func formatMemory(bytes uint64) string {
-	const (
-		KB = 1024
-		MB = 1024 * KB
-		GB = 1024 * MB
-	)
-
-	switch {
-	case bytes >= GB:
-		return fmt.Sprintf("%.1fG", float64(bytes)/float64(GB))
-	case bytes >= MB:
-		return fmt.Sprintf("%.1fM", float64(bytes)/float64(MB))
-	case bytes >= KB:
-		return fmt.Sprintf("%.1fK", float64(bytes)/float64(KB))
-	default:
-		return fmt.Sprintf("%dB", bytes)
+// showWatchMenu 实时监控进程列表，按 Ctrl+C 返回菜单
+func (m *Menu) showWatchMenu() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	w := watch.New(m.detector, os.Stdout, watch.Options{Hint: "按 Ctrl+C 返回菜单"})
+	if err := w.Run(ctx); err != nil {
+		color.Red("监控失败: %v", err)
+		m.pause()
 	}
 }
 
@@ -112,7 +107,7 @@ func (m *Menu) showInjectedProcesses() {
 
 	var injectedProcs []*detector.JavaProcess
 	for _, proc := range procs {
-		if len(proc.Agents) > 0 {
+		if proc.HasSecPoint() {
 			injectedProcs = append(injectedProcs, proc)
 		}
 	}
@@ -148,7 +143,7 @@ func (m *Menu) showInjectedProcesses() {
 		}
 
 		// 格式化内存
-		memStr := formatMemory(proc.MemoryRSS)
+		memStr := procfs.FormatMemory(proc.MemoryRSS)
 
 		fmt.Fprintf(w, "%d\t%s\t%s\t%.1f\t%d\t%d\t%s\t\t%s\n",
 			proc.PID, proc.User, memStr, proc.CPUPercent,
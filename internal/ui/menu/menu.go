@@ -16,11 +16,13 @@ import (
 
 // Menu 交互式菜单
 type Menu struct {
-	config    *config.Config
-	detector  *detector.Detector
-	injector  *injector.StaticInjector
-	scanner   *bufio.Scanner
-	running   bool
+	config     *config.Config
+	configFile string // 配置文件路径（启动守护进程时传递）
+	pidFile    string // 守护进程 PID 文件路径
+	detector   *detector.Detector
+	injector   *injector.StaticInjector
+	scanner    *bufio.Scanner
+	running    bool
 }
 
 // NewMenu 创建菜单
@@ -29,11 +31,22 @@ func NewMenu(cfg *config.Config, det *detector.Detector, inj *injector.StaticInj
 		config:   cfg,
 		detector: det,
 		injector: inj,
+		pidFile:  cfg.Daemon.PidFile,
 		scanner:  bufio.NewScanner(os.Stdin),
 		running:  true,
 	}
 }
 
+// SetConfigFile 设置配置文件路径，从菜单启动的守护进程使用同一份配置
+func (m *Menu) SetConfigFile(path string) {
+	m.configFile = path
+}
+
+// SetPidFile 设置守护进程 PID 文件路径（与 daemon 命令解析的路径一致）
+func (m *Menu) SetPidFile(path string) {
+	m.pidFile = path
+}
+
 // Show 显示菜单
 func (m *Menu) Show() error {
 	color.Green("欢迎使用 IAST Auto Inject 交互式菜单！")
@@ -59,10 +72,11 @@ func (m *Menu) showMainMenu() {
 	fmt.Println("  1. 查看进程列表               2. 注入 Agent")
 	fmt.Println("  3. 查看已注入进程           4. 配置管理")
 	fmt.Println("  5. 启动守护进程             6. 系统信息")
+	fmt.Println("  7. 移除 Agent                8. 实时监控进程")
 	fmt.Println("  0. 退出")
 	fmt.Println()
 
-	choice := m.readInput("请选择 [0-6]: ")
+	choice := m.readInput("请选择 [0-8]: ")
 
 	switch choice {
 	case "1":
@@ -77,6 +91,10 @@ func (m *Menu) showMainMenu() {
 		m.showDaemonMenu()
 	case "6":
 		m.showSystemInfo()
+	case "7":
+		m.showEjectMenu()
+	case "8":
+		m.showWatchMenu()
 	case "0", "q", "Q":
 		m.running = false
 	default:
@@ -1,14 +1,23 @@
 package menu
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"text/tabwriter"
+	"time"
+
+	"iast-auto-inject/internal/core/control"
+	"iast-auto-inject/internal/pkg/pidfile"
+	"iast-auto-inject/internal/pkg/redact"
 
 	"github.com/fatih/color"
 )
 
+// daemonStopTimeout 等待守护进程退出的时间
+const daemonStopTimeout = 60 * time.Second
+
 // showConfigMenu 显示配置管理菜单
 func (m *Menu) showConfigMenu() {
 	for {
@@ -97,7 +106,7 @@ func (m *Menu) showAgentConfig() {
 			color.Red(enabled)
 		}
 		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\n",
-			agent.Name, agent.Path, agent.Options, enabled, agent.Priority)
+			agent.Name, agent.Path, redact.Default().Options(agent.Options), enabled, agent.Priority)
 	}
 
 	w.Flush()
@@ -150,7 +159,7 @@ func (m *Menu) showDaemonConfig() {
 	fmt.Fprintf(w, "启用:\t%s\n", enabled)
 	fmt.Fprintf(w, "间隔:\t%v\n", m.config.Daemon.Interval)
 	fmt.Fprintf(w, "日志级别:\t%s\n", m.config.Daemon.LogLevel)
-	fmt.Fprintf(w, "PID 文件:\t%s\n", m.config.Daemon.PidFile)
+	fmt.Fprintf(w, "PID 文件:\t%s\n", m.pidFile)
 	w.Flush()
 
 	m.pause()
@@ -194,33 +203,64 @@ func (m *Menu) showDaemonMenu() {
 	}
 }
 
+// daemonClient 创建守护进程控制 socket 客户端
+func (m *Menu) daemonClient() *control.Client {
+	path := m.config.Daemon.ControlSocket
+	if path == "" {
+		path = control.DefaultSocketPath
+	}
+	return control.NewClient(path)
+}
+
 // showDaemonStatus 显示守护进程状态
 func (m *Menu) showDaemonStatus() {
 	fmt.Println()
 	color.Cyan("守护进程状态:")
 	fmt.Println()
 
-	// 使用 systemctl 检查状态
-	cmd := exec.Command("systemctl", "is-active", "iast-auto-inject")
-	output, _ := cmd.Output()
+	status, err := m.daemonClient().Status()
+	if errors.Is(err, control.ErrNotRunning) {
+		color.Yellow("○ 未运行")
+		m.pause()
+		return
+	}
+	if err != nil {
+		color.Red("查询失败: %v", err)
+		m.pause()
+		return
+	}
 
-	status := string(output)
-	if len(status) > 0 {
-		status = status[:len(status)-1] // 去掉换行符
-		if status == "active" {
-			color.Green("● 运行中: %s", status)
-		} else {
-			color.Red("● 未运行: %s", status)
-		}
+	if status.Paused {
+		color.Yellow("● 已暂停 (PID %d)", status.PID)
 	} else {
-		color.Yellow("○ 未安装服务")
+		color.Green("● 运行中 (PID %d)", status.PID)
 	}
+	fmt.Println()
 
-	// 显示详细状态
-	cmd = exec.Command("systemctl", "status", "iast-auto-inject", "--no-pager")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	cmd.Run()
+	discovery := fmt.Sprintf("轮询（每 %v）", status.Interval)
+	if status.Events {
+		discovery = "进程事件"
+	}
+
+	quarantined := 0
+	for _, entry := range status.Quarantined {
+		if entry.Quarantined {
+			quarantined++
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "启动时间:\t%s\n", status.StartedAt.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(w, "运行时长:\t%v\n", status.Uptime.Round(time.Second))
+	fmt.Fprintf(w, "进程发现:\t%s\n", discovery)
+	fmt.Fprintf(w, "SecPoint:\t%s\n", status.SecPoint)
+	fmt.Fprintf(w, "扫描次数:\t%d\n", status.ScanCount)
+	fmt.Fprintf(w, "注入次数:\t%d\n", status.InjectCount)
+	fmt.Fprintf(w, "升级次数:\t%d\n", status.UpgradeCount)
+	fmt.Fprintf(w, "等待维护窗口:\t%d\n", len(status.Pending))
+	fmt.Fprintf(w, "退避中:\t%d\n", len(status.Quarantined)-quarantined)
+	fmt.Fprintf(w, "已隔离:\t%d\n", quarantined)
+	w.Flush()
 
 	m.pause()
 }
@@ -231,12 +271,20 @@ func (m *Menu) startDaemon() {
 	color.Cyan("启动守护进程...")
 	fmt.Println()
 
-	cmd := exec.Command("sudo", "systemctl", "start", "iast-auto-inject")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	err := cmd.Run()
+	if _, err := m.daemonClient().Status(); err == nil {
+		color.Yellow("守护进程已在运行")
+		m.pause()
+		return
+	}
 
-	if err != nil {
+	secPointPath := m.readInput("请输入 SecPoint.jar 路径: ")
+	if secPointPath == "" {
+		color.Red("SecPoint.jar 路径不能为空")
+		m.pause()
+		return
+	}
+
+	if err := m.spawnDaemon(secPointPath, control.Flags{}); err != nil {
 		color.Red("启动失败: %v", err)
 	} else {
 		color.Green("启动成功")
@@ -245,18 +293,39 @@ func (m *Menu) startDaemon() {
 	m.pause()
 }
 
+// spawnDaemon 以后台模式运行 daemon 命令，命令在守护进程完成初始化后返回
+// flags 为重启时沿用的原守护进程命令行参数
+func (m *Menu) spawnDaemon(secPointPath string, flags control.Flags) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate executable: %w", err)
+	}
+
+	var args []string
+	if m.configFile != "" {
+		args = append(args, "--config", m.configFile)
+	}
+	args = append(args, "daemon", "--secpoint", secPointPath)
+	if flags.AgentVersion != "" {
+		args = append(args, "--agent-version", flags.AgentVersion)
+	}
+	if flags.Interval > 0 {
+		args = append(args, "--interval", flags.Interval.String())
+	}
+
+	cmd := exec.Command(executable, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
 // stopDaemon 停止守护进程
 func (m *Menu) stopDaemon() {
 	fmt.Println()
 	color.Cyan("停止守护进程...")
 	fmt.Println()
 
-	cmd := exec.Command("sudo", "systemctl", "stop", "iast-auto-inject")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	err := cmd.Run()
-
-	if err != nil {
+	if err := m.stopAndWait(); err != nil {
 		color.Red("停止失败: %v", err)
 	} else {
 		color.Green("停止成功")
@@ -265,19 +334,36 @@ func (m *Menu) stopDaemon() {
 	m.pause()
 }
 
-// restartDaemon 重启守护进程
+// stopAndWait 请求守护进程停止并等待其释放 PID 文件
+func (m *Menu) stopAndWait() error {
+	if err := m.daemonClient().Stop(); err != nil {
+		return err
+	}
+
+	return pidfile.WaitReleased(m.pidFile, daemonStopTimeout)
+}
+
+// restartDaemon 重启守护进程（沿用当前的 SecPoint.jar 路径、--agent-version 和 --interval）
 func (m *Menu) restartDaemon() {
 	fmt.Println()
 	color.Cyan("重启守护进程...")
 	fmt.Println()
 
-	cmd := exec.Command("sudo", "systemctl", "restart", "iast-auto-inject")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	err := cmd.Run()
-
+	status, err := m.daemonClient().Status()
 	if err != nil {
 		color.Red("重启失败: %v", err)
+		m.pause()
+		return
+	}
+
+	if err := m.stopAndWait(); err != nil {
+		color.Red("停止失败: %v", err)
+		m.pause()
+		return
+	}
+
+	if err := m.spawnDaemon(status.SecPoint, status.Flags); err != nil {
+		color.Red("启动失败: %v", err)
 	} else {
 		color.Green("重启成功")
 	}
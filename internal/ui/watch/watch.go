@@ -0,0 +1,366 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"iast-auto-inject/internal/core/detector"
+	"iast-auto-inject/internal/pkg/procfs"
+	"iast-auto-inject/internal/pkg/textutil"
+
+	"github.com/fatih/color"
+)
+
+// DefaultInterval 默认刷新间隔
+const DefaultInterval = 2 * time.Second
+
+// highlightFor 新启动、已退出和 agent 状态变化的进程保持高亮的时间
+const highlightFor = 10 * time.Second
+
+// ANSI 控制序列
+const (
+	cursorHome  = "\033[H"
+	clearScreen = "\033[2J"
+	clearLine   = "\033[K"
+	clearBelow  = "\033[J"
+	hideCursor  = "\033[?25l"
+	showCursor  = "\033[?25h"
+)
+
+// markKind 行的高亮类型
+type markKind int
+
+const (
+	markNone    markKind = iota
+	markNew              // 新启动的 JVM
+	markExited           // 已退出的 JVM
+	markChanged          // agent 状态变化
+)
+
+// Options 监控选项
+type Options struct {
+	Interval time.Duration                         // 刷新间隔，为 0 时使用 DefaultInterval
+	Filter   *detector.ProcessFilter               // 发现进程时的过滤器
+	Match    func(proc *detector.JavaProcess) bool // 额外的过滤条件，为 nil 时显示所有进程
+	Sort     func(procs []*detector.JavaProcess)   // 排序，为 nil 时按 PID 排序
+	Hint     string                                // 显示在底部的提示，如退出方式
+}
+
+// row 一个进程在监控中的状态
+type row struct {
+	proc      *detector.JavaProcess
+	cpuTime   time.Duration // 上次采样的累计 CPU 时间
+	sampledAt time.Time     // 上次采样时间
+	cpuKnown  bool          // 是否已有两次采样，可以计算 CPU 使用率
+	mark      markKind
+	markUntil time.Time
+}
+
+// Watcher 原地刷新的进程表，类似 top
+type Watcher struct {
+	det      *detector.Detector
+	opts     Options
+	out      io.Writer
+	terminal bool
+
+	rows    map[string]*row // 进程标识（PID + 启动时间，避免 PID 复用）-> 状态
+	started bool
+}
+
+// New 创建监控，out 为终端时原地刷新，否则依次输出每一帧
+func New(det *detector.Detector, out *os.File, opts Options) *Watcher {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultInterval
+	}
+
+	terminal := false
+	if info, err := out.Stat(); err == nil {
+		terminal = info.Mode()&os.ModeCharDevice != 0
+	}
+
+	return &Watcher{
+		det:      det,
+		opts:     opts,
+		out:      out,
+		terminal: terminal,
+		rows:     make(map[string]*row),
+	}
+}
+
+// Run 定期刷新，直到 ctx 取消
+func (w *Watcher) Run(ctx context.Context) error {
+	if w.terminal {
+		fmt.Fprint(w.out, hideCursor+clearScreen)
+		defer fmt.Fprint(w.out, showCursor)
+	}
+
+	ticker := time.NewTicker(w.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.refresh(ctx); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// refresh 采样一次并重绘
+func (w *Watcher) refresh(ctx context.Context) error {
+	now := time.Now()
+
+	procs, err := w.det.DiscoverJavaProcesses(ctx, w.opts.Filter)
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	// 发现失败时保留上一帧的数据并显示错误，下次刷新重试
+	if err == nil {
+		w.update(procs, now)
+	}
+
+	var buf bytes.Buffer
+	w.render(&buf, now, err)
+
+	_, err = w.out.Write(buf.Bytes())
+	return err
+}
+
+// update 根据本次发现的进程更新状态
+func (w *Watcher) update(procs []*detector.JavaProcess, now time.Time) {
+	seen := make(map[string]bool, len(procs))     // 满足过滤条件的进程
+	filtered := make(map[string]bool, len(procs)) // 仍在运行但不满足过滤条件的进程
+
+	for _, proc := range procs {
+		key := fmt.Sprintf("%d/%s", proc.PID, proc.StartTime)
+		if w.opts.Match != nil && !w.opts.Match(proc) {
+			filtered[key] = true
+			continue
+		}
+		seen[key] = true
+
+		r, ok := w.rows[key]
+		if !ok {
+			r = &row{}
+			w.rows[key] = r
+			// 第一帧的进程都是已有的，不高亮
+			if w.started {
+				r.setMark(markNew, now)
+			}
+		} else if r.mark == markExited {
+			r.mark = markNone
+		} else if agentState(r.proc) != agentState(proc) {
+			r.setMark(markChanged, now)
+		}
+
+		cpuTime, err := procfs.ReadCPUTime(proc.PID)
+		if err == nil {
+			if !r.sampledAt.IsZero() {
+				if elapsed := now.Sub(r.sampledAt); elapsed > 0 {
+					proc.CPUPercent = float64(cpuTime-r.cpuTime) / float64(elapsed) * 100
+					r.cpuKnown = true
+				}
+			}
+			r.cpuTime, r.sampledAt = cpuTime, now
+		}
+
+		r.proc = proc
+	}
+
+	for key, r := range w.rows {
+		if seen[key] {
+			continue
+		}
+
+		switch {
+		case filtered[key]:
+			delete(w.rows, key)
+		case r.mark != markExited:
+			r.setMark(markExited, now)
+		case !now.Before(r.markUntil):
+			delete(w.rows, key)
+		}
+	}
+
+	// 过期的高亮
+	for _, r := range w.rows {
+		if r.mark != markNone && r.mark != markExited && !now.Before(r.markUntil) {
+			r.mark = markNone
+		}
+	}
+
+	w.started = true
+}
+
+// setMark 设置高亮
+func (r *row) setMark(kind markKind, now time.Time) {
+	r.mark = kind
+	r.markUntil = now.Add(highlightFor)
+}
+
+// render 绘制一帧
+func (w *Watcher) render(buf *bytes.Buffer, now time.Time, discoverErr error) {
+	rows := w.sortedRows()
+
+	counts := make(map[markKind]int)
+	running := 0
+	for _, r := range rows {
+		counts[r.mark]++
+		if r.mark != markExited {
+			running++
+		}
+	}
+
+	var lines []string
+
+	summary := fmt.Sprintf("Every %s   %d Java process(es)", w.opts.Interval, running)
+	if n := counts[markNew]; n > 0 {
+		summary += color.GreenString("   +%d new", n)
+	}
+	if n := counts[markExited]; n > 0 {
+		summary += color.RedString("   -%d exited", n)
+	}
+	if n := counts[markChanged]; n > 0 {
+		summary += color.YellowString("   *%d agent changed", n)
+	}
+	lines = append(lines, summary+"   "+now.Format("15:04:05"), "")
+
+	// 表格（agent 状态为最后一列，颜色不影响对齐；高亮的行在对齐后整行着色）
+	var table bytes.Buffer
+	tw := tabwriter.NewWriter(&table, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, " \tPID\tUser\tMemory\tCPU%\tThreads\tFDs\tMain Class/JAR\tAgent")
+	for _, r := range rows {
+		proc := r.proc
+
+		cpu := "-"
+		if r.cpuKnown && r.mark != markExited {
+			cpu = fmt.Sprintf("%.1f", proc.CPUPercent)
+		}
+
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%d\t%d\t%s\t%s\n",
+			markSymbol(r.mark), proc.PID, proc.User, procfs.FormatMemory(proc.MemoryRSS), cpu,
+			proc.Threads, proc.OpenFDs, textutil.Truncate(proc.MainName(), 25), agentText(r))
+	}
+	tw.Flush()
+
+	tableLines := strings.Split(strings.TrimSuffix(table.String(), "\n"), "\n")
+	lines = append(lines, tableLines[0])
+	for i, line := range tableLines[1:] {
+		lines = append(lines, markColor(rows[i].mark)(line))
+	}
+
+	if len(rows) == 0 {
+		lines = append(lines, color.YellowString("No Java processes found"))
+	}
+	if discoverErr != nil {
+		lines = append(lines, "", color.RedString("Failed to discover processes: %v", discoverErr))
+	}
+	if w.opts.Hint != "" {
+		lines = append(lines, "", w.opts.Hint)
+	}
+
+	if !w.terminal {
+		// 非终端（如重定向到文件）时依次输出每一帧
+		for _, line := range lines {
+			buf.WriteString(line + "\n")
+		}
+		buf.WriteString("\n")
+		return
+	}
+
+	// 回到左上角逐行覆盖并清除行尾和下方的残留内容，避免整屏清除造成闪烁
+	buf.WriteString(cursorHome)
+	for _, line := range lines {
+		buf.WriteString(line + clearLine + "\n")
+	}
+	buf.WriteString(clearBelow)
+}
+
+// sortedRows 返回排序后的行
+func (w *Watcher) sortedRows() []*row {
+	procs := make([]*detector.JavaProcess, 0, len(w.rows))
+	byProc := make(map[*detector.JavaProcess]*row, len(w.rows))
+	for _, r := range w.rows {
+		procs = append(procs, r.proc)
+		byProc[r.proc] = r
+	}
+
+	sort.Slice(procs, func(i, j int) bool {
+		return procs[i].PID < procs[j].PID
+	})
+	if w.opts.Sort != nil {
+		w.opts.Sort(procs)
+	}
+
+	rows := make([]*row, len(procs))
+	for i, proc := range procs {
+		rows[i] = byProc[proc]
+	}
+	return rows
+}
+
+// markSymbol 高亮类型在第一列显示的符号
+func markSymbol(kind markKind) string {
+	switch kind {
+	case markNew:
+		return "+"
+	case markExited:
+		return "-"
+	case markChanged:
+		return "*"
+	}
+	return " "
+}
+
+// markColor 高亮类型对应的整行颜色
+func markColor(kind markKind) func(a ...interface{}) string {
+	switch kind {
+	case markNew:
+		return color.New(color.FgGreen).SprintFunc()
+	case markExited:
+		return color.New(color.FgRed).SprintFunc()
+	case markChanged:
+		return color.New(color.FgYellow).SprintFunc()
+	}
+	return fmt.Sprint
+}
+
+// agentText agent 状态列，高亮的行整行着色，不单独着色
+func agentText(r *row) string {
+	if r.mark == markExited {
+		return "exited"
+	}
+
+	attached := r.proc.HasSecPoint()
+	if r.mark != markNone {
+		if attached {
+			return "✓"
+		}
+		return "✗"
+	}
+	if attached {
+		return color.GreenString("✓")
+	}
+	return color.RedString("✗")
+}
+
+// agentState 用于比较 agent 状态的已附加 agent 路径
+func agentState(proc *detector.JavaProcess) string {
+	paths := make([]string, 0, len(proc.Agents))
+	for _, agent := range proc.Agents {
+		paths = append(paths, agent.Path)
+	}
+	return strings.Join(paths, ",")
+}
@@ -143,8 +143,8 @@ After=network.target
 [Service]
 Type=simple
 User=root
-ExecStart=$INSTALL_DIR/$BINARY_NAME daemon --config $CONFIG_DIR/config.yaml
-Restart=always
+ExecStart=$INSTALL_DIR/$BINARY_NAME daemon --no-daemon --config $CONFIG_DIR/config.yaml
+Restart=on-failure
 RestartSec=10
 StandardOutput=journal
 StandardError=journal